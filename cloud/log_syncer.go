@@ -74,7 +74,7 @@ func (s *LogSyncer) NewBuffer(channel LogChannel, out io.Writer) io.Writer {
 		var pending []byte
 		errs := errors.L()
 		for {
-			lines, rest, readErr := readLines(r, pending)
+			lines, rest, readErr := ReadLines(r, pending)
 			if readErr != nil && readErr != io.EOF {
 				errs.Append(readErr)
 				break
@@ -173,7 +173,10 @@ func (s *LogSyncer) enqueue(l *DeploymentLog) {
 	}
 }
 
-func readLines(r io.Reader, pending []byte) (line [][]byte, rest []byte, err error) {
+// ReadLines reads from r until at least one full line is available and
+// returns the lines read (including the line ending) and the remaining
+// incomplete data, which must be given as pending in the next call.
+func ReadLines(r io.Reader, pending []byte) (line [][]byte, rest []byte, err error) {
 	const readSize = 1024
 
 	var buf [readSize]byte
@@ -23,7 +23,7 @@ func BenchmarkCloudReadLines(b *testing.B) {
 		buf := bytes.NewBufferString(bufData)
 		var pending []byte
 		for {
-			_, rest, err := readLines(buf, pending[:])
+			_, rest, err := ReadLines(buf, pending[:])
 			if err != nil {
 				break
 			}
@@ -11,6 +11,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -116,6 +117,8 @@ type cliSpec struct {
 	DisableCheckpoint          bool `optional:"true" default:"false" help:"Disable checkpoint checks for updates"`
 	DisableCheckpointSignature bool `optional:"true" default:"false" help:"Disable checkpoint signature"`
 
+	DisableGenerateCache bool `optional:"true" default:"false" help:"Disable the code generation cache"`
+
 	Create struct {
 		Path           string   `arg:"" optional:"" name:"path" predictor:"file" help:"Path of the new stack relative to the working dir"`
 		ID             string   `help:"ID of the stack, defaults to UUID"`
@@ -135,25 +138,65 @@ type cliSpec struct {
 	} `cmd:"" help:"Format all files inside dir recursively"`
 
 	List struct {
-		Why                bool   `help:"Shows the reason why the stack has changed"`
-		ExperimentalStatus string `help:"Filter by status"`
+		Why                 bool   `help:"Shows the reason why the stack has changed"`
+		ExperimentalStatus  string `help:"Filter by status"`
+		Format              string `default:"text" enum:"text,json,ndjson" help:"Output format: 'text', 'json' or 'ndjson'"`
+		IncludeDependents   bool   `default:"false" help:"Include the stacks ordered after the selected stacks"`
+		IncludeDependencies bool   `default:"false" help:"Include the stacks ordered before the selected stacks"`
 	} `cmd:"" help:"List stacks"`
 
 	Run struct {
-		CloudSyncDeployment        bool     `default:"false" help:"Enable synchronization of stack execution with the Terramate Cloud"`
-		CloudSyncDriftStatus       bool     `default:"false" help:"Enable drift detection and synchronization with the Terramate Cloud"`
-		CloudSyncTerraformPlanFile string   `default:"" help:"Enable sync of Terraform plan file"`
-		DisableCheckGenCode        bool     `default:"false" help:"Disable outdated generated code check"`
-		DisableCheckGitRemote      bool     `default:"false" help:"Disable checking if local default branch is updated with remote"`
-		ContinueOnError            bool     `default:"false" help:"Continue executing in other stacks in case of error"`
-		NoRecursive                bool     `default:"false" help:"Do not recurse into child stacks"`
-		DryRun                     bool     `default:"false" help:"Plan the execution but do not execute it"`
-		Reverse                    bool     `default:"false" help:"Reverse the order of execution"`
-		Eval                       bool     `default:"false" help:"Evaluate command line arguments as HCL strings"`
-		Command                    []string `arg:"" name:"cmd" predictor:"file" passthrough:"" help:"Command to execute"`
+		CloudSyncDeployment        bool           `default:"false" help:"Enable synchronization of stack execution with the Terramate Cloud"`
+		CloudSyncDriftStatus       bool           `default:"false" help:"Enable drift detection and synchronization with the Terramate Cloud"`
+		CloudSyncTerraformPlanFile string         `default:"" help:"Enable sync of Terraform plan file"`
+		DisableCheckGenCode        bool           `default:"false" help:"Disable outdated generated code check"`
+		DisableCheckGitRemote      bool           `default:"false" help:"Disable checking if local default branch is updated with remote"`
+		ContinueOnError            bool           `default:"false" help:"Continue executing in other stacks in case of error"`
+		SkipDependents             bool           `default:"false" help:"In case of error, skip only the stacks depending on the failed stack and continue executing the others"`
+		Parallel                   int            `short:"j" default:"1" help:"Maximum number of stacks executed concurrently. A stack only starts after all stacks ordered before it have finished"`
+		NoRecursive                bool           `default:"false" help:"Do not recurse into child stacks"`
+		IncludeDependents          bool           `default:"false" help:"Include the stacks ordered after the selected stacks"`
+		IncludeDependencies        bool           `default:"false" help:"Include the stacks ordered before the selected stacks"`
+		DryRun                     bool           `default:"false" help:"Plan the execution but do not execute it"`
+		Format                     string         `default:"text" enum:"text,json" help:"Output format of --dry-run: 'text' or 'json'"`
+		Reverse                    bool           `default:"false" help:"Reverse the order of execution"`
+		Eval                       bool           `default:"false" help:"Evaluate command line arguments as HCL strings"`
+		ReportFile                 string         `default:"" predictor:"file" help:"Write a JSON report of the execution to the given file"`
+		PrefixOutput               bool           `default:"false" help:"Prefix each line of the commands output with the stack path"`
+		LogDir                     string         `default:"" predictor:"file" help:"Write the output of each stack to <dir>/<stack-path>.log"`
+		Timeout                    *time.Duration `help:"Maximum duration of the command in each stack (eg.: 30m). Overrides terramate.config.run.timeout"`
+		Retries                    *int           `help:"Number of times a failed command is retried. Overrides terramate.config.run.retries"`
+		RetryOnExitCode            []int          `help:"Only retry commands exiting with the given code. Overrides terramate.config.run.retry_on_exit_codes"`
+		Resume                     bool           `default:"false" help:"Resume the last failed or interrupted execution, skipping the stacks already completed"`
+		Command                    []string       `arg:"" optional:"true" name:"cmd" predictor:"file" passthrough:"" help:"Command to execute. If omitted with --resume, the command of the resumed execution is used"`
 	} `cmd:"" help:"Run command in the stacks"`
 
-	Generate struct{} `cmd:"" help:"Generate terraform code for stacks"`
+	Generate struct {
+		DryRun   bool   `default:"false" help:"Report the changes without writing any file. Exits with status 2 if there are pending changes"`
+		Diff     bool   `default:"false" help:"Show the unified diff of the changes. Requires --dry-run"`
+		Format   string `default:"text" enum:"text,json" help:"Output format of the report: 'text' or 'json'"`
+		Parallel int    `short:"j" default:"0" help:"Maximum number of stacks generated concurrently. Defaults to the number of CPUs"`
+	} `cmd:"" help:"Generate terraform code for stacks"`
+
+	Script struct {
+		Run struct {
+			DisableCheckGenCode   bool     `default:"false" help:"Disable outdated generated code check"`
+			DisableCheckGitRemote bool     `default:"false" help:"Disable checking if local default branch is updated with remote"`
+			ContinueOnError       bool     `default:"false" help:"Continue executing in other stacks in case of error"`
+			DryRun                bool     `default:"false" help:"Plan the execution but do not execute it"`
+			Reverse               bool     `default:"false" help:"Reverse the order of execution"`
+			Labels                []string `arg:"" name:"labels" help:"Labels of the script to execute"`
+		} `cmd:"" help:"Run a script in all stacks defining it"`
+
+		List struct {
+			Format string `default:"text" enum:"text,json" help:"Output format: 'text' or 'json'"`
+		} `cmd:"" help:"List the scripts defined in the project"`
+
+		Info struct {
+			Format string   `default:"text" enum:"text,json" help:"Output format: 'text' or 'json'"`
+			Labels []string `arg:"" name:"labels" help:"Labels of the script"`
+		} `cmd:"" help:"Show the evaluated jobs of a script for each stack running it"`
+	} `cmd:"" help:"Manage and run scripts (experimental)"`
 
 	InstallCompletions kongplete.InstallCompletions `cmd:"" help:"Install shell completions"`
 
@@ -527,12 +570,28 @@ func (c *cli) run() {
 		c.setupGit()
 		c.printStacks()
 	case "run":
-		log.Fatal().Msg("no command specified")
+		if !c.parsedArgs.Run.Resume {
+			log.Fatal().Msg("no command specified")
+		}
+		c.setupGit()
+		c.runOnStacks()
 	case "run <cmd>":
 		c.setupGit()
 		c.runOnStacks()
 	case "generate":
 		c.generate()
+	case "script run":
+		fatal(errors.E("no script specified"))
+	case "script run <labels>":
+		c.setupGit()
+		c.runScript()
+	case "script list":
+		c.printScripts()
+	case "script info":
+		fatal(errors.E("no script specified"))
+	case "script info <labels>":
+		c.setupGit()
+		c.printScriptInfo()
 	case "experimental clone <srcdir> <destdir>":
 		c.cloneStack()
 	case "experimental trigger":
@@ -780,14 +839,41 @@ func (c *cli) cloneStack() {
 }
 
 func (c *cli) generate() {
+	if c.parsedArgs.Generate.Diff && !c.parsedArgs.Generate.DryRun {
+		fatal(errors.E("--diff requires --dry-run"))
+	}
+
+	if c.parsedArgs.Generate.Diff && c.parsedArgs.Generate.Format != "text" {
+		fatal(errors.E("--diff conflicts with --format=%s", c.parsedArgs.Generate.Format))
+	}
+
+	if c.parsedArgs.Generate.Parallel < 0 {
+		fatal(errors.E("--parallel must be a positive number, got %d", c.parsedArgs.Generate.Parallel))
+	}
+
+	if c.parsedArgs.Generate.DryRun {
+		c.generateDryRun()
+		return
+	}
+
 	report, vendorReport := c.gencodeWithVendor()
 
-	c.output.MsgStdOut(report.Full())
+	isJSON := c.parsedArgs.Generate.Format == "json"
+	if isJSON {
+		c.outputJSON(c.newGenerateReportJSON(report))
+	} else {
+		c.output.MsgStdOut(report.Full())
+	}
 
 	vendorReport.RemoveIgnoredByKind(download.ErrAlreadyVendored)
 
 	if !vendorReport.IsEmpty() {
-		c.output.MsgStdOut(vendorReport.String())
+		// the stdout is reserved for the JSON report.
+		if isJSON {
+			c.output.MsgStdErr("%s", vendorReport.String())
+		} else {
+			c.output.MsgStdOut(vendorReport.String())
+		}
 	}
 
 	if report.HasFailures() || vendorReport.HasFailures() {
@@ -795,6 +881,44 @@ func (c *cli) generate() {
 	}
 }
 
+// generateDryRun reports the code generation changes without writing any file.
+// The tm_vendor calls are not vendored.
+func (c *cli) generateDryRun() {
+	report := generate.DoWithOptions(c.cfg(), c.vendorDir(), nil, generate.Options{
+		DryRun:   true,
+		Parallel: c.parsedArgs.Generate.Parallel,
+		Cache:    c.generateCache(),
+	})
+
+	if c.parsedArgs.Generate.Format == "json" {
+		c.outputJSON(c.newGenerateReportJSON(report))
+	} else if c.parsedArgs.Generate.Diff {
+		if diff := report.Diff(); diff != "" {
+			c.output.MsgStdOut("%s", strings.TrimSuffix(diff, "\n"))
+		}
+		if report.HasFailures() {
+			c.output.MsgStdErr("%s", report.Minimal())
+		}
+	} else {
+		c.output.MsgStdOut(report.Full())
+	}
+
+	if report.HasFailures() {
+		os.Exit(1)
+	}
+	if report.HasChanges() {
+		os.Exit(2)
+	}
+}
+
+// generateCache returns the code generation cache, or nil if it's disabled.
+func (c *cli) generateCache() *generate.Cache {
+	if c.parsedArgs.DisableGenerateCache {
+		return nil
+	}
+	return generate.NewCache(filepath.Join(c.dataDir(), "generate-cache"))
+}
+
 // gencodeWithVendor will generate code for the whole project providing automatic
 // vendoring of all tm_vendor calls.
 func (c *cli) gencodeWithVendor() (generate.Report, download.Report) {
@@ -812,7 +936,10 @@ func (c *cli) gencodeWithVendor() (generate.Report, download.Report) {
 
 	log.Debug().Msg("generating code")
 
-	report := generate.Do(c.cfg(), c.vendorDir(), vendorRequestEvents)
+	report := generate.DoWithOptions(c.cfg(), c.vendorDir(), vendorRequestEvents, generate.Options{
+		Parallel: c.parsedArgs.Generate.Parallel,
+		Cache:    c.generateCache(),
+	})
 
 	log.Debug().Msg("code generation finished, waiting for vendor requests to be handled")
 
@@ -882,8 +1009,35 @@ func debugFiles(files []string, msg string) {
 	}
 }
 
+// isScriptRun tells if the script run command is the active subcommand, which
+// has its own copy of the run flags.
+func (c *cli) isScriptRun() bool {
+	return strings.HasPrefix(c.ctx.Command(), "script run")
+}
+
+func (c *cli) dryRun() bool {
+	if c.isScriptRun() {
+		return c.parsedArgs.Script.Run.DryRun
+	}
+	return c.parsedArgs.Run.DryRun
+}
+
+func (c *cli) disableCheckGenCode() bool {
+	if c.isScriptRun() {
+		return c.parsedArgs.Script.Run.DisableCheckGenCode
+	}
+	return c.parsedArgs.Run.DisableCheckGenCode
+}
+
+func (c *cli) disableCheckGitRemote() bool {
+	if c.isScriptRun() {
+		return c.parsedArgs.Script.Run.DisableCheckGitRemote
+	}
+	return c.parsedArgs.Run.DisableCheckGitRemote
+}
+
 func (c *cli) gitFileSafeguards(shouldAbort bool) {
-	if c.parsedArgs.Run.DryRun {
+	if c.dryRun() {
 		return
 	}
 
@@ -1041,6 +1195,12 @@ func (c *cli) initTerraform() {
 	if err != nil {
 		fatal(err, "reloading the configuration")
 	}
+	if c.prj.isRepo {
+		err = root.SetRepositoryRoot(c.rootdir())
+		if err != nil {
+			fatal(err, "reloading the configuration")
+		}
+	}
 
 	c.prj.root = *root
 
@@ -1271,14 +1431,27 @@ func (c *cli) printStacks() {
 
 	c.gitFileSafeguards(false)
 
-	for _, entry := range c.filterStacks(report.Stacks) {
+	entries, err := c.addOrderedStacks(c.filterStacks(report.Stacks),
+		c.parsedArgs.List.IncludeDependents, c.parsedArgs.List.IncludeDependencies)
+	if err != nil {
+		fatal(err, "computing selected stacks")
+	}
+
+	if c.parsedArgs.List.Format != "text" {
+		c.printStacksStructured(entries)
+		return
+	}
+
+	for _, entry := range entries {
 		stack := entry.Stack
 
 		log.Debug().Msgf("printing stack %s", stack.Dir)
 
 		stackRepr, ok := c.friendlyFmtDir(stack.Dir.String())
 		if !ok {
-			continue
+			// stacks outside the working dir are only selected by
+			// --include-dependents or --include-dependencies.
+			stackRepr = stack.Dir.String()
 		}
 
 		if c.parsedArgs.List.Why {
@@ -1497,7 +1670,9 @@ func (c *cli) generateDebug() {
 		selectedStacks[stack.Dir()] = struct{}{}
 	}
 
-	results, err := generate.Load(c.cfg(), c.vendorDir())
+	results, err := generate.LoadWithOptions(c.cfg(), c.vendorDir(), generate.Options{
+		Cache: c.generateCache(),
+	})
 	if err != nil {
 		fatal(err, "generate debug: loading generated code")
 	}
@@ -1606,7 +1781,7 @@ func (c *cli) printMetadata() {
 }
 
 func (c *cli) checkGenCode() bool {
-	if c.parsedArgs.Run.DisableCheckGenCode {
+	if c.disableCheckGenCode() {
 		return false
 	}
 
@@ -1768,7 +1943,7 @@ func (c *cli) setupEvalContext(st *config.Stack, overrideGlobals map[string]stri
 	var tdir string
 	if st != nil {
 		tdir = st.HostDir(c.cfg())
-		runtime.Merge(st.RuntimeValues(c.cfg()))
+		runtime = globals.StackRuntime(c.cfg(), st)
 	} else {
 		tdir = c.wd()
 	}
@@ -1822,7 +1997,9 @@ func (c *cli) checkOutdatedGeneratedCode() {
 		return
 	}
 
-	outdatedFiles, err := generate.DetectOutdated(c.cfg(), c.vendorDir())
+	outdatedFiles, err := generate.DetectOutdatedWithOptions(c.cfg(), c.vendorDir(), generate.Options{
+		Cache: c.generateCache(),
+	})
 	if err != nil {
 		fatal(err, "failed to check outdated code on project")
 	}
@@ -1841,7 +2018,7 @@ func (c *cli) checkOutdatedGeneratedCode() {
 }
 
 func (c *cli) gitSafeguardRemoteEnabled() bool {
-	if c.parsedArgs.Run.DisableCheckGitRemote {
+	if c.disableCheckGitRemote() {
 		return false
 	}
 
@@ -1872,6 +2049,20 @@ func (c *cli) friendlyFmtDir(dir string) (string, bool) {
 }
 
 func (c *cli) computeSelectedStacks(ensureCleanRepo bool) (config.List[*config.SortableStack], error) {
+	entries, err := c.computeSelectedEntries(ensureCleanRepo)
+	if err != nil {
+		return nil, err
+	}
+	stacks := make(config.List[*config.SortableStack], len(entries))
+	for i, e := range entries {
+		stacks[i] = e.Stack.Sortable()
+	}
+	return stacks, nil
+}
+
+// computeSelectedEntries computes the selected stacks together with the
+// reason each of them was selected.
+func (c *cli) computeSelectedEntries(ensureCleanRepo bool) ([]stack.Entry, error) {
 	mgr := stack.NewManager(c.cfg(), c.prj.baseRef)
 
 	report, err := c.listStacks(mgr, c.parsedArgs.Changed, cloudstack.NoFilter)
@@ -1882,16 +2073,106 @@ func (c *cli) computeSelectedStacks(ensureCleanRepo bool) (config.List[*config.S
 	c.gitFileSafeguards(ensureCleanRepo)
 
 	entries := c.filterStacks(report.Stacks)
+	reasons := map[prj.Path]string{}
 	stacks := make(config.List[*config.SortableStack], len(entries))
 	for i, e := range entries {
 		stacks[i] = e.Stack.Sortable()
+		reasons[e.Stack.Dir] = c.selectionReason(e)
 	}
 
 	stacks, err = mgr.AddWantedOf(stacks)
 	if err != nil {
 		return nil, errors.E(err, "adding wanted stacks")
 	}
-	return stacks, nil
+
+	selected := make([]stack.Entry, len(stacks))
+	for i, st := range stacks {
+		reason, ok := reasons[st.Dir()]
+		if !ok {
+			reason = "stack is wanted by a selected stack"
+		}
+		selected[i] = stack.Entry{
+			Stack:  st.Stack,
+			Reason: reason,
+		}
+	}
+	return selected, nil
+}
+
+// selectionReason describes why the stack entry was selected by the stack
+// filters of the command line.
+func (c *cli) selectionReason(e stack.Entry) string {
+	reason := "stack is inside the working directory"
+	if c.parsedArgs.Changed {
+		reason = e.Reason
+	}
+
+	var tagFilters []string
+	for _, tags := range c.parsedArgs.Tags {
+		tagFilters = append(tagFilters, "--tags "+tags)
+	}
+	if len(c.parsedArgs.NoTags) > 0 {
+		tagFilters = append(tagFilters, "--no-tags "+strings.Join(c.parsedArgs.NoTags, ","))
+	}
+	if len(tagFilters) > 0 {
+		reason += " and matches " + strings.Join(tagFilters, " ")
+	}
+	return reason
+}
+
+// addOrderedStacks adds to the entries the stacks ordered after them, if
+// dependents is set, and the stacks ordered before them, if dependencies is set.
+// The returned entries are sorted by stack directory.
+func (c *cli) addOrderedStacks(entries []stack.Entry, dependents, dependencies bool) ([]stack.Entry, error) {
+	if !dependents && !dependencies {
+		return entries, nil
+	}
+
+	mgr := stack.NewManager(c.cfg(), c.prj.baseRef)
+
+	reasons := map[prj.Path]string{}
+	stacks := make(config.List[*config.SortableStack], len(entries))
+	for i, e := range entries {
+		stacks[i] = e.Stack.Sortable()
+		reasons[e.Stack.Dir] = e.Reason
+	}
+
+	var added config.List[*config.SortableStack]
+	addStacks := func(addFunc func(config.List[*config.SortableStack]) (config.List[*config.SortableStack], error), reason string) error {
+		all, err := addFunc(stacks)
+		if err != nil {
+			return err
+		}
+		for _, st := range all {
+			if _, ok := reasons[st.Dir()]; !ok {
+				reasons[st.Dir()] = reason
+				added = append(added, st)
+			}
+		}
+		return nil
+	}
+
+	if dependents {
+		if err := addStacks(mgr.AddDependentsOf, "stack is ordered after a selected stack"); err != nil {
+			return nil, errors.E(err, "adding dependent stacks")
+		}
+	}
+	if dependencies {
+		if err := addStacks(mgr.AddDependenciesOf, "stack is ordered before a selected stack"); err != nil {
+			return nil, errors.E(err, "adding dependency stacks")
+		}
+	}
+
+	result := make([]stack.Entry, 0, len(entries)+len(added))
+	result = append(result, entries...)
+	for _, st := range added {
+		result = append(result, stack.Entry{
+			Stack:  st.Stack,
+			Reason: reasons[st.Dir()],
+		})
+	}
+	sort.Sort(stack.EntrySlice(result))
+	return result, nil
 }
 
 func (c *cli) filterStacks(stacks []stack.Entry) []stack.Entry {
@@ -2101,6 +2382,10 @@ func lookupProject(wd string) (prj project, found bool, err error) {
 			if err != nil {
 				return project{}, false, err
 			}
+			err = cfg.SetRepositoryRoot(rootdir)
+			if err != nil {
+				return project{}, false, err
+			}
 
 			prj.isRepo = true
 			prj.root = *cfg
@@ -210,6 +210,16 @@ func (c *cli) cloudSyncBefore(run ExecContext, _ string) {
 	c.doCloudSyncDeployment(run, deployment.Running)
 }
 
+// cloudSyncRetry syncs a failed attempt of a command which is going to be
+// retried. The deployment records every attempt while the drift is only synced
+// with the result of the last one.
+func (c *cli) cloudSyncRetry(run ExecContext, err error) {
+	if !c.cloudEnabled() || !c.parsedArgs.Run.CloudSyncDeployment {
+		return
+	}
+	c.cloudSyncDeployment(run, err)
+}
+
 func (c *cli) cloudSyncAfter(runContext ExecContext, res RunResult, err error) {
 	if !c.cloudEnabled() || !c.isCloudSync() {
 		return
@@ -0,0 +1,170 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	stderrors "errors"
+	"strings"
+
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/hcl/info"
+	prj "github.com/terramate-io/terramate/project"
+)
+
+// generateReportJSON is the code generation report printed by
+// `terramate generate --format json`.
+type generateReportJSON struct {
+	Successes    []generateResultJSON  `json:"successes"`
+	Failures     []generateFailureJSON `json:"failures"`
+	BootstrapErr *generateErrorJSON    `json:"bootstrap_error,omitempty"`
+	CleanupErr   *generateErrorJSON    `json:"cleanup_error,omitempty"`
+}
+
+type generateResultJSON struct {
+	Dir     string   `json:"dir"`
+	Created []string `json:"created"`
+	Changed []string `json:"changed"`
+	Deleted []string `json:"deleted"`
+
+	// Diffs is only present with --dry-run.
+	Diffs map[string]string `json:"diffs,omitempty"`
+}
+
+type generateFailureJSON struct {
+	generateResultJSON
+	Errors []generateErrorJSON `json:"errors"`
+}
+
+type generateErrorJSON struct {
+	Kind    string             `json:"kind,omitempty"`
+	Message string             `json:"message"`
+	Range   *generateRangeJSON `json:"range,omitempty"`
+
+	// Label is the label of the generate block where the error happened,
+	// if it's known.
+	Label string `json:"label,omitempty"`
+}
+
+type generateRangeJSON struct {
+	File  string          `json:"file"`
+	Start generatePosJSON `json:"start"`
+	End   generatePosJSON `json:"end"`
+}
+
+type generatePosJSON struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Byte   int `json:"byte"`
+}
+
+func (c *cli) newGenerateReportJSON(report generate.Report) generateReportJSON {
+	newResult := func(res generate.Result) generateResultJSON {
+		return generateResultJSON{
+			Dir:     res.Dir.String(),
+			Created: nonNilStrings(res.Created),
+			Changed: nonNilStrings(res.Changed),
+			Deleted: nonNilStrings(res.Deleted),
+			Diffs:   res.Diffs,
+		}
+	}
+
+	reportJSON := generateReportJSON{
+		Successes: []generateResultJSON{},
+		Failures:  []generateFailureJSON{},
+	}
+	for _, success := range report.Successes {
+		reportJSON.Successes = append(reportJSON.Successes, newResult(success))
+	}
+	for _, failure := range report.Failures {
+		failureJSON := generateFailureJSON{
+			generateResultJSON: newResult(failure.Result),
+			Errors:             []generateErrorJSON{},
+		}
+
+		errs := []error{failure.Error}
+		var list *errors.List
+		if stderrors.As(failure.Error, &list) {
+			errs = list.Errors()
+		}
+		for _, err := range errs {
+			failureJSON.Errors = append(failureJSON.Errors, c.newGenerateErrorJSON(err))
+		}
+		reportJSON.Failures = append(reportJSON.Failures, failureJSON)
+	}
+	if report.BootstrapErr != nil {
+		errJSON := c.newGenerateErrorJSON(report.BootstrapErr)
+		reportJSON.BootstrapErr = &errJSON
+	}
+	if report.CleanupErr != nil {
+		errJSON := c.newGenerateErrorJSON(report.CleanupErr)
+		reportJSON.CleanupErr = &errJSON
+	}
+	return reportJSON
+}
+
+func (c *cli) newGenerateErrorJSON(err error) generateErrorJSON {
+	errJSON := generateErrorJSON{
+		Message: err.Error(),
+	}
+
+	var e *errors.Error
+	if !stderrors.As(err, &e) {
+		return errJSON
+	}
+
+	errJSON.Kind = string(e.Kind)
+	if e.FileRange.Empty() {
+		return errJSON
+	}
+
+	filename := e.FileRange.Filename
+	if strings.HasPrefix(filename, c.rootdir()) {
+		filename = prj.PrjAbsPath(c.rootdir(), filename).String()
+	}
+	errJSON.Range = &generateRangeJSON{
+		File: filename,
+		Start: generatePosJSON{
+			Line:   e.FileRange.Start.Line,
+			Column: e.FileRange.Start.Column,
+			Byte:   e.FileRange.Start.Byte,
+		},
+		End: generatePosJSON{
+			Line:   e.FileRange.End.Line,
+			Column: e.FileRange.End.Column,
+			Byte:   e.FileRange.End.Byte,
+		},
+	}
+	errJSON.Label = c.genBlockLabelAt(e.FileRange)
+	return errJSON
+}
+
+// genBlockLabelAt returns the label of the generate block containing the
+// given range or an empty string if the range is not inside a generate block.
+func (c *cli) genBlockLabelAt(rng hhcl.Range) string {
+	contains := func(block info.Range) bool {
+		return block.HostPath() == rng.Filename &&
+			block.Start().Byte() <= rng.Start.Byte &&
+			rng.End.Byte <= block.End().Byte()
+	}
+	for _, cfg := range c.cfg().Tree().AsList() {
+		for _, block := range cfg.Node.Generate.Files {
+			if contains(block.Range) {
+				return block.Label
+			}
+		}
+		for _, block := range cfg.Node.Generate.HCLs {
+			if contains(block.Range) {
+				return block.Label
+			}
+		}
+		for _, block := range cfg.Node.Generate.Data {
+			if contains(block.Range) {
+				return block.Label
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"encoding/json"
+
+	"github.com/terramate-io/terramate/stack"
+)
+
+type listStackEntry struct {
+	Path   string            `json:"path"`
+	ID     string            `json:"id"`
+	Name   string            `json:"name"`
+	Tags   []string          `json:"tags"`
+	After  []string          `json:"after"`
+	Before []string          `json:"before"`
+	Reason *listChangeReason `json:"reason,omitempty"`
+}
+
+type listChangeReason struct {
+	Kind          string   `json:"kind"`
+	Description   string   `json:"description"`
+	Paths         []string `json:"paths"`
+	TriggerReason string   `json:"trigger_reason,omitempty"`
+}
+
+// printStacksStructured prints the stack entries using the machine readable
+// format selected with --format. The change reason is only present when
+// listing changed stacks.
+func (c *cli) printStacksStructured(entries []stack.Entry) {
+	list := []listStackEntry{}
+	for _, entry := range entries {
+		list = append(list, newListStackEntry(entry))
+	}
+
+	if c.parsedArgs.List.Format == "json" {
+		c.outputJSON(list)
+		return
+	}
+
+	for _, entry := range list {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			fatal(err, "encoding stack %s as JSON", entry.Path)
+		}
+		c.output.MsgStdOut("%s", data)
+	}
+}
+
+func newListStackEntry(entry stack.Entry) listStackEntry {
+	st := entry.Stack
+	listEntry := listStackEntry{
+		Path:   st.Dir.String(),
+		ID:     st.ID,
+		Name:   st.Name,
+		Tags:   nonNilStrings(st.Tags),
+		After:  nonNilStrings(st.After),
+		Before: nonNilStrings(st.Before),
+	}
+
+	if entry.Change.Kind != "" {
+		listEntry.Reason = &listChangeReason{
+			Kind:          string(entry.Change.Kind),
+			Description:   entry.Reason,
+			Paths:         nonNilStrings(entry.Change.Paths.Strings()),
+			TriggerReason: entry.Change.TriggerReason,
+		}
+	}
+	return listEntry
+}
+
+func nonNilStrings(strs []string) []string {
+	if strs == nil {
+		return []string{}
+	}
+	return strs
+}
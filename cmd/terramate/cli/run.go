@@ -6,10 +6,13 @@ package cli
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"os/signal"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -17,9 +20,11 @@ import (
 	"github.com/terramate-io/terramate/cloud"
 	"github.com/terramate-io/terramate/config"
 	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
 	prj "github.com/terramate-io/terramate/project"
 	"github.com/terramate-io/terramate/run"
 	"github.com/terramate-io/terramate/run/dag"
+	"github.com/terramate-io/terramate/stack"
 )
 
 const (
@@ -32,12 +37,42 @@ const (
 	// ErrRunCommandNotFound represents the error when the command cannot be found
 	// in the system.
 	ErrRunCommandNotFound errors.Kind = "command not found"
+
+	// ErrRunTimeout represents the error when the command exceeds its timeout.
+	ErrRunTimeout errors.Kind = "execution timed out"
 )
 
+// timeoutKillGracePeriod is how long a timed out command has to exit after
+// being interrupted before it's killed.
+const timeoutKillGracePeriod = 10 * time.Second
+
 // ExecContext declares an stack execution context.
 type ExecContext struct {
 	Stack *config.Stack
 	Cmd   []string
+
+	// Deps are the stacks of the same execution that must finish before
+	// this stack can start.
+	Deps prj.Paths
+
+	// Reason is the reason the stack was selected for execution.
+	Reason string
+
+	// Policy is the timeout and retry policy of the command.
+	Policy runPolicy
+}
+
+// runPolicy is the timeout and retry policy of a stack command.
+type runPolicy struct {
+	// Timeout is the maximum duration of each attempt. Zero means no timeout.
+	Timeout time.Duration
+
+	// Retries is the number of times a failed command is retried.
+	Retries int
+
+	// RetryOnExitCodes is the list of exit codes that are retried. If empty
+	// then any failure is retried.
+	RetryOnExitCodes []int
 }
 
 // RunResult contains exit code and duration of a completed run.
@@ -55,14 +90,14 @@ func (c *cli) runOnStacks() {
 
 	c.gitSafeguardDefaultBranchIsReachable()
 
-	if len(c.parsedArgs.Run.Command) == 0 {
+	if len(c.parsedArgs.Run.Command) == 0 && !c.parsedArgs.Run.Resume {
 		logger.Fatal().Msgf("run expects a cmd")
 	}
 
 	c.checkOutdatedGeneratedCode()
 	c.checkCloudSync()
 
-	var stacks config.List[*config.SortableStack]
+	var entries []stack.Entry
 	if c.parsedArgs.Run.NoRecursive {
 		st, found, err := config.TryLoadStack(c.cfg(), prj.PrjAbsPath(c.rootdir(), c.wd()))
 		if err != nil {
@@ -74,16 +109,52 @@ func (c *cli) runOnStacks() {
 				Msg("--no-recursive provided but no stack found in the current directory")
 		}
 
-		stacks = append(stacks, st.Sortable())
+		entries = append(entries, stack.Entry{
+			Stack:  st,
+			Reason: "stack is the working directory",
+		})
 	} else {
 		var err error
-		stacks, err = c.computeSelectedStacks(true)
+		entries, err = c.computeSelectedEntries(true)
 		if err != nil {
 			fatal(err, "computing selected stacks")
 		}
 	}
 
-	orderedStacks, reason, err := run.Sort(c.cfg(), stacks)
+	entries, err := c.addOrderedStacks(entries,
+		c.parsedArgs.Run.IncludeDependents, c.parsedArgs.Run.IncludeDependencies)
+	if err != nil {
+		fatal(err, "computing selected stacks")
+	}
+
+	var stacks config.List[*config.SortableStack]
+	reasons := map[prj.Path]string{}
+	for _, entry := range entries {
+		stacks = append(stacks, entry.Stack.Sortable())
+		reasons[entry.Stack.Dir] = entry.Reason
+	}
+
+	if c.parsedArgs.Run.Parallel < 1 {
+		fatal(errors.E("--parallel must be greater than zero"))
+	}
+
+	if c.parsedArgs.Run.Format != "text" && !c.parsedArgs.Run.DryRun {
+		fatal(errors.E("--format=%s requires --dry-run", c.parsedArgs.Run.Format))
+	}
+
+	if c.parsedArgs.Run.ContinueOnError && c.parsedArgs.Run.SkipDependents {
+		fatal(errors.E("--continue-on-error conflicts with --skip-dependents"))
+	}
+
+	if c.parsedArgs.Run.Timeout != nil && *c.parsedArgs.Run.Timeout < 0 {
+		fatal(errors.E("--timeout must not be negative"))
+	}
+
+	if c.parsedArgs.Run.Retries != nil && *c.parsedArgs.Run.Retries < 0 {
+		fatal(errors.E("--retries must not be negative"))
+	}
+
+	d, reason, err := run.BuildOrderDAG(c.cfg(), stacks)
 	if err != nil {
 		if errors.IsKind(err, dag.ErrCycleDetected) {
 			fatal(err, "cycle detected: %s", reason)
@@ -92,11 +163,54 @@ func (c *cli) runOnStacks() {
 		}
 	}
 
+	orderedStacks, err := run.SortDAG(d, stacks)
+	if err != nil {
+		fatal(err, "failed to plan execution")
+	}
+
+	deps := run.Dependencies(d, orderedStacks)
+
 	if c.parsedArgs.Run.Reverse {
 		config.ReverseStacks(orderedStacks)
+		deps = reverseDependencies(deps)
 	}
 
-	if c.parsedArgs.Run.DryRun {
+	command := c.parsedArgs.Run.Command
+	evalCmd := c.parsedArgs.Run.Eval
+
+	// the state is only saved by executions, a dry run only reads the state
+	// when resuming.
+	var state *runState
+	if c.parsedArgs.Run.Resume {
+		state, err = loadRunState(c.runStateFile())
+		if err != nil {
+			fatal(err)
+		}
+		if err := state.checkResumable(c.runStateHead(), orderedStacks); err != nil {
+			fatal(err)
+		}
+		if len(command) == 0 {
+			command = state.Command
+			evalCmd = state.Eval
+		}
+
+		// the execution continues from the failed stack and runs every
+		// stack after it, including the ones which completed after the
+		// failure when --continue-on-error was used.
+		from := state.resumeIndex()
+
+		logger.Debug().
+			Int("completed", from).
+			Int("pending", len(orderedStacks)-from).
+			Msg("resuming execution")
+
+		state.resume(from, command, evalCmd)
+		orderedStacks = orderedStacks[from:]
+	} else if !c.parsedArgs.Run.DryRun {
+		state = newRunState(c.runStateFile(), c.runStateHead(), command, evalCmd, orderedStacks)
+	}
+
+	if c.parsedArgs.Run.DryRun && c.parsedArgs.Run.Format == "text" {
 		if len(orderedStacks) > 0 {
 			c.output.MsgStdOut("The stacks will be executed using order below:")
 
@@ -114,15 +228,23 @@ func (c *cli) runOnStacks() {
 	var runStacks []ExecContext
 	for _, st := range orderedStacks {
 		run := ExecContext{
-			Stack: st.Stack,
-			Cmd:   c.parsedArgs.Run.Command,
+			Stack:  st.Stack,
+			Cmd:    command,
+			Deps:   deps[st.Dir()],
+			Reason: reasons[st.Dir()],
+			Policy: c.runPolicy(st.Stack),
 		}
-		if c.parsedArgs.Run.Eval {
+		if evalCmd {
 			run.Cmd = c.evalRunArgs(run.Stack, run.Cmd)
 		}
 		runStacks = append(runStacks, run)
 	}
 
+	if c.parsedArgs.Run.DryRun {
+		c.printRunPlan(runStacks, run.OrderEdges(d, orderedStacks, c.parsedArgs.Run.Reverse))
+		return
+	}
+
 	if c.parsedArgs.Run.CloudSyncDeployment && c.parsedArgs.Run.CloudSyncDriftStatus {
 		fatal(errors.E("--cloud-sync-deployment conflicts with --cloud-sync-drift-status"))
 	}
@@ -157,24 +279,93 @@ func (c *cli) runOnStacks() {
 		}
 	}
 
-	err = c.RunAll(runStacks, isSuccessExit)
+	err = c.RunAll(runStacks, runAllOptions{
+		ContinueOnError: c.parsedArgs.Run.ContinueOnError,
+		SkipDependents:  c.parsedArgs.Run.SkipDependents,
+		Parallel:        c.parsedArgs.Run.Parallel,
+		ReportFile:      c.parsedArgs.Run.ReportFile,
+		PrefixOutput:    c.parsedArgs.Run.PrefixOutput,
+		LogDir:          c.parsedArgs.Run.LogDir,
+		State:           state,
+	}, isSuccessExit)
 	if err != nil {
 		fatal(err, "one or more commands failed")
 	}
+
+	if err := state.remove(); err != nil {
+		logger.Warn().Err(err).Msg("removing run state")
+	}
+}
+
+// runAllOptions are the options controlling how [cli.RunAll] executes the stacks.
+type runAllOptions struct {
+	// ContinueOnError continues executing the remaining stacks when a command
+	// fails.
+	ContinueOnError bool
+
+	// SkipDependents skips only the stacks depending, directly or not, on a
+	// failed stack and continues executing the others.
+	SkipDependents bool
+
+	// Parallel is the maximum number of stacks executed concurrently.
+	Parallel int
+
+	// ReportFile is the file where the report of the execution is written.
+	// No report is written if empty.
+	ReportFile string
+
+	// PrefixOutput prefixes each line of the commands output with the
+	// stack path.
+	PrefixOutput bool
+
+	// LogDir is the directory where the output of each stack is written
+	// into <LogDir>/<stack-path>.log. No log is written if empty.
+	LogDir string
+
+	// State, if not nil, is updated and saved whenever a stack finishes.
+	State *runState
 }
 
 // RunAll will execute the list of RunStack definitions. A RunStack defines the
-// stack and its command to be executed. The isSuccessCode is a predicate used
-// to decide if the command is considered a successful run or not.
+// stack and its command to be executed. The opts control the behavior of the
+// execution and the isSuccessCode is a predicate used to decide if the command
+// is considered a successful run or not.
+// The stacks are started in the given order, but when parallelism is enabled
+// a stack is started as soon as all of its dependencies have finished.
 // During the execution of this function the default behavior
 // for signal handling will be changed so we can wait for the child
 // process to exit before exiting Terramate.
 // If a single SIGINT is sent to the Terramate process group then Terramate will
-// wait for the process graceful exit and abort the execution of all subsequent
-// stacks.
+// wait for the graceful exit of the running processes and abort the execution
+// of all subsequent stacks.
 // If SIGINT is sent 3x then Terramate will send a SIGKILL to the currently
-// running process and abort the execution of all subsequent stacks.
-func (c *cli) RunAll(runStacks []ExecContext, isSuccessCode func(exitCode int) bool) error {
+// running processes and abort the execution of all subsequent stacks.
+// If opts.SkipDependents is set then a failure skips only the stacks depending
+// on the failed stack and the report tells which failed stack blocked them.
+// A failed command of a stack always skips the remaining commands of the same
+// stack, even if opts.ContinueOnError is set.
+// If a report file is configured then the report is written after all
+// started processes have finished, including when the execution is interrupted
+// or fails before any stack is started.
+func (c *cli) RunAll(runStacks []ExecContext, opts runAllOptions, isSuccessCode func(exitCode int) bool) error {
+	report := newRunReport(runStacks)
+	err := c.runAll(runStacks, opts, isSuccessCode, report)
+	if opts.ReportFile != "" {
+		report.finish(err)
+		if werr := report.write(opts.ReportFile); werr != nil {
+			errs := errors.L(err, werr)
+			return errs.AsError()
+		}
+	}
+	return err
+}
+
+func (c *cli) runAll(
+	runStacks []ExecContext,
+	opts runAllOptions,
+	isSuccessCode func(exitCode int) bool,
+	report *runReport,
+) error {
 	errs := errors.L()
 
 	// we load/check the env of all stacks beforehand then no stack is executed
@@ -189,152 +380,455 @@ func (c *cli) RunAll(runStacks []ExecContext, isSuccessCode func(exitCode int) b
 	signal.Notify(signals, os.Interrupt)
 	defer signal.Reset(os.Interrupt)
 
-	cmds := make(chan *exec.Cmd)
-	defer close(cmds)
+	parallel := opts.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
 
-	continueOnError := c.parsedArgs.Run.ContinueOnError
-	results := startCmdConsumer(cmds)
-	for i, runContext := range runStacks {
-		cmdStr := strings.Join(runContext.Cmd, " ")
-		logger := log.With().
-			Str("cmd", cmdStr).
-			Stringer("stack", runContext.Stack).
-			Logger()
+	stdin := c.stdin
+	stdout := c.stdout
+	stderr := c.stderr
+	if parallel > 1 {
+		// the stdin cannot be shared between concurrent processes and the
+		// output of all of them must be serialized.
+		stdin = nil
+		stdout, stderr = newSyncWriters(c.stdout, c.stderr)
+	}
 
-		c.cloudSyncBefore(runContext, cmdStr)
+	inExecution := map[prj.Path]struct{}{}
+	for _, runContext := range runStacks {
+		inExecution[runContext.Stack.Dir] = struct{}{}
+	}
 
-		environ := newEnvironFrom(stackEnvs[runContext.Stack.Dir])
-		cmdPath, err := run.LookPath(runContext.Cmd[0], environ)
-		if err != nil {
-			c.cloudSyncAfter(runContext, RunResult{ExitCode: -1}, errors.E(ErrRunCommandNotFound, err))
-			errs.Append(errors.E(err, "running `%s` in stack %s", cmdStr, runContext.Stack.Dir))
-			if continueOnError {
+	var (
+		scheduled     = make([]bool, len(runStacks))
+		attempts      = make([]int, len(runStacks))
+		finished      = map[prj.Path]struct{}{}
+		failed        = map[prj.Path]struct{}{}
+		blockedBy     = map[prj.Path]prj.Path{}
+		running       = map[int]*exec.Cmd{}
+		results       = make(chan stackRunResult)
+		retrying      = map[int]failedAttempt{}
+		retryc        = make(chan int, len(runStacks))
+		interruptions int
+		aborted       bool
+	)
+
+	abortOnError := !opts.ContinueOnError && !opts.SkipDependents
+
+	setState := func(stackdir prj.Path, status runStateStatus) {
+		if opts.State != nil {
+			opts.State.set(stackdir, status)
+		}
+	}
+
+	if opts.State != nil {
+		if err := opts.State.save(); err != nil {
+			return err
+		}
+	}
+
+	var logFiles *stackLogFiles
+	if opts.LogDir != "" {
+		logFiles = newStackLogFiles(opts.LogDir)
+		defer func() {
+			if err := logFiles.close(); err != nil {
+				log.Error().Err(err).Msg("closing stack log files")
+			}
+		}()
+	}
+
+	canStart := func(runContext ExecContext) bool {
+		for _, dep := range runContext.Deps {
+			if _, ok := inExecution[dep]; !ok {
 				continue
 			}
-			c.cloudSyncCancelStacks(runStacks[i+1:])
-			return errs.AsError()
+			if _, ok := finished[dep]; !ok {
+				return false
+			}
 		}
-		cmd := exec.Command(cmdPath, runContext.Cmd[1:]...)
-		cmd.Dir = runContext.Stack.HostDir(c.cfg())
-		cmd.Env = environ
-
-		stdout := c.stdout
-		stderr := c.stderr
+		return true
+	}
 
-		logSyncWait := func() {}
-		if c.cloudEnabled() && c.parsedArgs.Run.CloudSyncDeployment {
-			logSyncer := cloud.NewLogSyncer(func(logs cloud.DeploymentLogs) {
-				c.syncLogs(&logger, runContext, logs)
-			})
-			stdout = logSyncer.NewBuffer(cloud.StdoutLogChannel, c.stdout)
-			stderr = logSyncer.NewBuffer(cloud.StderrLogChannel, c.stderr)
+	// blockingStack returns the failed stack blocking the execution of the
+	// given stack, if any.
+	blockingStack := func(runContext ExecContext) (prj.Path, bool) {
+		for _, dep := range runContext.Deps {
+			if _, ok := failed[dep]; ok {
+				return dep, true
+			}
+			if blocking, ok := blockedBy[dep]; ok {
+				return blocking, true
+			}
+		}
+		return prj.Path{}, false
+	}
 
-			logSyncWait = logSyncer.Wait
+	// abandonRetry gives up on the pending retry of the given stack, failing
+	// it with the result of its last attempt.
+	abandonRetry := func(index int) {
+		runContext := runStacks[index]
+		attempt := retrying[index]
+		delete(retrying, index)
+
+		errs.Append(attempt.err)
+		setState(runContext.Stack.Dir, runStateFailed)
+		failed[runContext.Stack.Dir] = struct{}{}
+		finished[runContext.Stack.Dir] = struct{}{}
+
+		// the deployment already has the failed attempt.
+		if !c.parsedArgs.Run.CloudSyncDeployment {
+			c.cloudSyncAfter(runContext, attempt.res, attempt.err)
 		}
+	}
 
-		cmd.Stdin = c.stdin
-		cmd.Stdout = stdout
-		cmd.Stderr = stderr
+	for {
+		if aborted {
+			for index := range retrying {
+				abandonRetry(index)
+			}
+		}
 
-		logger.Info().Msg("running")
+		for i, runContext := range runStacks {
+			if aborted || len(running) >= parallel {
+				break
+			}
+			if scheduled[i] || !canStart(runContext) {
+				continue
+			}
 
-		startTime := time.Now().UTC()
+			if _, ok := failed[runContext.Stack.Dir]; ok {
+				// the commands of a stack, like the jobs of a script, run in
+				// order and stop at the first failure.
+				log.Warn().
+					Stringer("stack", runContext.Stack.Dir).
+					Str("cmd", strings.Join(runContext.Cmd, " ")).
+					Msg("skipping command because a previous command of the stack failed")
 
-		if err := cmd.Start(); err != nil {
-			endTime := time.Now().UTC()
+				scheduled[i] = true
+				report.setStatus(i, runStatusSkipped)
+				continue
+			}
 
-			logSyncWait()
+			if opts.SkipDependents {
+				if blocking, ok := blockingStack(runContext); ok {
+					log.Warn().
+						Stringer("stack", runContext.Stack.Dir).
+						Stringer("blocked_by", blocking).
+						Msg("skipping stack because it depends on a failed stack")
+
+					scheduled[i] = true
+					finished[runContext.Stack.Dir] = struct{}{}
+					blockedBy[runContext.Stack.Dir] = blocking
+					report.setBlocked(i, blocking)
+					continue
+				}
+			}
 
-			res := RunResult{
-				ExitCode:   -1,
-				StartedAt:  &startTime,
-				FinishedAt: &endTime,
+			scheduled[i] = true
+			attempts[i]++
+
+			var logFile io.Writer
+			if logFiles != nil {
+				f, err := logFiles.open(runContext.Stack.Dir)
+				if err != nil {
+					finished[runContext.Stack.Dir] = struct{}{}
+					report.setFailed(i, err)
+					setState(runContext.Stack.Dir, runStateFailed)
+					failed[runContext.Stack.Dir] = struct{}{}
+					errs.Append(err)
+					if abortOnError {
+						aborted = true
+					}
+					continue
+				}
+				logFile = f
 			}
-			c.cloudSyncAfter(runContext, res, errors.E(err, ErrRunFailed))
-			errs.Append(errors.E(err, "running %s (at stack %s)", cmd, runContext.Stack.Dir))
-			logger.Error().Err(err).Msg("failed to execute")
-			if continueOnError {
+
+			c.cloudSyncBefore(runContext, strings.Join(runContext.Cmd, " "))
+
+			cmd, err := c.startStack(runContext, stackEnvs[runContext.Stack.Dir], stdin, stdout, stderr,
+				stackOutputOptions{prefix: opts.PrefixOutput, logFile: logFile}, i, results)
+			if err != nil {
+				finished[runContext.Stack.Dir] = struct{}{}
+				report.setFailed(i, err)
+				setState(runContext.Stack.Dir, runStateFailed)
+				failed[runContext.Stack.Dir] = struct{}{}
+				errs.Append(err)
+				if abortOnError {
+					aborted = true
+				}
 				continue
 			}
-			c.cloudSyncCancelStacks(runStacks[i+1:])
-			return errs.AsError()
+			running[i] = cmd
 		}
 
-		cmds <- cmd
-		interruptions := 0
-		cmdIsRunning := true
+		if len(running) == 0 && len(retrying) == 0 {
+			break
+		}
 
-		for cmdIsRunning {
-			select {
-			case sig := <-signals:
-				interruptions++
+		select {
+		case sig := <-signals:
+			interruptions++
+			aborted = true
 
-				logger.Info().
-					Str("signal", sig.String()).
-					Int("interruptions", interruptions).
-					Msg("received interruption signal")
+			log.Info().
+				Str("signal", sig.String()).
+				Int("interruptions", interruptions).
+				Msg("received interruption signal")
 
-				if interruptions >= 3 {
-					logger.Info().Msg("interrupted 3x times or more, killing child process")
+			if interruptions >= 3 {
+				log.Info().Msg("interrupted 3x times or more, killing child processes")
 
+				for i, cmd := range running {
 					if err := cmd.Process.Kill(); err != nil {
-						logger.Debug().Err(err).Msg("unable to send kill signal to child process")
+						log.Debug().
+							Err(err).
+							Stringer("stack", runStacks[i].Stack).
+							Msg("unable to send kill signal to child process")
 					}
+				}
+			}
+		case index := <-retryc:
+			if _, ok := retrying[index]; !ok {
+				// the retry was abandoned.
+				continue
+			}
 
-					endTime := time.Now().UTC()
+			// the stack is scheduled again as its dependencies
+			// already finished.
+			delete(retrying, index)
+			scheduled[index] = false
+		case result := <-results:
+			runContext := runStacks[result.index]
+			logger := log.With().
+				Str("cmd", strings.Join(runContext.Cmd, " ")).
+				Stringer("stack", runContext.Stack).
+				Logger()
 
-					logSyncWait()
+			delete(running, result.index)
 
-					res := RunResult{
-						ExitCode:   -1,
-						StartedAt:  &startTime,
-						FinishedAt: &endTime,
-					}
-					c.cloudSyncAfter(runContext, res, errors.E(ErrRunCanceled))
-					c.cloudSyncCancelStacks(runStacks[i+1:])
-					return errors.E(ErrRunCanceled, "execution aborted by CTRL-C (3x)")
-				}
-			case result := <-results:
-				logSyncWait()
-				var err error
-				if !isSuccessCode(result.cmd.ProcessState.ExitCode()) {
-					err = errors.E(result.err, ErrRunFailed, "running %s (at stack %s)", result.cmd, runContext.Stack.Dir)
-					errs.Append(err)
-					logger.Error().Err(err).Msg("failed to execute")
+			res := RunResult{
+				ExitCode:   result.cmd.ProcessState.ExitCode(),
+				StartedAt:  result.startedAt,
+				FinishedAt: result.finishedAt,
+			}
+
+			var err error
+			if interruptions >= 3 {
+				res.ExitCode = -1
+				err = errors.E(ErrRunCanceled)
+				report.setResult(result.index, runStatusCanceled, res, err)
+			} else if !isSuccessCode(res.ExitCode) {
+				err = errors.E(result.err, ErrRunFailed, "running %s (at stack %s)", result.cmd, runContext.Stack.Dir)
+				if result.timedOut {
+					err = errors.E(err, ErrRunTimeout, "exceeded the timeout of %s", runContext.Policy.Timeout)
 				}
 
-				res := RunResult{
-					ExitCode:   result.cmd.ProcessState.ExitCode(),
-					StartedAt:  &startTime,
-					FinishedAt: result.finishedAt,
+				if !aborted && runContext.Policy.shouldRetry(attempts[result.index], res.ExitCode) {
+					delay := retryBackoff(attempts[result.index])
+					logger.Warn().
+						Err(err).
+						Int("attempt", attempts[result.index]).
+						Dur("backoff", delay).
+						Msg("failed to execute, retrying")
+
+					report.setResult(result.index, runStatusFailed, res, err)
+					c.cloudSyncRetry(runContext, err)
+
+					retrying[result.index] = failedAttempt{res: res, err: err}
+					index := result.index
+					time.AfterFunc(delay, func() {
+						retryc <- index
+					})
+					continue
 				}
 
-				logMsg := logger.Debug().Int("exit_code", res.ExitCode)
-				if res.StartedAt != nil && res.FinishedAt != nil {
-					logMsg = logMsg.
-						Time("started_at", *res.StartedAt).
-						Time("finished_at", *res.FinishedAt).
-						TimeDiff("duration", *res.FinishedAt, *res.StartedAt)
+				errs.Append(err)
+				logger.Error().Err(err).Msg("failed to execute")
+				report.setResult(result.index, runStatusFailed, res, err)
+				setState(runContext.Stack.Dir, runStateFailed)
+				failed[runContext.Stack.Dir] = struct{}{}
+
+				if abortOnError {
+					aborted = true
 				}
-				logMsg.Msg("command execution finished")
+			} else {
+				report.setResult(result.index, runStatusSuccess, res, nil)
+				setState(runContext.Stack.Dir, runStateCompleted)
+			}
 
-				c.cloudSyncAfter(runContext, res, err)
-				cmdIsRunning = false
+			finished[runContext.Stack.Dir] = struct{}{}
+
+			logMsg := logger.Debug().Int("exit_code", res.ExitCode)
+			if res.StartedAt != nil && res.FinishedAt != nil {
+				logMsg = logMsg.
+					Time("started_at", *res.StartedAt).
+					Time("finished_at", *res.FinishedAt).
+					TimeDiff("duration", *res.FinishedAt, *res.StartedAt)
 			}
-		}
+			logMsg.Msg("command execution finished")
 
-		err = errs.AsError()
-		if interruptions > 0 || (err != nil && !continueOnError) {
-			logger.Info().Msg("interrupting execution of further stacks")
+			c.cloudSyncAfter(runContext, res, err)
+		}
+	}
 
-			c.cloudSyncCancelStacks(runStacks[i+1:])
-			return errs.AsError()
+	var notStarted []ExecContext
+	for i, runContext := range runStacks {
+		if _, ok := blockedBy[runContext.Stack.Dir]; ok {
+			notStarted = append(notStarted, runContext)
+			continue
+		}
+		if !scheduled[i] {
+			notStarted = append(notStarted, runContext)
+			if interruptions > 0 {
+				report.setStatus(i, runStatusCanceled)
+			} else {
+				report.setStatus(i, runStatusSkipped)
+			}
 		}
 	}
 
+	report.Interrupted = interruptions > 0
+
+	if len(notStarted) > 0 {
+		log.Info().Msg("interrupting execution of further stacks")
+
+		c.cloudSyncCancelStacks(notStarted)
+	}
+
+	if interruptions >= 3 {
+		return errors.E(ErrRunCanceled, "execution aborted by CTRL-C (3x)")
+	}
+
 	return errs.AsError()
 }
 
+// stackOutputOptions controls how the output of a stack command is written.
+type stackOutputOptions struct {
+	// prefix tells if each line must be prefixed with the stack path.
+	prefix bool
+
+	// logFile, if not nil, receives a copy of stdout and stderr.
+	logFile io.Writer
+}
+
+// startStack starts the execution of the command of the given stack.
+// The result is sent to the results channel once the command finishes.
+func (c *cli) startStack(
+	runContext ExecContext,
+	stackEnv run.EnvVars,
+	stdin io.Reader,
+	stdout, stderr io.Writer,
+	outputOpts stackOutputOptions,
+	index int,
+	results chan<- stackRunResult,
+) (*exec.Cmd, error) {
+	cmdStr := strings.Join(runContext.Cmd, " ")
+	logger := log.With().
+		Str("cmd", cmdStr).
+		Stringer("stack", runContext.Stack).
+		Logger()
+
+	environ := newEnvironFrom(stackEnv)
+	cmdPath, err := run.LookPath(runContext.Cmd[0], environ)
+	if err != nil {
+		c.cloudSyncAfter(runContext, RunResult{ExitCode: -1}, errors.E(ErrRunCommandNotFound, err))
+		return nil, errors.E(err, "running `%s` in stack %s", cmdStr, runContext.Stack.Dir)
+	}
+	cmd := exec.Command(cmdPath, runContext.Cmd[1:]...)
+	cmd.Dir = runContext.Stack.HostDir(c.cfg())
+	cmd.Env = environ
+
+	outputWait := func() {}
+	if outputOpts.prefix {
+		prefix := fmt.Sprintf("[%s] ", runContext.Stack.Dir)
+		var stdoutWait, stderrWait func()
+		stdout, stdoutWait = newLinePrefixWriter(stdout, prefix)
+		stderr, stderrWait = newLinePrefixWriter(stderr, prefix)
+		outputWait = func() {
+			stdoutWait()
+			stderrWait()
+		}
+	}
+	if outputOpts.logFile != nil {
+		// stdout and stderr are copied concurrently, so only whole lines are
+		// written into the log file, one at a time.
+		logFile := &syncWriter{mu: &sync.Mutex{}, w: outputOpts.logFile}
+		stdoutLog, stdoutLogWait := newLinePrefixWriter(logFile, "")
+		stderrLog, stderrLogWait := newLinePrefixWriter(logFile, "")
+		stdout = io.MultiWriter(stdout, stdoutLog)
+		stderr = io.MultiWriter(stderr, stderrLog)
+
+		prefixWait := outputWait
+		outputWait = func() {
+			stdoutLogWait()
+			stderrLogWait()
+			prefixWait()
+		}
+	}
+
+	logSyncWait := func() {}
+	if c.cloudEnabled() && c.parsedArgs.Run.CloudSyncDeployment {
+		logSyncer := cloud.NewLogSyncer(func(logs cloud.DeploymentLogs) {
+			c.syncLogs(&logger, runContext, logs)
+		})
+		stdout = logSyncer.NewBuffer(cloud.StdoutLogChannel, stdout)
+		stderr = logSyncer.NewBuffer(cloud.StderrLogChannel, stderr)
+
+		logSyncWait = logSyncer.Wait
+	}
+
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	logger.Info().Msg("running")
+
+	startTime := time.Now().UTC()
+
+	if err := cmd.Start(); err != nil {
+		endTime := time.Now().UTC()
+
+		logSyncWait()
+		outputWait()
+
+		res := RunResult{
+			ExitCode:   -1,
+			StartedAt:  &startTime,
+			FinishedAt: &endTime,
+		}
+		c.cloudSyncAfter(runContext, res, errors.E(err, ErrRunFailed))
+		logger.Error().Err(err).Msg("failed to execute")
+		return nil, errors.E(err, "running %s (at stack %s)", cmd, runContext.Stack.Dir)
+	}
+
+	stopTimeout := func() bool { return false }
+	if runContext.Policy.Timeout > 0 {
+		stopTimeout = interruptOnTimeout(cmd, runContext.Policy.Timeout, logger)
+	}
+
+	go func() {
+		err := cmd.Wait()
+		endTime := time.Now().UTC()
+		timedOut := stopTimeout()
+
+		logSyncWait()
+		outputWait()
+
+		results <- stackRunResult{
+			index:      index,
+			cmd:        cmd,
+			err:        err,
+			timedOut:   timedOut,
+			startedAt:  &startTime,
+			finishedAt: &endTime,
+		}
+	}()
+
+	return cmd, nil
+}
+
 func (c *cli) syncLogs(logger *zerolog.Logger, runContext ExecContext, logs cloud.DeploymentLogs) {
 	data, _ := json.Marshal(logs)
 	logger.Debug().RawJSON("logs", data).Msg("synchronizing logs")
@@ -349,28 +843,182 @@ func (c *cli) syncLogs(logger *zerolog.Logger, runContext ExecContext, logs clou
 	}
 }
 
-type cmdResult struct {
+type stackRunResult struct {
+	index      int
 	cmd        *exec.Cmd
 	err        error
+	timedOut   bool
+	startedAt  *time.Time
 	finishedAt *time.Time
 }
 
-func startCmdConsumer(cmds <-chan *exec.Cmd) <-chan cmdResult {
-	results := make(chan cmdResult)
+// interruptOnTimeout interrupts the command if it doesn't finish before the
+// timeout and kills it if it's still running after [timeoutKillGracePeriod],
+// similar to what happens when CTRL-C is pressed 3x.
+// The returned function must be called after the command finishes and it
+// tells if the command timed out.
+func interruptOnTimeout(cmd *exec.Cmd, timeout time.Duration, logger zerolog.Logger) func() bool {
+	done := make(chan struct{})
+	timedOut := make(chan bool, 1)
 	go func() {
-		for cmd := range cmds {
-			err := cmd.Wait()
-			endTime := time.Now().UTC()
-
-			results <- cmdResult{
-				cmd:        cmd,
-				err:        err,
-				finishedAt: &endTime,
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case <-done:
+			timedOut <- false
+			return
+		case <-timer.C:
+		}
+
+		logger.Warn().
+			Dur("timeout", timeout).
+			Msg("command timed out, sending interrupt signal")
+
+		grace := timeoutKillGracePeriod
+		if err := cmd.Process.Signal(os.Interrupt); err != nil {
+			logger.Debug().Err(err).Msg("unable to send interrupt signal to child process")
+			grace = 0
+		}
+
+		graceTimer := time.NewTimer(grace)
+		defer graceTimer.Stop()
+
+		select {
+		case <-done:
+		case <-graceTimer.C:
+			logger.Warn().Msg("command did not exit after interrupted, killing it")
+			if err := cmd.Process.Kill(); err != nil {
+				logger.Debug().Err(err).Msg("unable to send kill signal to child process")
 			}
 		}
-		close(results)
+		timedOut <- true
 	}()
-	return results
+
+	return func() bool {
+		close(done)
+		return <-timedOut
+	}
+}
+
+// failedAttempt is a failed attempt of executing a stack command which is
+// waiting to be retried.
+type failedAttempt struct {
+	res RunResult
+	err error
+}
+
+const (
+	// retryBaseDelay is the delay before the first retry of a command. It
+	// doubles at each subsequent retry, up to retryMaxDelay.
+	retryBaseDelay = time.Second
+	retryMaxDelay  = 30 * time.Second
+)
+
+// retryBackoff returns how long to wait before retrying a command which
+// failed in the given attempt (starting at 1).
+func retryBackoff(attempt int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempt && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return delay
+}
+
+// shouldRetry tells if a command which failed with the given exit code in
+// the given attempt (starting at 1) must be retried.
+func (p runPolicy) shouldRetry(attempt int, exitCode int) bool {
+	if attempt > p.Retries {
+		return false
+	}
+	if len(p.RetryOnExitCodes) == 0 {
+		return true
+	}
+	for _, code := range p.RetryOnExitCodes {
+		if code == exitCode {
+			return true
+		}
+	}
+	return false
+}
+
+// runPolicy computes the timeout and retry policy of the stack. The command
+// line flags have precedence over the stack.run block, which has precedence
+// over the terramate.config.run block.
+func (c *cli) runPolicy(st *config.Stack) runPolicy {
+	var policy runPolicy
+	apply := func(cfg hcl.RunPolicy) {
+		if cfg.Timeout != nil {
+			policy.Timeout = *cfg.Timeout
+		}
+		if cfg.Retries != nil {
+			policy.Retries = *cfg.Retries
+		}
+		if cfg.RetryOnExitCodes != nil {
+			policy.RetryOnExitCodes = cfg.RetryOnExitCodes
+		}
+	}
+
+	cfg := c.rootNode()
+	if cfg.Terramate != nil &&
+		cfg.Terramate.Config != nil &&
+		cfg.Terramate.Config.Run != nil {
+		apply(cfg.Terramate.Config.Run.RunPolicy)
+	}
+
+	apply(st.RunPolicy)
+
+	if c.parsedArgs.Run.Timeout != nil {
+		policy.Timeout = *c.parsedArgs.Run.Timeout
+	}
+	if c.parsedArgs.Run.Retries != nil {
+		policy.Retries = *c.parsedArgs.Run.Retries
+	}
+	if len(c.parsedArgs.Run.RetryOnExitCode) > 0 {
+		policy.RetryOnExitCodes = c.parsedArgs.Run.RetryOnExitCode
+	}
+	return policy
+}
+
+// syncWriter serializes the writes of concurrent processes into the
+// underlying writer.
+type syncWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+// newSyncWriters creates writers for stdout and stderr which share the same
+// lock, so they can be safely used even if both write to the same destination.
+func newSyncWriters(stdout, stderr io.Writer) (io.Writer, io.Writer) {
+	mu := &sync.Mutex{}
+	return &syncWriter{mu: mu, w: stdout}, &syncWriter{mu: mu, w: stderr}
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+// reverseDependencies inverts the direction of the dependencies, so each stack
+// depends on the stacks that previously depended on it.
+func reverseDependencies(deps map[prj.Path]prj.Paths) map[prj.Path]prj.Paths {
+	reversed := map[prj.Path]prj.Paths{}
+	for stackdir, stackDeps := range deps {
+		if _, ok := reversed[stackdir]; !ok {
+			reversed[stackdir] = prj.Paths{}
+		}
+		for _, dep := range stackDeps {
+			reversed[dep] = append(reversed[dep], stackdir)
+		}
+	}
+	for _, stackDeps := range reversed {
+		stackDeps.Sort()
+	}
+	return reversed
 }
 
 func newEnvironFrom(stackEnviron []string) []string {
@@ -0,0 +1,114 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/cloud"
+	"github.com/terramate-io/terramate/errors"
+	prj "github.com/terramate-io/terramate/project"
+)
+
+// rootStackLogName is the name of the log file of a stack at the project root.
+const rootStackLogName = "_root.log"
+
+// newLinePrefixWriter creates a writer which writes each line written to it
+// into out prefixed with the given prefix, which may be empty. Each line is
+// written with a single call, so lines of concurrent writers are not mixed
+// when out is serialized.
+// The returned function must be called after all data was written and it
+// waits until all lines were flushed into out.
+func newLinePrefixWriter(out io.Writer, prefix string) (io.Writer, func()) {
+	r, w := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		var pending []byte
+		errs := errors.L()
+		for {
+			lines, rest, readErr := cloud.ReadLines(r, pending)
+			if readErr != nil && readErr != io.EOF {
+				errs.Append(readErr)
+				break
+			}
+			if readErr == io.EOF && len(rest) > 0 {
+				lines = [][]byte{rest}
+			}
+			for _, line := range lines {
+				prefixed := make([]byte, 0, len(prefix)+len(line))
+				prefixed = append(prefixed, prefix...)
+				prefixed = append(prefixed, line...)
+				if _, err := out.Write(prefixed); err != nil {
+					errs.Append(errors.E(err, "writing command output"))
+				}
+			}
+			if readErr == io.EOF {
+				break
+			}
+			pending = rest
+		}
+
+		errs.Append(r.Close())
+		if err := errs.AsError(); err != nil {
+			log.Error().Err(err).Msg("writing command output lines")
+		}
+	}()
+	return w, func() {
+		_ = w.Close()
+		<-done
+	}
+}
+
+// stackLogFiles manages the log files of the stacks of an execution.
+// Each stack has a single log file, truncated when first opened in the
+// execution, receiving both the stdout and stderr of all its commands.
+type stackLogFiles struct {
+	dir   string
+	files map[prj.Path]*os.File
+}
+
+func newStackLogFiles(dir string) *stackLogFiles {
+	return &stackLogFiles{
+		dir:   dir,
+		files: map[prj.Path]*os.File{},
+	}
+}
+
+// open returns the log file of the given stack, creating it if needed.
+func (l *stackLogFiles) open(stackdir prj.Path) (*os.File, error) {
+	if f, ok := l.files[stackdir]; ok {
+		return f, nil
+	}
+
+	fname := filepath.Join(l.dir, filepath.FromSlash(stackdir.String())) + ".log"
+	if stackdir.String() == "/" {
+		fname = filepath.Join(l.dir, rootStackLogName)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fname), 0755); err != nil {
+		return nil, errors.E(err, "creating log directory for stack %s", stackdir)
+	}
+
+	f, err := os.Create(fname)
+	if err != nil {
+		return nil, errors.E(err, "creating log file for stack %s", stackdir)
+	}
+	l.files[stackdir] = f
+	return f, nil
+}
+
+// close closes all the opened log files.
+func (l *stackLogFiles) close() error {
+	errs := errors.L()
+	for _, f := range l.files {
+		errs.Append(f.Close())
+	}
+	l.files = map[prj.Path]*os.File{}
+	return errs.AsError()
+}
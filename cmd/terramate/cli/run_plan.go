@@ -0,0 +1,139 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"strings"
+
+	prj "github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/run"
+)
+
+// redactedEnvValue replaces the value of secret-looking environment variables
+// in the execution plan.
+const redactedEnvValue = "<redacted>"
+
+// secretEnvNameParts are the parts of environment variable names which make
+// them considered secrets.
+var secretEnvNameParts = []string{
+	"SECRET",
+	"TOKEN",
+	"PASSWORD",
+	"PASSWD",
+	"CREDENTIAL",
+	"PRIVATE",
+	"AUTH",
+	"API_KEY",
+	"ACCESS_KEY",
+}
+
+// secretEnvValuePrefixes are well known prefixes of secret values.
+var secretEnvValuePrefixes = []string{
+	"AKIA",        // AWS access key ID
+	"ghp_",        // GitHub personal access token
+	"gho_",        // GitHub OAuth token
+	"github_pat_", // GitHub fine-grained personal access token
+	"glpat-",      // GitLab personal access token
+	"xoxb-",       // Slack bot token
+	"-----BEGIN",  // PEM encoded keys
+}
+
+// runPlan is the execution plan of `terramate run --dry-run --format json`.
+type runPlan struct {
+	Stacks []runPlanStack `json:"stacks"`
+}
+
+type runPlanStack struct {
+	Order      int               `json:"order"`
+	Path       string            `json:"path"`
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Reason     string            `json:"reason"`
+	Command    []string          `json:"command"`
+	WorkingDir string            `json:"working_dir"`
+	Env        map[string]string `json:"env"`
+
+	// Dependencies are the stacks of the plan which must finish before this
+	// stack starts, directly or through stacks not in the plan.
+	Dependencies []string `json:"dependencies"`
+
+	// OrderEdges are the edges of the order DAG placing other stacks, in the
+	// plan or not, right before this stack.
+	OrderEdges []runPlanEdge `json:"order_edges"`
+}
+
+type runPlanEdge struct {
+	Stack  string   `json:"stack"`
+	Kinds  []string `json:"kinds"`
+	InPlan bool     `json:"in_plan"`
+}
+
+// printRunPlan prints the execution plan of the given stacks as JSON.
+// The edges are the order DAG edges of each stack, as given by [run.OrderEdges].
+func (c *cli) printRunPlan(runStacks []ExecContext, edges map[prj.Path][]run.OrderEdge) {
+	stackEnvs, err := c.loadAllStackEnvs(runStacks)
+	if err != nil {
+		fatal(err, "loading stacks run environment")
+	}
+
+	inPlan := map[prj.Path]struct{}{}
+	for _, runContext := range runStacks {
+		inPlan[runContext.Stack.Dir] = struct{}{}
+	}
+
+	plan := runPlan{
+		Stacks: []runPlanStack{},
+	}
+	for i, runContext := range runStacks {
+		st := runContext.Stack
+		env := map[string]string{}
+		for _, envVar := range stackEnvs[st.Dir] {
+			name, value, _ := strings.Cut(envVar, "=")
+			env[name] = redactEnvValue(name, value)
+		}
+		planEdges := []runPlanEdge{}
+		for _, edge := range edges[st.Dir] {
+			_, ok := inPlan[edge.Stack]
+			planEdges = append(planEdges, runPlanEdge{
+				Stack:  edge.Stack.String(),
+				Kinds:  nonNilStrings(edge.Kinds),
+				InPlan: ok,
+			})
+		}
+		plan.Stacks = append(plan.Stacks, runPlanStack{
+			Order:        i,
+			Path:         st.Dir.String(),
+			ID:           st.ID,
+			Name:         st.Name,
+			Reason:       runContext.Reason,
+			Command:      nonNilStrings(runContext.Cmd),
+			WorkingDir:   st.HostDir(c.cfg()),
+			Env:          env,
+			Dependencies: nonNilStrings(runContext.Deps.Strings()),
+			OrderEdges:   planEdges,
+		})
+	}
+	c.outputJSON(plan)
+}
+
+// redactEnvValue returns the value of the environment variable, or
+// [redactedEnvValue] if either its name or its value looks like a secret.
+// The rules are documented in docs/cmdline/run.md and must be kept in sync.
+func redactEnvValue(name, value string) string {
+	upperName := strings.ToUpper(name)
+	for _, part := range secretEnvNameParts {
+		if strings.Contains(upperName, part) {
+			return redactedEnvValue
+		}
+	}
+	if strings.HasSuffix(upperName, "_KEY") || upperName == "KEY" {
+		return redactedEnvValue
+	}
+	for _, prefix := range secretEnvValuePrefixes {
+		if strings.HasPrefix(value, prefix) {
+			return redactedEnvValue
+		}
+	}
+	return value
+}
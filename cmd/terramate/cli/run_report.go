@@ -0,0 +1,140 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/terramate-io/terramate/errors"
+	prj "github.com/terramate-io/terramate/project"
+)
+
+// runStatus is the final status of a stack execution in the run report.
+type runStatus string
+
+const (
+	runStatusSuccess  runStatus = "success"
+	runStatusFailed   runStatus = "failed"
+	runStatusCanceled runStatus = "canceled"
+	runStatusSkipped  runStatus = "skipped"
+)
+
+// runReport is the report of a [cli.RunAll] execution, written as JSON
+// with the --report-file flag.
+type runReport struct {
+	StartedAt   time.Time        `json:"started_at"`
+	FinishedAt  time.Time        `json:"finished_at"`
+	Interrupted bool             `json:"interrupted"`
+	Stacks      []runReportStack `json:"stacks"`
+
+	// Error is the error of the whole execution, if any. It's set even if
+	// no stack was started, like when loading the stacks environment fails.
+	Error string `json:"error,omitempty"`
+}
+
+type runReportStack struct {
+	Path       string     `json:"path"`
+	ID         string     `json:"id"`
+	Command    []string   `json:"command"`
+	Reason     string     `json:"reason"`
+	Status     runStatus  `json:"status"`
+	ExitCode   *int       `json:"exit_code,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+
+	// BlockedBy is the failed stack which caused this stack to be skipped.
+	BlockedBy string `json:"blocked_by,omitempty"`
+
+	// Attempts has the result of each execution of the command. The fields
+	// above are the same as of the last attempt.
+	Attempts []runReportAttempt `json:"attempts,omitempty"`
+}
+
+type runReportAttempt struct {
+	ExitCode   int        `json:"exit_code"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+func newRunReport(runStacks []ExecContext) *runReport {
+	report := &runReport{
+		StartedAt: time.Now().UTC(),
+		Stacks:    make([]runReportStack, len(runStacks)),
+	}
+	for i, runContext := range runStacks {
+		report.Stacks[i] = runReportStack{
+			Path:    runContext.Stack.Dir.String(),
+			ID:      runContext.Stack.ID,
+			Command: runContext.Cmd,
+			Reason:  runContext.Reason,
+		}
+	}
+	return report
+}
+
+func (r *runReport) setStatus(index int, status runStatus) {
+	r.Stacks[index].Status = status
+}
+
+// setFailed marks the stack as failed before its command could be started.
+func (r *runReport) setFailed(index int, err error) {
+	r.Stacks[index].Status = runStatusFailed
+	r.Stacks[index].Error = err.Error()
+}
+
+// setBlocked marks the stack as skipped because it depends on the given
+// failed stack.
+func (r *runReport) setBlocked(index int, blocking prj.Path) {
+	r.Stacks[index].Status = runStatusSkipped
+	r.Stacks[index].BlockedBy = blocking.String()
+}
+
+// setResult sets the result of an attempt of executing the stack command.
+func (r *runReport) setResult(index int, status runStatus, res RunResult, err error) {
+	exitCode := res.ExitCode
+	entry := &r.Stacks[index]
+	entry.Status = status
+	entry.ExitCode = &exitCode
+	entry.StartedAt = res.StartedAt
+	entry.FinishedAt = res.FinishedAt
+	entry.Error = ""
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	entry.Attempts = append(entry.Attempts, runReportAttempt{
+		ExitCode:   exitCode,
+		StartedAt:  res.StartedAt,
+		FinishedAt: res.FinishedAt,
+		Error:      entry.Error,
+	})
+}
+
+// finish sets the end of the execution with its resulting error. The stacks
+// without a status were never started and are marked as skipped.
+func (r *runReport) finish(err error) {
+	r.FinishedAt = time.Now().UTC()
+	for i := range r.Stacks {
+		if r.Stacks[i].Status == "" {
+			r.Stacks[i].Status = runStatusSkipped
+		}
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+func (r *runReport) write(fname string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return errors.E(err, "encoding run report")
+	}
+	if err := os.WriteFile(fname, append(data, '\n'), 0644); err != nil {
+		return errors.E(err, "writing run report to %s", fname)
+	}
+	return nil
+}
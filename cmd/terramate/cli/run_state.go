@@ -0,0 +1,206 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	prj "github.com/terramate-io/terramate/project"
+)
+
+// ErrRunResume indicates that a previous execution cannot be resumed.
+const ErrRunResume errors.Kind = "cannot resume execution"
+
+const (
+	// runStateDir is the directory of the project where the run state is
+	// kept. It's ignored by git and by the configuration loading.
+	runStateDir = ".terramate"
+
+	// runStateFilename is the name of the file keeping the state of the last
+	// execution of terramate run.
+	runStateFilename = "run-state.json"
+)
+
+// runStateStatus is the status of a stack in the run state.
+type runStateStatus string
+
+const (
+	runStatePending   runStateStatus = "pending"
+	runStateCompleted runStateStatus = "completed"
+	runStateFailed    runStateStatus = "failed"
+)
+
+// runState is the state of a terramate run execution, persisted so a failed
+// or interrupted execution can be continued with --resume.
+type runState struct {
+	// Head is the commit checked out when the execution started.
+	Head    string          `json:"head"`
+	Command []string        `json:"command"`
+	Eval    bool            `json:"eval"`
+	Stacks  []runStateStack `json:"stacks"`
+
+	file string
+}
+
+type runStateStack struct {
+	Path   string         `json:"path"`
+	Status runStateStatus `json:"status"`
+}
+
+func newRunState(file, head string, cmd []string, eval bool, stacks config.List[*config.SortableStack]) *runState {
+	state := &runState{
+		Head:    head,
+		Command: cmd,
+		Eval:    eval,
+		Stacks:  make([]runStateStack, len(stacks)),
+		file:    file,
+	}
+	for i, st := range stacks {
+		state.Stacks[i] = runStateStack{
+			Path:   st.Dir().String(),
+			Status: runStatePending,
+		}
+	}
+	return state
+}
+
+// loadRunState loads the run state from the given file.
+func loadRunState(file string) (*runState, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.E(ErrRunResume, "no failed or interrupted execution found")
+		}
+		return nil, errors.E(ErrRunResume, err, "reading run state")
+	}
+	state := &runState{file: file}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, errors.E(ErrRunResume, err, "parsing run state file %s", file)
+	}
+	return state, nil
+}
+
+// checkResumable checks that the state was created for the same commit and
+// the same ordered list of stacks.
+func (s *runState) checkResumable(head string, stacks config.List[*config.SortableStack]) error {
+	if s.Head != head {
+		return errors.E(ErrRunResume,
+			"HEAD changed from %s to %s since the failed execution", s.Head, head)
+	}
+	if len(s.Stacks) != len(stacks) {
+		return errors.E(ErrRunResume, "the selected stacks changed since the failed execution")
+	}
+	for i, st := range stacks {
+		if s.Stacks[i].Path != st.Dir().String() {
+			return errors.E(ErrRunResume, "the selected stacks changed since the failed execution")
+		}
+	}
+	return nil
+}
+
+// resumeIndex returns the index of the first stack which didn't complete in
+// the execution, which is the stack the execution is resumed from.
+func (s *runState) resumeIndex() int {
+	for i, st := range s.Stacks {
+		if st.Status != runStateCompleted {
+			return i
+		}
+	}
+	return len(s.Stacks)
+}
+
+// resume prepares the state for continuing the execution from the stack at
+// the given index with the given command. The stacks from the index on are
+// pending again.
+func (s *runState) resume(from int, cmd []string, eval bool) {
+	s.Command = cmd
+	s.Eval = eval
+	for i := from; i < len(s.Stacks); i++ {
+		s.Stacks[i].Status = runStatePending
+	}
+}
+
+// set updates the status of the stack and saves the state.
+func (s *runState) set(stackdir prj.Path, status runStateStatus) {
+	for i, st := range s.Stacks {
+		if st.Path == stackdir.String() {
+			s.Stacks[i].Status = status
+		}
+	}
+	if err := s.save(); err != nil {
+		log.Warn().Err(err).Msg("saving run state")
+	}
+}
+
+func (s *runState) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return errors.E(err, "encoding run state")
+	}
+	dir := filepath.Dir(s.file)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.E(err, "creating run state directory")
+	}
+	// the state is local to the working copy, then the directory ignores
+	// itself so it's never reported as untracked files.
+	gitignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(gitignore); os.IsNotExist(err) {
+		if err := os.WriteFile(gitignore, []byte("*\n"), 0644); err != nil {
+			return errors.E(err, "writing %s", gitignore)
+		}
+	}
+	if err := os.WriteFile(s.file, append(data, '\n'), 0644); err != nil {
+		return errors.E(err, "writing run state file %s", s.file)
+	}
+	return nil
+}
+
+// remove removes the state file, as there's nothing left to resume.
+func (s *runState) remove() error {
+	if err := os.Remove(s.file); err != nil && !os.IsNotExist(err) {
+		return errors.E(err, "removing run state file %s", s.file)
+	}
+	return nil
+}
+
+// runStateFile returns the path of the run state file.
+func (c *cli) runStateFile() string {
+	return filepath.Join(c.rootdir(), runStateDir, runStateFilename)
+}
+
+// dataDir returns the directory where Terramate keeps local caches, like the
+// code generation cache. Inside a git repository it's kept
+// in the git directory so it's never reported as untracked files, otherwise
+// in the .terramate directory of the project.
+func (c *cli) dataDir() string {
+	if c.prj.isRepo {
+		gitdir, err := c.prj.git.wrapper.RevParse("--absolute-git-dir")
+		if err != nil {
+			fatal(err, "looking up the git directory")
+		}
+		return filepath.Join(gitdir, "terramate")
+	}
+	return filepath.Join(c.rootdir(), ".terramate")
+}
+
+// runStateHead returns the HEAD commit saved in the run state. It's empty
+// outside a git repository or if the repository has no commits yet.
+func (c *cli) runStateHead() string {
+	if !c.prj.isRepo {
+		return ""
+	}
+	hasCommits, err := c.prj.git.wrapper.HasCommits()
+	if err != nil {
+		fatal(err, "checking if the repository has commits")
+	}
+	if !hasCommits {
+		return ""
+	}
+	return c.prj.headCommit()
+}
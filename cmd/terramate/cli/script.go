@@ -0,0 +1,257 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/ast"
+	prj "github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/run"
+	"github.com/terramate-io/terramate/run/dag"
+	"github.com/terramate-io/terramate/scripts"
+)
+
+// ErrScriptNotFound indicates that the script is not defined in the project.
+const ErrScriptNotFound errors.Kind = "script not found"
+
+type scriptListEntry struct {
+	Name        string   `json:"name"`
+	Labels      []string `json:"labels"`
+	Description string   `json:"description"`
+	Dir         string   `json:"dir"`
+}
+
+type scriptInfoEntry struct {
+	Stack       string          `json:"stack"`
+	Dir         string          `json:"definition_dir"`
+	Description string          `json:"description"`
+	Jobs        []scriptInfoJob `json:"jobs"`
+}
+
+type scriptInfoJob struct {
+	Commands [][]string `json:"commands"`
+}
+
+func (c *cli) runScript() {
+	labels := c.parsedArgs.Script.Run.Labels
+	scriptName := strings.Join(labels, " ")
+
+	logger := log.With().
+		Str("action", "cli.runScript()").
+		Str("workingDir", c.wd()).
+		Str("script", scriptName).
+		Logger()
+
+	c.gitSafeguardDefaultBranchIsReachable()
+	c.checkOutdatedGeneratedCode()
+
+	stacks, err := c.computeSelectedStacks(true)
+	if err != nil {
+		fatal(err, "computing selected stacks")
+	}
+
+	scriptStacks, defs := c.stacksWithScript(stacks, labels)
+	if len(scriptStacks) == 0 && !c.isScriptDefined(labels) {
+		fatal(errors.E(ErrScriptNotFound, "script %q is not defined by any stack", scriptName))
+	}
+
+	orderedStacks, reason, err := run.Sort(c.cfg(), scriptStacks)
+	if err != nil {
+		if errors.IsKind(err, dag.ErrCycleDetected) {
+			fatal(err, "cycle detected: %s", reason)
+		} else {
+			fatal(err, "failed to plan execution")
+		}
+	}
+
+	if c.parsedArgs.Script.Run.Reverse {
+		config.ReverseStacks(orderedStacks)
+	}
+
+	var runStacks []ExecContext
+	for _, st := range orderedStacks {
+		script, err := scripts.EvalStack(c.cfg(), st.Stack, defs[st.Dir()])
+		if err != nil {
+			fatal(err, "evaluating script %q in stack %s", scriptName, st.Dir())
+		}
+
+		for _, job := range script.Jobs {
+			for _, cmd := range job.Cmds {
+				runStacks = append(runStacks, ExecContext{
+					Stack: st.Stack,
+					Cmd:   cmd,
+				})
+			}
+		}
+	}
+
+	if c.parsedArgs.Script.Run.DryRun {
+		if len(runStacks) == 0 {
+			c.output.MsgStdOut("No stacks will be executed.")
+			return
+		}
+
+		c.output.MsgStdOut("The script commands will be executed using order below:")
+
+		for i, runContext := range runStacks {
+			stackdir, _ := c.friendlyFmtDir(runContext.Stack.Dir.String())
+			c.output.MsgStdOut("\t%d. %s (%s): %s", i, runContext.Stack.Name, stackdir,
+				strings.Join(runContext.Cmd, " "))
+		}
+		return
+	}
+
+	logger.Debug().
+		Int("commands", len(runStacks)).
+		Msg("running script")
+
+	err = c.RunAll(runStacks, runAllOptions{
+		ContinueOnError: c.parsedArgs.Script.Run.ContinueOnError,
+		Parallel:        1,
+	}, func(exitCode int) bool {
+		return exitCode == 0
+	})
+	if err != nil {
+		fatal(err, "one or more commands failed")
+	}
+}
+
+// stacksWithScript filters the stacks which have the script with the given labels
+// available. It also returns the script definition for each of them.
+func (c *cli) stacksWithScript(
+	stacks config.List[*config.SortableStack],
+	labels []string,
+) (config.List[*config.SortableStack], map[prj.Path]*hcl.Script) {
+	var filtered config.List[*config.SortableStack]
+	defs := map[prj.Path]*hcl.Script{}
+	for _, st := range stacks {
+		def, _, found := scripts.Lookup(c.cfg(), st.Dir(), labels)
+		if !found {
+			continue
+		}
+		filtered = append(filtered, st)
+		defs[st.Dir()] = def
+	}
+	return filtered, defs
+}
+
+// isScriptDefined tells if any stack of the project has the script with the
+// given labels available.
+func (c *cli) isScriptDefined(labels []string) bool {
+	for _, stackdir := range c.cfg().Stacks() {
+		if _, _, found := scripts.Lookup(c.cfg(), stackdir, labels); found {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cli) printScripts() {
+	var entries []scriptListEntry
+	for _, def := range scripts.Definitions(c.cfg()) {
+		desc, err := def.Description(c.cfg())
+		if err != nil {
+			log.Debug().
+				Err(err).
+				Str("script", def.Name()).
+				Msg("unable to evaluate the script description, showing the expression instead")
+
+			desc = string(ast.TokensForExpression(def.Script.Description.Expr).Bytes())
+		}
+		entries = append(entries, scriptListEntry{
+			Name:        def.Name(),
+			Labels:      def.Script.Labels,
+			Description: desc,
+			Dir:         def.Dir.String(),
+		})
+	}
+
+	if c.parsedArgs.Script.List.Format == "json" {
+		if entries == nil {
+			entries = []scriptListEntry{}
+		}
+		c.outputJSON(entries)
+		return
+	}
+
+	for _, entry := range entries {
+		c.output.MsgStdOut("%s", entry.Name)
+		c.output.MsgStdOut("\tDescription: %s", entry.Description)
+		c.output.MsgStdOut("\tDefined at: %s", entry.Dir)
+	}
+}
+
+func (c *cli) printScriptInfo() {
+	labels := c.parsedArgs.Script.Info.Labels
+	scriptName := strings.Join(labels, " ")
+
+	stacks, err := c.computeSelectedStacks(false)
+	if err != nil {
+		fatal(err, "computing selected stacks")
+	}
+
+	scriptStacks, _ := c.stacksWithScript(stacks, labels)
+	if len(scriptStacks) == 0 && !c.isScriptDefined(labels) {
+		fatal(errors.E(ErrScriptNotFound, "script %q is not defined by any stack", scriptName))
+	}
+
+	orderedStacks, reason, err := run.Sort(c.cfg(), scriptStacks)
+	if err != nil {
+		if errors.IsKind(err, dag.ErrCycleDetected) {
+			fatal(err, "cycle detected: %s", reason)
+		} else {
+			fatal(err, "failed to plan execution")
+		}
+	}
+
+	entries := []scriptInfoEntry{}
+	for _, st := range orderedStacks {
+		def, defdir, _ := scripts.Lookup(c.cfg(), st.Dir(), labels)
+		script, err := scripts.EvalStack(c.cfg(), st.Stack, def)
+		if err != nil {
+			fatal(err, "evaluating script %q in stack %s", scriptName, st.Dir())
+		}
+
+		entry := scriptInfoEntry{
+			Stack:       st.Dir().String(),
+			Dir:         defdir.String(),
+			Description: script.Description,
+		}
+		for _, job := range script.Jobs {
+			entry.Jobs = append(entry.Jobs, scriptInfoJob{Commands: job.Cmds})
+		}
+		entries = append(entries, entry)
+	}
+
+	if c.parsedArgs.Script.Info.Format == "json" {
+		c.outputJSON(entries)
+		return
+	}
+
+	for _, entry := range entries {
+		c.output.MsgStdOut("stack %s:", entry.Stack)
+		c.output.MsgStdOut("\tDescription: %s", entry.Description)
+		c.output.MsgStdOut("\tDefined at: %s", entry.Dir)
+		for i, job := range entry.Jobs {
+			c.output.MsgStdOut("\tJob %d:", i+1)
+			for _, cmd := range job.Commands {
+				c.output.MsgStdOut("\t\t%s", strings.Join(cmd, " "))
+			}
+		}
+	}
+}
+
+func (c *cli) outputJSON(val any) {
+	data, err := json.MarshalIndent(val, "", "  ")
+	if err != nil {
+		fatal(err, "encoding output as JSON")
+	}
+	c.output.MsgStdOut("%s", data)
+}
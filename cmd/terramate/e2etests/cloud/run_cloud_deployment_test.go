@@ -90,6 +90,37 @@ func TestCLIRunWithCloudSyncDeployment(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:     "every attempt of a retried command is synced",
+			layout:   []string{"s:stack"},
+			runflags: []string{"--retries=1"},
+			cmd:      []string{HelperPath, "false"},
+			want: want{
+				run: RunExpected{
+					Status:       1,
+					IgnoreStderr: true,
+				},
+				events: eventsResponse{
+					"stack": []string{"pending", "running", "failed", "running", "failed"},
+				},
+			},
+		},
+		{
+			name:     "timed out command is synced as failed",
+			layout:   []string{"s:stack"},
+			runflags: []string{"--timeout=500ms"},
+			cmd:      []string{HelperPath, "sleep", "5s"},
+			want: want{
+				run: RunExpected{
+					Status:      1,
+					Stdout:      "ready\n",
+					StderrRegex: "exceeded the timeout of 500ms",
+				},
+				events: eventsResponse{
+					"stack": []string{"pending", "running", "failed"},
+				},
+			},
+		},
 		{
 			name:   "failed cmd cancels execution of subsequent stacks",
 			layout: []string{"s:s1", "s:s2"},
@@ -0,0 +1,108 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/cmd/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/generate/genhcl"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGenerateDryRunDiff(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	stack := s.CreateStack("stack")
+	stack.CreateFile("gen.tm", `
+generate_file "changed.txt" {
+  content = "old\n"
+}
+
+generate_file "deleted.txt" {
+  content = "bye\n"
+}
+`)
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("generate"), RunExpected{IgnoreStdout: true})
+
+	stack.CreateFile("gen.tm", `
+generate_file "changed.txt" {
+  content = "new\n"
+}
+
+generate_file "created.txt" {
+  content = "hello\n"
+}
+
+generate_file "deleted.txt" {
+  condition = false
+  content   = "bye\n"
+}
+`)
+
+	s.RootEntry().CreateDir("orphan").CreateFile("orphan.tf", genhcl.Header+"\n\nx = 1\n")
+
+	AssertRunResult(t, cli.Run("generate", "--dry-run", "--diff"), RunExpected{
+		Status: 2,
+		Stdout: nljoin(
+			"--- a/orphan/orphan.tf",
+			"+++ /dev/null",
+			"@@ -1,3 +0,0 @@",
+			"-"+genhcl.Header,
+			"-",
+			"-x = 1",
+			"--- a/stack/changed.txt",
+			"+++ b/stack/changed.txt",
+			"@@ -1,1 +1,1 @@",
+			"-old",
+			"+new",
+			"--- /dev/null",
+			"+++ b/stack/created.txt",
+			"@@ -0,0 +1,1 @@",
+			"+hello",
+			"--- a/stack/deleted.txt",
+			"+++ /dev/null",
+			"@@ -1,1 +0,0 @@",
+			"-bye",
+		),
+	})
+
+	// nothing is changed on disk
+	assertFileContent(t, filepath.Join(stack.Path(), "changed.txt"), "old\n")
+	assertFileContent(t, filepath.Join(stack.Path(), "deleted.txt"), "bye\n")
+	assertFileContent(t, filepath.Join(s.RootDir(), "orphan", "orphan.tf"), genhcl.Header+"\n\nx = 1\n")
+	_, err := os.Stat(filepath.Join(stack.Path(), "created.txt"))
+	assert.IsTrue(t, os.IsNotExist(err), "created.txt must not exist")
+
+	AssertRunResult(t, cli.Run("generate"), RunExpected{IgnoreStdout: true})
+	AssertRunResult(t, cli.Run("generate", "--dry-run"), RunExpected{
+		Stdout: "Nothing to do, generated code is up to date\n",
+	})
+	AssertRunResult(t, cli.Run("generate", "--dry-run", "--diff"), RunExpected{})
+}
+
+func TestGenerateDiffRequiresDryRun(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("generate", "--diff"), RunExpected{
+		Status:      1,
+		StderrRegex: `--diff requires --dry-run`,
+	})
+}
+
+func assertFileContent(t *testing.T, fname string, want string) {
+	t.Helper()
+
+	data, err := os.ReadFile(fname)
+	assert.NoError(t, err)
+	assert.EqualStrings(t, want, string(data), "file %s", fname)
+}
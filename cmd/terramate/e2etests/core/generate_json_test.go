@@ -0,0 +1,112 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/cmd/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+type generateReport struct {
+	Successes []struct {
+		Dir     string            `json:"dir"`
+		Created []string          `json:"created"`
+		Changed []string          `json:"changed"`
+		Deleted []string          `json:"deleted"`
+		Diffs   map[string]string `json:"diffs"`
+	} `json:"successes"`
+	Failures []struct {
+		Dir    string `json:"dir"`
+		Errors []struct {
+			Kind    string `json:"kind"`
+			Message string `json:"message"`
+			Label   string `json:"label"`
+			Range   *struct {
+				File  string `json:"file"`
+				Start struct {
+					Line int `json:"line"`
+				} `json:"start"`
+			} `json:"range"`
+		} `json:"errors"`
+	} `json:"failures"`
+}
+
+func TestGenerateJSONReport(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.CreateStack("ok").CreateFile("gen.tm", `
+generate_file "ok.txt" {
+  content = "ok"
+}
+`)
+	s.CreateStack("bad").CreateFile("gen.tm", `
+generate_hcl "bad.tf" {
+  content {
+    a = global.undefined
+  }
+}
+`)
+
+	cli := NewCLI(t, s.RootDir())
+	res := cli.Run("generate", "--format", "json")
+	AssertRunResult(t, res, RunExpected{
+		Status:       1,
+		IgnoreStdout: true,
+	})
+
+	var report generateReport
+	assert.NoError(t, json.Unmarshal([]byte(res.Stdout), &report))
+
+	assert.EqualInts(t, 1, len(report.Successes))
+	assert.EqualStrings(t, "/ok", report.Successes[0].Dir)
+	assert.EqualInts(t, 1, len(report.Successes[0].Created))
+	assert.EqualStrings(t, "ok.txt", report.Successes[0].Created[0])
+	assert.EqualInts(t, 0, len(report.Successes[0].Diffs))
+
+	assert.EqualInts(t, 1, len(report.Failures))
+	failure := report.Failures[0]
+	assert.EqualStrings(t, "/bad", failure.Dir)
+	assert.EqualInts(t, 1, len(failure.Errors))
+
+	err := failure.Errors[0]
+	assert.EqualStrings(t, "evaluating content block", err.Kind)
+	assert.EqualStrings(t, "bad.tf", err.Label)
+	assert.IsTrue(t, err.Message != "")
+	assert.IsTrue(t, err.Range != nil, "error must have a range")
+	assert.EqualStrings(t, "/bad/gen.tm", err.Range.File)
+	assert.EqualInts(t, 4, err.Range.Start.Line)
+}
+
+func TestGenerateDryRunJSONReport(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.CreateStack("stack").CreateFile("gen.tm", `
+generate_file "file.txt" {
+  content = "hello\n"
+}
+`)
+
+	cli := NewCLI(t, s.RootDir())
+	res := cli.Run("generate", "--dry-run", "--format", "json")
+	AssertRunResult(t, res, RunExpected{
+		Status:       2,
+		IgnoreStdout: true,
+	})
+
+	var report generateReport
+	assert.NoError(t, json.Unmarshal([]byte(res.Stdout), &report))
+	assert.EqualInts(t, 1, len(report.Successes))
+	assert.EqualStrings(t, "/stack", report.Successes[0].Dir)
+	assert.EqualStrings(t,
+		"--- /dev/null\n+++ b/stack/file.txt\n@@ -0,0 +1,1 @@\n+hello\n",
+		report.Successes[0].Diffs["file.txt"],
+	)
+	assert.EqualInts(t, 0, len(report.Failures))
+}
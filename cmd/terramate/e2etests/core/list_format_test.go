@@ -0,0 +1,67 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"testing"
+
+	. "github.com/terramate-io/terramate/cmd/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestListFormat(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stack-a:id=stack-a;tags=["prod"]`,
+		`s:stack-b:after=["/stack-a"]`,
+	})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+	git.Push("main")
+	git.CheckoutNew("change-stack-b")
+
+	s.DirEntry("stack-b").CreateFile("main.tf", "# changed")
+	git.CommitAll("change stack-b")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.ListStacks("--format", "json"), RunExpected{
+		Stdout: `[
+  {
+    "path": "/stack-a",
+    "id": "stack-a",
+    "name": "stack-a",
+    "tags": [
+      "prod"
+    ],
+    "after": [],
+    "before": []
+  },
+  {
+    "path": "/stack-b",
+    "id": "",
+    "name": "stack-b",
+    "tags": [],
+    "after": [
+      "/stack-a"
+    ],
+    "before": []
+  }
+]
+`,
+	})
+
+	AssertRunResult(t, cli.ListChangedStacks("--format", "ndjson"), RunExpected{
+		Stdout: nljoin(
+			`{"path":"/stack-b","id":"","name":"stack-b","tags":[],"after":["/stack-a"],"before":[],"reason":{"kind":"files_changed","description":"stack has unmerged changes","paths":["/stack-b/main.tf"]}}`,
+		),
+	})
+
+	AssertRunResult(t, cli.ListStacks("--format", "yaml"), RunExpected{
+		StderrRegex: "--format must be one of",
+		Status:      1,
+	})
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/terramate-io/terramate/cmd/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestListIncludeOrderedStacks(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:vpc:tags=["vpc"]`,
+		`s:app:tags=["app"];after=["/vpc"]`,
+		`s:app/child`,
+		`s:db:before=["/app"]`,
+		`s:other`,
+	})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	cli := NewCLI(t, s.RootDir())
+
+	AssertRunResult(t, cli.ListStacks("--tags", "vpc", "--include-dependents"), RunExpected{
+		Stdout: nljoin("app", "app/child", "vpc"),
+	})
+
+	AssertRunResult(t, cli.ListStacks("--tags", "app", "--include-dependencies"), RunExpected{
+		Stdout: nljoin("app", "db", "vpc"),
+	})
+
+	AssertRunResult(t, cli.ListStacks("--tags", "app", "--include-dependents", "--include-dependencies"), RunExpected{
+		Stdout: nljoin("app", "app/child", "db", "vpc"),
+	})
+
+	cli = NewCLI(t, filepath.Join(s.RootDir(), "app"))
+	AssertRunResult(t, cli.ListStacks("--include-dependencies"), RunExpected{
+		Stdout: nljoin(".", "child", "/db", "/vpc"),
+	})
+}
+
+func TestRunIncludeDependents(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:vpc:tags=["vpc"]`,
+		`s:app:after=["/vpc"]`,
+		`s:app/child`,
+		`s:db:before=["/app"]`,
+		`s:other`,
+	})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("run", "--tags", "vpc", "--include-dependents",
+		HelperPath, "stack-abs-path", s.RootDir()), RunExpected{
+		Stdout: nljoin("/vpc", "/app", "/app/child"),
+	})
+}
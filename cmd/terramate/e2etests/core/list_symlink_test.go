@@ -0,0 +1,54 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"runtime"
+	"testing"
+
+	. "github.com/terramate-io/terramate/cmd/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestListChangedThroughSymlinks(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks are not supported on windows")
+	}
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stack-a`,
+		`s:stack-b`,
+		`s:stack-c`,
+		`f:shared/providers.tf:# providers`,
+		`f:shared/mod/main.tf:# module`,
+		`l:shared/providers.tf:stack-a/providers.tf`,
+		`l:shared/mod:stack-b/mod`,
+		`l:stack-c/loop2:stack-c/loop1`,
+		`l:stack-c/loop1:stack-c/loop2`,
+	})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+	git.Push("main")
+	git.CheckoutNew("change-shared")
+
+	s.RootEntry().CreateFile("shared/providers.tf", "# changed providers")
+	s.RootEntry().CreateFile("shared/mod/main.tf", "# changed module")
+	git.CommitAll("change shared files")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.ListChangedStacks(), RunExpected{
+		Stdout: nljoin("stack-a", "stack-b"),
+	})
+
+	AssertRunResult(t, cli.ListChangedStacks("--format", "ndjson"), RunExpected{
+		Stdout: nljoin(
+			`{"path":"/stack-a","id":"","name":"stack-a","tags":[],"after":[],"before":[],"reason":{"kind":"symlink_target_changed","description":"stack changed because symlink \"/stack-a/providers.tf\" points to changed \"/shared/providers.tf\"","paths":["/stack-a/providers.tf","/shared/providers.tf"]}}`,
+			`{"path":"/stack-b","id":"","name":"stack-b","tags":[],"after":[],"before":[],"reason":{"kind":"symlink_target_changed","description":"stack changed because symlink \"/stack-b/mod\" points to changed \"/shared/mod\"","paths":["/stack-b/mod","/shared/mod"]}}`,
+		),
+	})
+}
@@ -196,18 +196,21 @@ func TestListWatchExprWithFuncalls(t *testing.T) {
 	AssertRunResult(t, cli.ListChangedStacks(), want)
 }
 
-func TestListWatchDirectoryFails(t *testing.T) {
+func TestListWatchDirectory(t *testing.T) {
 	t.Parallel()
 
 	s := sandbox.New(t)
 
 	extDir := s.RootEntry().CreateDir("external")
-	extFile := extDir.CreateFile("file.txt", "anything")
+	extFile := extDir.CreateDir("dir").CreateFile("file.txt", "anything")
 
 	s.BuildTree([]string{
 		`s:stack:watch=["/external"]`,
+		`s:other-stack:watch=["/external-other"]`,
 	})
 
+	stack := s.LoadStack(project.NewPath("/stack"))
+
 	cli := NewCLI(t, s.RootDir())
 
 	git := s.Git()
@@ -219,8 +222,62 @@ func TestListWatchDirectoryFails(t *testing.T) {
 	git.CommitAll("external file changed")
 
 	want := RunExpected{
-		Status:      1,
-		StderrRegex: string(config.ErrStackInvalidWatch),
+		Stdout: stack.RelPath() + "\n",
 	}
 	AssertRunResult(t, cli.ListChangedStacks(), want)
 }
+
+func TestListWatchGlobPatterns(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stack-rego:watch=["/policies/**/*.rego", "!/policies/drafts/**"]`,
+		`s:stack-json:watch=["../policies/*.json"]`,
+		`f:policies/prod/main.rego:package prod`,
+		`f:policies/drafts/main.rego:package drafts`,
+		`f:policies/config.json:{}`,
+	})
+
+	cli := NewCLI(t, s.RootDir())
+
+	git := s.Git()
+	git.CommitAll("all")
+	git.Push("main")
+
+	git.CheckoutNew("change-drafts")
+	s.RootEntry().CreateFile("policies/drafts/main.rego", "package changed")
+	s.RootEntry().CreateFile("policies/drafts/new.rego", "package new")
+	git.CommitAll("drafts changed")
+
+	AssertRun(t, cli.ListChangedStacks())
+
+	git.CheckoutNew("change-prod")
+	s.RootEntry().CreateFile("policies/prod/nested/new.rego", "package new")
+	git.CommitAll("new prod policy")
+
+	AssertRunResult(t, cli.ListChangedStacks(), RunExpected{
+		Stdout: "stack-rego\n",
+	})
+
+	AssertRunResult(t, cli.ListChangedStacks("--format", "ndjson"), RunExpected{
+		Stdout: nljoin(
+			`{"path":"/stack-rego","id":"","name":"stack-rego","tags":[],"after":[],"before":[],"reason":{"kind":"watch_file","description":"stack changed because watched file \"/policies/prod/nested/new.rego\" changed","paths":["/policies/prod/nested/new.rego"]}}`,
+		),
+	})
+}
+
+func TestListWatchInvalidGlobFails(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stack:watch=["/policies/[*.rego"]`,
+	})
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.ListStacks(), RunExpected{
+		Status:      1,
+		StderrRegex: string(config.ErrStackInvalidWatch),
+	})
+}
@@ -0,0 +1,50 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/cmd/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestRunPrefixOutputAndLogDir(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stack-a`,
+		`s:stack-b/nested`,
+		"f:stack-a/out.txt:a1\na2\n",
+		"f:stack-b/nested/out.txt:b1\nb2",
+	})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	logdir := t.TempDir()
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("run", "--prefix-output", "--log-dir", logdir,
+		HelperPath, "cat", "out.txt"),
+		RunExpected{
+			Stdout: "[/stack-a] a1\n[/stack-a] a2\n[/stack-b/nested] b1\n[/stack-b/nested] b2",
+		},
+	)
+
+	assert.EqualStrings(t, "a1\na2\n",
+		string(test.ReadFile(t, logdir, "stack-a.log")))
+	assert.EqualStrings(t, "b1\nb2",
+		string(test.ReadFile(t, filepath.Join(logdir, "stack-b"), "nested.log")))
+
+	AssertRunResult(t, cli.Run("run", HelperPath, "cat", "out.txt"),
+		RunExpected{
+			Stdout: "a1\na2\nb1\nb2",
+		},
+	)
+}
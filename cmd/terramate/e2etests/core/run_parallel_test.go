@@ -0,0 +1,86 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"testing"
+
+	. "github.com/terramate-io/terramate/cmd/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestRunParallelHonorsOrder(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stack-a`,
+		`s:stack-b:after=["/stack-a"]`,
+		`s:stack-c:after=["/stack-b"]`,
+		"f:stack-a/file.txt:stack-a\n",
+		"f:stack-b/file.txt:stack-b\n",
+		"f:stack-c/file.txt:stack-c\n",
+	})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+	git.Push("main")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("run", "--parallel", "3", HelperPath, "cat", "file.txt"),
+		RunExpected{
+			Stdout: nljoin("stack-a", "stack-b", "stack-c"),
+		},
+	)
+
+	AssertRunResult(t, cli.Run("run", "--parallel", "3", "--reverse", HelperPath, "cat", "file.txt"),
+		RunExpected{
+			Stdout: nljoin("stack-c", "stack-b", "stack-a"),
+		},
+	)
+}
+
+func TestRunParallelStopsOnError(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stack-a`,
+		`s:stack-b:after=["/stack-a"]`,
+		"f:stack-b/file.txt:stack-b\n",
+	})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+	git.Push("main")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("run", "--parallel", "2", HelperPath, "cat", "file.txt"),
+		RunExpected{
+			StderrRegex: "one or more commands failed",
+			Status:      1,
+		},
+	)
+}
+
+func TestRunParallelMustBePositive(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stack`,
+	})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+	git.Push("main")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("run", "--parallel", "0", HelperPath, "true"),
+		RunExpected{
+			StderrRegex: "--parallel must be greater than zero",
+			Status:      1,
+		},
+	)
+}
@@ -0,0 +1,187 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/cmd/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/hclwrite"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+type runPlan struct {
+	Stacks []struct {
+		Order        int               `json:"order"`
+		Path         string            `json:"path"`
+		ID           string            `json:"id"`
+		Name         string            `json:"name"`
+		Reason       string            `json:"reason"`
+		Command      []string          `json:"command"`
+		WorkingDir   string            `json:"working_dir"`
+		Env          map[string]string `json:"env"`
+		Dependencies []string          `json:"dependencies"`
+		OrderEdges   []runPlanEdge     `json:"order_edges"`
+	} `json:"stacks"`
+}
+
+type runPlanEdge struct {
+	Stack  string   `json:"stack"`
+	Kinds  []string `json:"kinds"`
+	InPlan bool     `json:"in_plan"`
+}
+
+func TestRunDryRunJSONPlan(t *testing.T) {
+	t.Parallel()
+
+	run := func(builders ...hclwrite.BlockBuilder) *hclwrite.Block {
+		return hclwrite.BuildBlock("run", builders...)
+	}
+	env := func(builders ...hclwrite.BlockBuilder) *hclwrite.Block {
+		return hclwrite.BuildBlock("env", builders...)
+	}
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:vpc:id=vpc`,
+		`s:app:id=app;after=["/vpc"]`,
+		`s:app/child:id=child`,
+	})
+
+	s.RootEntry().CreateFile("env.tm", Terramate(
+		Config(
+			run(
+				env(
+					Expr("STACK_NAME", "terramate.stack.name"),
+					Str("DB_PASSWORD", "hunter2"),
+					Str("GITHUB_VALUE", "ghp_0123456789"),
+				),
+			),
+		),
+	).String())
+
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	cli := NewCLI(t, s.RootDir())
+	res := cli.Run("run", "--dry-run", "--format", "json", "--eval",
+		"echo", "${terramate.stack.path.absolute}")
+	AssertRunResult(t, res, RunExpected{IgnoreStdout: true})
+
+	var plan runPlan
+	assert.NoError(t, json.Unmarshal([]byte(res.Stdout), &plan))
+	assert.EqualInts(t, 3, len(plan.Stacks))
+
+	wantPaths := []string{"/vpc", "/app", "/app/child"}
+	wantDeps := [][]string{{}, {"/vpc"}, {"/app"}}
+	for i, st := range plan.Stacks {
+		assert.EqualInts(t, i, st.Order)
+		assert.EqualStrings(t, wantPaths[i], st.Path)
+		assert.EqualStrings(t, "stack is inside the working directory", st.Reason)
+		assert.EqualInts(t, 2, len(st.Command))
+		assert.EqualStrings(t, "echo", st.Command[0])
+		assert.EqualStrings(t, wantPaths[i], st.Command[1])
+		assert.EqualStrings(t, filepath.Join(s.RootDir(), filepath.FromSlash(wantPaths[i][1:])), st.WorkingDir)
+		assert.EqualStrings(t, filepath.Base(wantPaths[i]), st.Env["STACK_NAME"])
+		assert.EqualStrings(t, "<redacted>", st.Env["DB_PASSWORD"])
+		assert.EqualStrings(t, "<redacted>", st.Env["GITHUB_VALUE"])
+
+		assert.EqualInts(t, len(wantDeps[i]), len(st.Dependencies), "stack %s", st.Path)
+		for j, dep := range wantDeps[i] {
+			assert.EqualStrings(t, dep, st.Dependencies[j])
+		}
+	}
+
+	assert.EqualStrings(t, "vpc", plan.Stacks[0].ID)
+	assert.EqualStrings(t, "app", plan.Stacks[1].ID)
+}
+
+func TestRunDryRunJSONPlanOrderEdges(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:net`,
+		`s:vpc:tags=["plan"]`,
+		`s:db:tags=["plan"];before=["/app"]`,
+		`s:app:tags=["plan"];after=["/vpc", "/net"]`,
+		`s:app/child:tags=["plan"]`,
+	})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	cli := NewCLI(t, s.RootDir())
+
+	assertEdges := func(reverse bool, want map[string][]runPlanEdge) {
+		t.Helper()
+
+		args := []string{"run", "--dry-run", "--format", "json", "--tags", "plan"}
+		if reverse {
+			args = append(args, "--reverse")
+		}
+		res := cli.Run(append(args, "echo")...)
+		AssertRunResult(t, res, RunExpected{IgnoreStdout: true})
+
+		var plan runPlan
+		assert.NoError(t, json.Unmarshal([]byte(res.Stdout), &plan))
+		assert.EqualInts(t, len(want), len(plan.Stacks))
+
+		for _, st := range plan.Stacks {
+			if diff := cmp.Diff(want[st.Path], st.OrderEdges); diff != "" {
+				t.Fatalf("stack %s (reverse=%t) edges mismatch (-want +got):\n%s", st.Path, reverse, diff)
+			}
+		}
+	}
+
+	assertEdges(false, map[string][]runPlanEdge{
+		"/vpc": {},
+		"/db":  {},
+		"/app": {
+			{Stack: "/db", Kinds: []string{"before"}, InPlan: true},
+			{Stack: "/net", Kinds: []string{"after"}, InPlan: false},
+			{Stack: "/vpc", Kinds: []string{"after"}, InPlan: true},
+		},
+		// the before of /db also applies to the stacks inside /app.
+		"/app/child": {
+			{Stack: "/app", Kinds: []string{"parent"}, InPlan: true},
+			{Stack: "/db", Kinds: []string{"before"}, InPlan: true},
+		},
+	})
+
+	assertEdges(true, map[string][]runPlanEdge{
+		"/vpc": {
+			{Stack: "/app", Kinds: []string{"after"}, InPlan: true},
+		},
+		"/db": {
+			{Stack: "/app", Kinds: []string{"before"}, InPlan: true},
+			{Stack: "/app/child", Kinds: []string{"before"}, InPlan: true},
+		},
+		"/app": {
+			{Stack: "/app/child", Kinds: []string{"parent"}, InPlan: true},
+		},
+		"/app/child": {},
+	})
+}
+
+func TestRunFormatRequiresDryRun(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{`s:stack`})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("run", "--format", "json", HelperPath, "true"), RunExpected{
+		Status:      1,
+		StderrRegex: `--format=json requires --dry-run`,
+	})
+}
@@ -0,0 +1,169 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/cmd/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+type runReport struct {
+	StartedAt   time.Time `json:"started_at"`
+	FinishedAt  time.Time `json:"finished_at"`
+	Interrupted bool      `json:"interrupted"`
+	Error       string    `json:"error"`
+	Stacks      []struct {
+		Path       string     `json:"path"`
+		ID         string     `json:"id"`
+		Command    []string   `json:"command"`
+		Reason     string     `json:"reason"`
+		Status     string     `json:"status"`
+		ExitCode   *int       `json:"exit_code"`
+		StartedAt  *time.Time `json:"started_at"`
+		FinishedAt *time.Time `json:"finished_at"`
+		Error      string     `json:"error"`
+		BlockedBy  string     `json:"blocked_by"`
+		Attempts   []struct {
+			ExitCode int    `json:"exit_code"`
+			Error    string `json:"error"`
+		} `json:"attempts"`
+	} `json:"stacks"`
+}
+
+func loadRunReport(t *testing.T, fname string) runReport {
+	t.Helper()
+
+	data, err := os.ReadFile(fname)
+	assert.NoError(t, err)
+
+	var report runReport
+	assert.NoError(t, json.Unmarshal(data, &report))
+	return report
+}
+
+func TestRunReportFile(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stack-a:id=stack-a`,
+		`s:stack-b:id=stack-b`,
+		`s:stack-c:id=stack-c`,
+	})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	reportFile := filepath.Join(t.TempDir(), "run.json")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("run", "--report-file", reportFile, "--eval",
+		HelperPathAsHCL,
+		`${terramate.stack.path.absolute == "/stack-b" ? "false" : "true"}`,
+	), RunExpected{
+		Status:       1,
+		IgnoreStderr: true,
+	})
+
+	report := loadRunReport(t, reportFile)
+
+	assert.IsTrue(t, !report.Interrupted)
+	assert.IsTrue(t, !report.FinishedAt.Before(report.StartedAt))
+	assert.EqualInts(t, 3, len(report.Stacks))
+
+	wantStatus := []string{"success", "failed", "skipped"}
+	for i, st := range report.Stacks {
+		assert.EqualStrings(t, wantStatus[i], st.Status, "stack %s", st.Path)
+		assert.EqualStrings(t, "stack is inside the working directory", st.Reason)
+		assert.EqualInts(t, 2, len(st.Command))
+	}
+
+	stackA, stackB, stackC := report.Stacks[0], report.Stacks[1], report.Stacks[2]
+	assert.EqualStrings(t, "/stack-a", stackA.Path)
+	assert.EqualStrings(t, "stack-a", stackA.ID)
+	assert.EqualStrings(t, "true", stackA.Command[1])
+	assert.IsTrue(t, stackA.ExitCode != nil && *stackA.ExitCode == 0)
+	assert.IsTrue(t, stackA.StartedAt != nil && stackA.FinishedAt != nil)
+
+	assert.EqualStrings(t, "/stack-b", stackB.Path)
+	assert.IsTrue(t, stackB.ExitCode != nil && *stackB.ExitCode == 1)
+	assert.IsTrue(t, stackB.Error != "")
+
+	assert.EqualStrings(t, "/stack-c", stackC.Path)
+	assert.IsTrue(t, stackC.ExitCode == nil)
+	assert.IsTrue(t, stackC.StartedAt == nil)
+}
+
+func TestRunReportFileHasTheSelectionReason(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stack-a:tags=["k8s"]`,
+		`s:stack-b`,
+	})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	reportFile := filepath.Join(t.TempDir(), "run.json")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("run", "--tags", "k8s", "--report-file", reportFile,
+		HelperPath, "true",
+	), RunExpected{
+		IgnoreStderr: true,
+	})
+
+	report := loadRunReport(t, reportFile)
+	assert.EqualInts(t, 1, len(report.Stacks))
+	assert.EqualStrings(t, "/stack-a", report.Stacks[0].Path)
+	assert.EqualStrings(t, "stack is inside the working directory and matches --tags k8s",
+		report.Stacks[0].Reason)
+}
+
+func TestRunReportFileIsWrittenIfNoStackStarts(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stack-a`,
+		`s:stack-b`,
+		`f:terramate.tm:terramate {
+  config {
+    run {
+      env {
+        INVALID = 1
+      }
+    }
+  }
+}`,
+	})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	reportFile := filepath.Join(t.TempDir(), "run.json")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("run", "--report-file", reportFile, HelperPath, "true"), RunExpected{
+		Status:       1,
+		IgnoreStderr: true,
+	})
+
+	report := loadRunReport(t, reportFile)
+	assert.IsTrue(t, report.Error != "", "want the execution error in the report")
+	assert.EqualInts(t, 2, len(report.Stacks))
+	for _, st := range report.Stacks {
+		assert.EqualStrings(t, "skipped", st.Status, "stack %s", st.Path)
+		assert.IsTrue(t, st.StartedAt == nil)
+	}
+}
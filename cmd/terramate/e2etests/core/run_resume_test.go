@@ -0,0 +1,151 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/cmd/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestRunResume(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stack-a`,
+		`s:stack-b`,
+		`s:stack-c`,
+		"f:stack-a/ok.txt:a\n",
+		"f:stack-c/ok.txt:c\n",
+	})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	cli := NewCLI(t, s.RootDir())
+
+	AssertRunResult(t, cli.Run("run", "--disable-check-git-untracked",
+		HelperPath, "cat", "ok.txt"), RunExpected{
+		Stdout:       "a\n",
+		Status:       1,
+		IgnoreStderr: true,
+	})
+
+	s.RootEntry().CreateFile("stack-b/ok.txt", "b\n")
+
+	reportFile := filepath.Join(t.TempDir(), "run.json")
+	AssertRunResult(t, cli.Run("run", "--disable-check-git-untracked", "--resume",
+		"--report-file", reportFile), RunExpected{
+		Stdout: "b\nc\n",
+	})
+
+	report := loadRunReport(t, reportFile)
+	assert.EqualInts(t, 2, len(report.Stacks))
+	assert.EqualStrings(t, "/stack-b", report.Stacks[0].Path)
+	assert.EqualStrings(t, "/stack-c", report.Stacks[1].Path)
+
+	AssertRunResult(t, cli.Run("run", "--disable-check-git-untracked", "--resume"),
+		RunExpected{
+			Status:      1,
+			StderrRegex: "no failed or interrupted execution found",
+		})
+
+	assert.NoError(t, os.Remove(filepath.Join(s.RootDir(), "stack-b", "ok.txt")))
+
+	AssertRunResult(t, cli.Run("run", HelperPath, "cat", "ok.txt"), RunExpected{
+		Stdout:       "a\n",
+		Status:       1,
+		IgnoreStderr: true,
+	})
+
+	s.RootEntry().CreateFile("stack-b/ok.txt", "b\n")
+	git.CommitAll("add stack-b/ok.txt")
+
+	AssertRunResult(t, cli.Run("run", "--resume"), RunExpected{
+		Status:      1,
+		StderrRegex: "HEAD changed",
+	})
+}
+
+func TestRunResumeRefusesChangedStacks(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stack-a`,
+		`s:stack-b`,
+	})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("run", HelperPath, "false"), RunExpected{
+		Status:       1,
+		IgnoreStderr: true,
+	})
+
+	AssertRunResult(t, cli.Run("run", "--resume", "--tags", "none"), RunExpected{
+		Status:      1,
+		StderrRegex: "the selected stacks changed",
+	})
+}
+
+func TestRunResumeWithOverriddenCommand(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stack-a`,
+		`s:stack-b`,
+		`s:stack-c`,
+		"f:stack-a/ok.txt:a\n",
+		"f:stack-c/ok.txt:c\n",
+	})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	cli := NewCLI(t, s.RootDir())
+	stateFile := filepath.Join(s.RootDir(), ".terramate", "run-state.json")
+
+	AssertRunResult(t, cli.Run("run", "--dry-run", HelperPath, "cat", "ok.txt"), RunExpected{
+		IgnoreStdout: true,
+	})
+	_, err := os.Stat(stateFile)
+	assert.IsTrue(t, os.IsNotExist(err), "dry run must not save the run state")
+
+	AssertRunResult(t, cli.Run("run", "--continue-on-error",
+		HelperPath, "cat", "ok.txt"), RunExpected{
+		Stdout:       "a\nc\n",
+		Status:       1,
+		IgnoreStderr: true,
+	})
+
+	s.RootEntry().CreateFile("stack-b/other.txt", "b\n")
+
+	// stack-c completed after the failure but it's executed again, failing
+	// with the new command.
+	AssertRunResult(t, cli.Run("run", "--disable-check-git-untracked", "--resume", "--continue-on-error",
+		HelperPath, "cat", "other.txt"), RunExpected{
+		Stdout:       "b\n",
+		Status:       1,
+		IgnoreStderr: true,
+	})
+
+	s.RootEntry().CreateFile("stack-c/other.txt", "c\n")
+
+	// the command given to the last resume is kept in the state.
+	AssertRunResult(t, cli.Run("run", "--disable-check-git-untracked", "--resume"), RunExpected{
+		Stdout: "c\n",
+	})
+
+	_, err = os.Stat(stateFile)
+	assert.IsTrue(t, os.IsNotExist(err), "run state must be removed after success")
+}
@@ -0,0 +1,135 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/cmd/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestRunRetries(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stack`,
+		`f:terramate.tm:terramate {
+  config {
+    run {
+      retries = 1
+    }
+  }
+}`,
+	})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	cli := NewCLI(t, s.RootDir())
+
+	reportFile := filepath.Join(t.TempDir(), "run.json")
+	AssertRunResult(t, cli.Run("run", "--report-file", reportFile, HelperPath, "false"),
+		RunExpected{
+			Status:       1,
+			IgnoreStderr: true,
+		})
+
+	report := loadRunReport(t, reportFile)
+	assert.EqualInts(t, 1, len(report.Stacks))
+	assert.EqualStrings(t, "failed", report.Stacks[0].Status)
+	assert.EqualInts(t, 2, len(report.Stacks[0].Attempts), "terramate.config.run.retries")
+
+	AssertRunResult(t, cli.Run("run", "--retries", "2", "--report-file", reportFile, HelperPath, "false"),
+		RunExpected{
+			Status:       1,
+			IgnoreStderr: true,
+		})
+
+	report = loadRunReport(t, reportFile)
+	assert.EqualInts(t, 3, len(report.Stacks[0].Attempts), "--retries overrides the config")
+	for _, attempt := range report.Stacks[0].Attempts {
+		assert.EqualInts(t, 1, attempt.ExitCode)
+	}
+
+	AssertRunResult(t, cli.Run("run", "--retry-on-exit-code", "2", "--report-file", reportFile, HelperPath, "false"),
+		RunExpected{
+			Status:       1,
+			IgnoreStderr: true,
+		})
+
+	report = loadRunReport(t, reportFile)
+	assert.EqualInts(t, 1, len(report.Stacks[0].Attempts), "exit code is not retried")
+
+	AssertRunResult(t, cli.Run("run", "--retries", "0", "--report-file", reportFile, HelperPath, "false"),
+		RunExpected{
+			Status:       1,
+			IgnoreStderr: true,
+		})
+
+	report = loadRunReport(t, reportFile)
+	assert.EqualInts(t, 1, len(report.Stacks[0].Attempts), "--retries=0 disables the config retries")
+}
+
+func TestRunRejectsNegativePolicyFlags(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{`s:stack`})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("run", "--timeout=-1s", HelperPath, "true"),
+		RunExpected{
+			Status:      1,
+			StderrRegex: "--timeout must not be negative",
+		})
+	AssertRunResult(t, cli.Run("run", "--retries=-1", HelperPath, "true"),
+		RunExpected{
+			Status:      1,
+			StderrRegex: "--retries must not be negative",
+		})
+}
+
+func TestRunTimeout(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stack-a`,
+		`f:stack-b/stack.tm.hcl:stack {
+  run {
+    timeout = "500ms"
+  }
+}`,
+	})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	cli := NewCLI(t, s.RootDir())
+
+	reportFile := filepath.Join(t.TempDir(), "run.json")
+	res := cli.Run("run", "--continue-on-error", "--report-file", reportFile, HelperPath, "sleep", "2s")
+	AssertRunResult(t, res, RunExpected{
+		Status:       1,
+		IgnoreStdout: true,
+		IgnoreStderr: true,
+	})
+
+	report := loadRunReport(t, reportFile)
+	assert.EqualInts(t, 2, len(report.Stacks))
+	assert.EqualStrings(t, "/stack-a", report.Stacks[0].Path)
+	assert.EqualStrings(t, "/stack-b", report.Stacks[1].Path)
+	assert.EqualStrings(t, "success", report.Stacks[0].Status)
+	assert.EqualStrings(t, "failed", report.Stacks[1].Status)
+	assert.IsTrue(t, strings.Contains(report.Stacks[1].Error, "timed out"),
+		"unexpected error: %s", report.Stacks[1].Error)
+}
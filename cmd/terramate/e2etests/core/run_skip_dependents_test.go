@@ -0,0 +1,83 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/cmd/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestRunSkipDependents(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:a`,
+		`s:a/child`,
+		`s:b:after=["/a"]`,
+		`s:c:after=["/b"]`,
+		`s:d`,
+		`s:e:after=["/d"]`,
+	})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	reportFile := filepath.Join(t.TempDir(), "run.json")
+
+	cli := NewCLI(t, s.RootDir())
+	cli.LogLevel = "warn"
+	AssertRunResult(t, cli.Run("run", "--skip-dependents", "--report-file", reportFile, "--eval",
+		HelperPathAsHCL,
+		`${terramate.stack.path.absolute == "/a" ? "false" : "true"}`,
+	), RunExpected{
+		Status:      1,
+		StderrRegex: `skipping stack because it depends on a failed stack`,
+	})
+
+	report := loadRunReport(t, reportFile)
+
+	type want struct {
+		status    string
+		blockedBy string
+	}
+
+	wants := map[string]want{
+		"/a":       {status: "failed"},
+		"/a/child": {status: "skipped", blockedBy: "/a"},
+		"/b":       {status: "skipped", blockedBy: "/a"},
+		"/c":       {status: "skipped", blockedBy: "/a"},
+		"/d":       {status: "success"},
+		"/e":       {status: "success"},
+	}
+
+	assert.EqualInts(t, len(wants), len(report.Stacks))
+	for _, st := range report.Stacks {
+		w, ok := wants[st.Path]
+		assert.IsTrue(t, ok, "unexpected stack %s", st.Path)
+		assert.EqualStrings(t, w.status, st.Status, "stack %s", st.Path)
+		assert.EqualStrings(t, w.blockedBy, st.BlockedBy, "stack %s", st.Path)
+	}
+}
+
+func TestRunSkipDependentsConflictsWithContinueOnError(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{`s:stack`})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("run", "--skip-dependents", "--continue-on-error", HelperPath, "true"),
+		RunExpected{
+			Status:      1,
+			StderrRegex: `--continue-on-error conflicts with --skip-dependents`,
+		})
+}
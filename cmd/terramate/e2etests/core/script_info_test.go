@@ -0,0 +1,164 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"testing"
+
+	. "github.com/terramate-io/terramate/cmd/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestScriptListAndInfo(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stacks/stack-a`,
+		`s:stacks/stack-b`,
+		`s:other`,
+	})
+
+	s.RootEntry().CreateFile("terramate.tm", `
+terramate {
+  config {
+    experiments = ["scripts"]
+  }
+}
+`)
+
+	s.DirEntry("stacks").CreateFile("script.tm", `
+script "deploy" {
+  description = "Deploy stacks"
+  job {
+    command = ["echo", terramate.stack.name]
+  }
+}
+`)
+
+	git := s.Git()
+	git.CommitAll("first commit")
+	git.Push("main")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("script", "list"), RunExpected{
+		Stdout: nljoin(
+			"deploy",
+			"\tDescription: Deploy stacks",
+			"\tDefined at: /stacks",
+		),
+	})
+
+	AssertRunResult(t, cli.Run("script", "list", "--format", "json"), RunExpected{
+		Stdout: `[
+  {
+    "name": "deploy",
+    "labels": [
+      "deploy"
+    ],
+    "description": "Deploy stacks",
+    "dir": "/stacks"
+  }
+]
+`,
+	})
+
+	AssertRunResult(t, cli.Run("script", "info", "deploy"), RunExpected{
+		Stdout: nljoin(
+			"stack /stacks/stack-a:",
+			"\tDescription: Deploy stacks",
+			"\tDefined at: /stacks",
+			"\tJob 1:",
+			"\t\techo stack-a",
+			"stack /stacks/stack-b:",
+			"\tDescription: Deploy stacks",
+			"\tDefined at: /stacks",
+			"\tJob 1:",
+			"\t\techo stack-b",
+		),
+	})
+
+	AssertRunResult(t, cli.Run("script", "info", "--format", "json", "deploy"), RunExpected{
+		Stdout: `[
+  {
+    "stack": "/stacks/stack-a",
+    "definition_dir": "/stacks",
+    "description": "Deploy stacks",
+    "jobs": [
+      {
+        "commands": [
+          [
+            "echo",
+            "stack-a"
+          ]
+        ]
+      }
+    ]
+  },
+  {
+    "stack": "/stacks/stack-b",
+    "definition_dir": "/stacks",
+    "description": "Deploy stacks",
+    "jobs": [
+      {
+        "commands": [
+          [
+            "echo",
+            "stack-b"
+          ]
+        ]
+      }
+    ]
+  }
+]
+`,
+	})
+}
+
+func TestScriptListShowsUniqueLabelPaths(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stacks/stack-a`,
+		`s:stacks/stack-b`,
+	})
+
+	s.RootEntry().CreateFile("terramate.tm", `
+terramate {
+  config {
+    experiments = ["scripts"]
+  }
+}
+
+script "deploy" {
+  description = "Deploy all"
+  job {
+    command = ["echo", "all"]
+  }
+}
+`)
+
+	s.DirEntry("stacks/stack-a").CreateFile("script.tm", `
+script "deploy" {
+  description = "Deploy stack-a"
+  job {
+    command = ["echo", "stack-a"]
+  }
+}
+`)
+
+	git := s.Git()
+	git.CommitAll("first commit")
+	git.Push("main")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("script", "list"), RunExpected{
+		Stdout: nljoin(
+			"deploy",
+			"\tDescription: Deploy all",
+			"\tDefined at: /",
+		),
+	})
+}
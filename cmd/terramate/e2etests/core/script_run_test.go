@@ -0,0 +1,131 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/terramate-io/terramate/cmd/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestScriptRun(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stack-a`,
+		`s:stack-b:after=["/stack-a"]`,
+		`s:other`,
+	})
+
+	s.RootEntry().CreateFile("terramate.tm", `
+terramate {
+  config {
+    experiments = ["scripts"]
+  }
+}
+
+globals {
+  helper = %q
+}
+`, HelperPath)
+
+	s.DirEntry("stack-a").CreateFile("script.tm", scriptDeployConfig("stack-a"))
+	s.DirEntry("stack-b").CreateFile("script.tm", scriptDeployConfig("stack-b"))
+
+	git := s.Git()
+	git.CommitAll("first commit")
+	git.Push("main")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("script", "run", "deploy"), RunExpected{
+		Stdout: nljoin(
+			"init stack-a stack-a",
+			"apply stack-a",
+			"init stack-b stack-b",
+			"apply stack-b",
+		),
+	})
+
+	AssertRunResult(t, cli.Run("script", "run", "--reverse", "deploy"), RunExpected{
+		Stdout: nljoin(
+			"init stack-b stack-b",
+			"apply stack-b",
+			"init stack-a stack-a",
+			"apply stack-a",
+		),
+	})
+
+	AssertRunResult(t, cli.Run("script", "run", "not-found"), RunExpected{
+		StderrRegex: "script not found",
+		Status:      1,
+	})
+}
+
+func TestScriptRunContinueOnErrorStopsFailedStackJobs(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stack-a`,
+		`s:stack-b`,
+	})
+
+	s.RootEntry().CreateFile("terramate.tm", `
+terramate {
+  config {
+    experiments = ["scripts"]
+  }
+}
+
+globals {
+  helper = %q
+}
+`, HelperPath)
+
+	s.DirEntry("stack-a").CreateFile("script.tm", `
+script "deploy" {
+  description = "deploy stack-a"
+  job {
+    command = [global.helper, "false"]
+  }
+  job {
+    command = [global.helper, "echo", "after failure"]
+  }
+}
+`)
+	s.DirEntry("stack-b").CreateFile("script.tm", scriptDeployConfig("stack-b"))
+
+	git := s.Git()
+	git.CommitAll("first commit")
+	git.Push("main")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("script", "run", "--continue-on-error", "deploy"), RunExpected{
+		Stdout: nljoin(
+			"init stack-b stack-b",
+			"apply stack-b",
+		),
+		StderrRegex: "one or more commands failed",
+		Status:      1,
+	})
+}
+
+func scriptDeployConfig(name string) string {
+	return fmt.Sprintf(`
+script "deploy" {
+  description = "deploy %s"
+  job {
+    command = [global.helper, "echo", "init", terramate.stack.name, "%s"]
+  }
+  job {
+    commands = [
+      [global.helper, "echo", "apply", terramate.stack.name],
+    ]
+  }
+}
+`, name, name)
+}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/rs/zerolog/log"
 	"github.com/terramate-io/terramate"
@@ -39,6 +40,19 @@ type Root struct {
 	tree Tree
 
 	runtime project.Runtime
+
+	// repoPrefix is the path of the project root relative to the root of the
+	// git repository containing it.
+	repoPrefix string
+
+	// values caches the values computed from the configuration, see
+	// [Root.CachedValue].
+	values *valuesCache
+}
+
+type valuesCache struct {
+	mu   sync.Mutex
+	vals map[string]cty.Value
 }
 
 // Tree is the configuration tree.
@@ -104,7 +118,8 @@ func TryLoadConfig(fromdir string) (tree *Root, configpath string, found bool, e
 // NewRoot creates a new [Root] tree for the cfg tree.
 func NewRoot(tree *Tree) *Root {
 	r := &Root{
-		tree: *tree,
+		tree:   *tree,
+		values: &valuesCache{vals: map[string]cty.Value{}},
 	}
 	r.initRuntime()
 	return r
@@ -223,6 +238,7 @@ func (root *Root) LoadSubTree(cfgdir project.Path) error {
 	} else {
 		node.Parent = parentNode
 		parentNode.Children[nextComponent] = node
+		root.values = &valuesCache{vals: map[string]cty.Value{}}
 	}
 	return nil
 }
@@ -242,6 +258,62 @@ func (root *Root) Runtime() project.Runtime {
 	return runtime
 }
 
+// CachedValue returns the value cached for the given key, computing it with fn
+// if it's not cached yet. The cache is discarded when the configuration
+// changes and failures of fn are not cached.
+func (root *Root) CachedValue(key string, fn func() (cty.Value, error)) (cty.Value, error) {
+	root.values.mu.Lock()
+	val, ok := root.values.vals[key]
+	root.values.mu.Unlock()
+	if ok {
+		return val, nil
+	}
+
+	// fn is called without holding the lock because it may need other
+	// cached values.
+	val, err := fn()
+	if err != nil {
+		return cty.NilVal, err
+	}
+
+	root.values.mu.Lock()
+	root.values.vals[key] = val
+	root.values.mu.Unlock()
+	return val, nil
+}
+
+// ExportedGlobals returns the names of the globals that stacks export to
+// other stacks, as defined by terramate.config.stacks.exported_globals.
+func (root *Root) ExportedGlobals() []string {
+	cfg := root.tree.Node.Terramate
+	if cfg == nil || cfg.Config == nil || cfg.Config.Stacks == nil {
+		return nil
+	}
+	return cfg.Config.Stacks.ExportedGlobals
+}
+
+// SetRepositoryRoot sets the root directory of the git repository containing
+// the project. It fails if the project root is not inside repodir.
+func (root *Root) SetRepositoryRoot(repodir string) error {
+	rel, err := filepath.Rel(repodir, root.HostDir())
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return errors.E("project root %q is not inside the repository %q", root.HostDir(), repodir)
+	}
+	root.repoPrefix = ""
+	if rel != "." {
+		root.repoPrefix = filepath.ToSlash(rel)
+	}
+	return nil
+}
+
+// RepositoryPath returns the given project path as an absolute path relative
+// to the root of the git repository containing the project. If the repository
+// root was not set with [Root.SetRepositoryRoot] then the project path is
+// returned.
+func (root *Root) RepositoryPath(p project.Path) string {
+	return path.Join("/", root.repoPrefix, p.String())
+}
+
 func (root *Root) initRuntime() {
 	rootfs := cty.ObjectVal(map[string]cty.Value{
 		"absolute": cty.StringVal(root.HostDir()),
@@ -330,6 +330,32 @@ func TestConfigSkipdir(t *testing.T) {
 	assert.IsTrue(t, !found)
 }
 
+func TestConfigRepositoryPath(t *testing.T) {
+	t.Parallel()
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:/stack",
+	})
+
+	root := s.Config()
+	stack := project.NewPath("/stack")
+	assert.EqualStrings(t, "/stack", root.RepositoryPath(stack))
+
+	repodir := filepath.Dir(filepath.Dir(s.RootDir()))
+	assert.NoError(t, root.SetRepositoryRoot(repodir))
+	want := "/" + filepath.ToSlash(filepath.Join(
+		filepath.Base(filepath.Dir(s.RootDir())),
+		filepath.Base(s.RootDir()),
+	)) + "/stack"
+	assert.EqualStrings(t, want, root.RepositoryPath(stack))
+
+	assert.NoError(t, root.SetRepositoryRoot(s.RootDir()))
+	assert.EqualStrings(t, "/stack", root.RepositoryPath(stack))
+
+	err := root.SetRepositoryRoot(filepath.Join(s.RootDir(), "stack"))
+	assert.IsTrue(t, err != nil)
+}
+
 func isStack(root *config.Root, dir string) bool {
 	return config.IsStack(root, filepath.Join(root.HostDir(), dir))
 }
@@ -0,0 +1,47 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/mapexpr"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// EvalForEach calls fn for each file generated by a generate block with the
+// given label and for_each configuration.
+//
+// If cfg is nil then fn is called once with the given label and evalctx.
+// Otherwise fn is called for each element of the for_each value, with the
+// label template evaluated for the element and a copy of evalctx having the
+// iterator variable defined.
+func EvalForEach(
+	evalctx *eval.Context,
+	label string,
+	cfg *hcl.ForEachConfig,
+	fn func(label string, evalctx *eval.Context) error,
+) error {
+	if cfg == nil {
+		return fn(label, evalctx)
+	}
+
+	return mapexpr.ForEach(evalctx, cfg.Expr, cfg.Iterator, func(evalctx *eval.Context) error {
+		val, err := evalctx.Eval(cfg.Label)
+		if err != nil {
+			return errors.E(err, "evaluating label %q", label)
+		}
+		if val.Type() != cty.String {
+			return errors.E(ErrSchema, cfg.Label.Range(),
+				"label %q must evaluate to a string, got %s",
+				label, val.Type().FriendlyName())
+		}
+		if val.IsNull() || !val.IsKnown() {
+			return errors.E(ErrSchema, cfg.Label.Range(),
+				"label %q must evaluate to a known and non-null string", label)
+		}
+		return fn(val.AsString(), evalctx)
+	})
+}
@@ -51,9 +51,19 @@ type (
 		// whenever they are selected.
 		WantedBy []string
 
-		// Watch is the list of files to be watched for changes.
+		// Watch is the list of files, directories or glob patterns to be
+		// watched for changes.
 		Watch []project.Path
 
+		// WatchExclude is the list of negated watch patterns (prefixed
+		// with "!" in the configuration). A file matching any of them is
+		// never considered watched.
+		WatchExclude []project.Path
+
+		// RunPolicy is the timeout and retry policy of the stack, overriding
+		// the terramate.config.run policy. Nil fields are not set.
+		RunPolicy hcl.RunPolicy
+
 		// IsChanged tells if this is a changed stack.
 		IsChanged bool
 	}
@@ -91,22 +101,26 @@ func NewStackFromHCL(root string, cfg hcl.Config) (*Stack, error) {
 		name = filepath.Base(cfg.AbsDir())
 	}
 
-	watchFiles, err := validateWatchPaths(root, cfg.AbsDir(), cfg.Stack.Watch)
+	watchFiles, watchExclude, err := validateWatchPaths(root, cfg.AbsDir(), cfg.Stack.Watch)
 	if err != nil {
 		return nil, errors.E(err, ErrStackInvalidWatch)
 	}
 
 	stack := &Stack{
-		Name:        name,
-		ID:          cfg.Stack.ID,
-		Description: cfg.Stack.Description,
-		Tags:        cfg.Stack.Tags,
-		After:       cfg.Stack.After,
-		Before:      cfg.Stack.Before,
-		Wants:       cfg.Stack.Wants,
-		WantedBy:    cfg.Stack.WantedBy,
-		Watch:       watchFiles,
-		Dir:         project.PrjAbsPath(root, cfg.AbsDir()),
+		Name:         name,
+		ID:           cfg.Stack.ID,
+		Description:  cfg.Stack.Description,
+		Tags:         cfg.Stack.Tags,
+		After:        cfg.Stack.After,
+		Before:       cfg.Stack.Before,
+		Wants:        cfg.Stack.Wants,
+		WantedBy:     cfg.Stack.WantedBy,
+		Watch:        watchFiles,
+		WatchExclude: watchExclude,
+		Dir:          project.PrjAbsPath(root, cfg.AbsDir()),
+	}
+	if cfg.Stack.Run != nil {
+		stack.RunPolicy = *cfg.Stack.Run
 	}
 	err = stack.Validate()
 	if err != nil {
@@ -249,33 +263,51 @@ func (s *Stack) Sortable() *SortableStack {
 	}
 }
 
-func validateWatchPaths(rootdir string, stackpath string, paths []string) (project.Paths, error) {
-	var projectPaths project.Paths
+// validateWatchPaths validates the stack.watch entries and returns them as
+// project paths, split into the watched and the negated (excluded) ones.
+func validateWatchPaths(
+	rootdir string, stackpath string, paths []string,
+) (watch project.Paths, exclude project.Paths, err error) {
 	for _, pathstr := range paths {
+		pattern := pathstr
+		negated := strings.HasPrefix(pattern, "!")
+		if negated {
+			pattern = pattern[1:]
+		}
 		var abspath string
-		if path.IsAbs(pathstr) {
-			abspath = filepath.Join(rootdir, filepath.FromSlash(pathstr))
+		if path.IsAbs(pattern) {
+			abspath = filepath.Join(rootdir, filepath.FromSlash(pattern))
 		} else {
-			abspath = filepath.Join(stackpath, filepath.FromSlash(pathstr))
+			abspath = filepath.Join(stackpath, filepath.FromSlash(pattern))
 		}
 		if !strings.HasPrefix(abspath, rootdir) {
-			return nil, errors.E("path %s is outside project root", pathstr)
+			return nil, nil, errors.E("path %s is outside project root", pathstr)
 		}
-		st, err := os.Stat(abspath)
-		if err == nil {
-			if st.IsDir() {
-				return nil, errors.E("stack.watch must be a list of regular files "+
-					"but directory %q was provided", pathstr)
+		prjpath := project.PrjAbsPath(rootdir, abspath)
+		if isGlobPattern(pattern) {
+			for _, elem := range strings.Split(prjpath.String(), "/") {
+				if _, err := path.Match(elem, ""); err != nil {
+					return nil, nil, errors.E(err, "invalid glob pattern %q", pathstr)
+				}
 			}
-
-			if !st.Mode().IsRegular() {
-				return nil, errors.E("stack.watch must be a list of regular files "+
-					"but file %q has mode %s", pathstr, st.Mode())
+		} else {
+			st, err := os.Stat(abspath)
+			if err == nil && !st.IsDir() && !st.Mode().IsRegular() {
+				return nil, nil, errors.E("stack.watch must be a list of regular files, "+
+					"directories or glob patterns but file %q has mode %s", pathstr, st.Mode())
 			}
 		}
-		projectPaths = append(projectPaths, project.PrjAbsPath(rootdir, abspath))
+		if negated {
+			exclude = append(exclude, prjpath)
+		} else {
+			watch = append(watch, prjpath)
+		}
 	}
-	return projectPaths, nil
+	return watch, exclude, nil
+}
+
+func isGlobPattern(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
 }
 
 // StacksFromTrees converts a List[*Tree] into a List[*Stack].
@@ -0,0 +1,81 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"path"
+	"strings"
+
+	"github.com/terramate-io/terramate/config/filter"
+	"github.com/terramate-io/terramate/hcl"
+)
+
+// StackFilterMatches tells if the stack matches any of the given stack
+// filters. A stack always matches an empty list of filters.
+func StackFilterMatches(root *Root, st *Stack, filters []hcl.StackFilterConfig) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, stackFilter := range filters {
+		if stackFilterMatches(root, st, stackFilter) {
+			return true
+		}
+	}
+	return false
+}
+
+func stackFilterMatches(root *Root, st *Stack, stackFilter hcl.StackFilterConfig) bool {
+	if len(stackFilter.ProjectPaths) > 0 &&
+		!matchAnyGlob(stackFilter.ProjectPaths, st.Dir.String()) {
+		return false
+	}
+	if len(stackFilter.RepositoryPaths) > 0 &&
+		!matchAnyGlob(stackFilter.RepositoryPaths, root.RepositoryPath(st.Dir)) {
+		return false
+	}
+	if !stackFilter.Tags.IsEmpty() && !filter.MatchTags(stackFilter.Tags, st.Tags) {
+		return false
+	}
+	return true
+}
+
+func matchAnyGlob(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches the absolute path name against the absolute glob pattern.
+// Each path element is matched with [path.Match] and the "**" element matches
+// any number of path elements, including none.
+func matchGlob(pattern, name string) bool {
+	return matchGlobElems(
+		strings.Split(strings.TrimPrefix(pattern, "/"), "/"),
+		strings.Split(strings.TrimPrefix(name, "/"), "/"),
+	)
+}
+
+func matchGlobElems(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchGlobElems(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
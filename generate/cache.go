@@ -0,0 +1,544 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/event"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/ast"
+	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/stdlib"
+	"github.com/terramate-io/terramate/tf"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// Cache is a content-hash based cache of the evaluated generate blocks of
+// stacks. A stack is only evaluated again if any of its inputs changed:
+//
+//   - The configuration files of the stack and its parent directories,
+//     including the imported files.
+//   - The runtime metadata of the project and the stack, including the
+//     metadata and exported globals of the stacks it references through
+//     terramate.stacks.by_path.
+//   - The vendor dir and the Terramate version.
+//   - The results of the filesystem functions called during the evaluation,
+//     like tm_file and tm_fileset.
+//
+// Evaluations calling impure functions, like tm_timestamp, are never cached.
+// The tm_vendor requests made by a cached evaluation are replayed when the
+// cache is used. A nil *Cache disables caching.
+type Cache struct {
+	dir string
+
+	// readonly tells if the cache is only read, never saving new entries.
+	readonly bool
+}
+
+type (
+	cacheEntry struct {
+		// Key is the hash of the stack inputs, excluding the calls.
+		Key     string          `json:"key"`
+		Calls   []cacheCall     `json:"calls,omitempty"`
+		Vendor  []tf.Source     `json:"vendor,omitempty"`
+		Asserts []config.Assert `json:"asserts,omitempty"`
+		Files   []cacheFile     `json:"files,omitempty"`
+	}
+
+	cacheCall struct {
+		Name string `json:"name"`
+		// Args is the JSON encoded tuple of the call arguments.
+		Args json.RawMessage `json:"args"`
+		// Result is the hash of the JSON encoded call result.
+		Result string `json:"result"`
+	}
+
+	cacheFile struct {
+		Label     string          `json:"label"`
+		Context   string          `json:"context"`
+		Header    string          `json:"header"`
+		Body      string          `json:"body"`
+		Condition bool            `json:"condition"`
+		Range     hhcl.Range      `json:"range"`
+		Asserts   []config.Assert `json:"asserts,omitempty"`
+	}
+
+	cachedGenFile struct {
+		file cacheFile
+		rng  info.Range
+	}
+
+	// stackEvalFunc evaluates the generate blocks of a stack, returning the
+	// generated files and all the evaluated assertions.
+	stackEvalFunc func(vendorRequests chan<- event.VendorRequest) ([]GenFile, []config.Assert, error)
+)
+
+// NewCache creates a code generation cache kept in the given directory.
+// The directory is created when the first entry is saved.
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// readOnly returns a copy of the cache which never saves new entries.
+func (c *Cache) readOnly() *Cache {
+	if c == nil {
+		return nil
+	}
+	return &Cache{dir: c.dir, readonly: true}
+}
+
+// loadStack returns the generated files of the stack. The generate blocks are
+// evaluated with the given eval function only if the cache has no valid entry
+// for the stack. The assertions are handled in both cases.
+func (c *Cache) loadStack(
+	root *config.Root,
+	st *config.Stack,
+	vendorDir project.Path,
+	vendorRequests chan<- event.VendorRequest,
+	eval stackEvalFunc,
+) ([]GenFile, error) {
+	stackpath := st.HostDir(root)
+	if c == nil {
+		return evalStack(root, stackpath, vendorRequests, eval)
+	}
+
+	logger := log.With().
+		Str("action", "generate.Cache.loadStack()").
+		Stringer("stack", st.Dir).
+		Logger()
+
+	key, err := cacheKey(root, st, vendorDir)
+	if err != nil {
+		logger.Debug().Err(err).Msg("unable to compute cache key")
+		return evalStack(root, stackpath, vendorRequests, eval)
+	}
+
+	if entry, ok := c.lookup(logger, stackpath, st.Dir, key); ok {
+		logger.Debug().Msg("using cached generated files")
+
+		if vendorRequests != nil {
+			for _, source := range entry.Vendor {
+				vendorRequests <- event.VendorRequest{
+					Source:    source,
+					VendorDir: vendorDir,
+				}
+			}
+		}
+		if err := handleAsserts(root.HostDir(), stackpath, entry.Asserts); err != nil {
+			return nil, err
+		}
+		generated := make([]GenFile, len(entry.Files))
+		for i, file := range entry.Files {
+			generated[i] = cachedGenFile{
+				file: file,
+				rng:  info.NewRange(root.HostDir(), file.Range),
+			}
+		}
+		return generated, nil
+	}
+
+	var (
+		mu     sync.Mutex
+		calls  []stdlib.Call
+		impure bool
+	)
+	unregister := stdlib.ObserveCalls(stackpath, func(call stdlib.Call) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, call)
+		impure = impure || call.Impure
+	})
+
+	var sources []tf.Source
+	stream := make(chan event.VendorRequest)
+	streamDone := make(chan struct{})
+	go func() {
+		defer close(streamDone)
+		for req := range stream {
+			sources = append(sources, req.Source)
+			if vendorRequests != nil {
+				vendorRequests <- req
+			}
+		}
+	}()
+
+	generated, asserts, err := eval(stream)
+
+	close(stream)
+	<-streamDone
+	unregister()
+
+	if err != nil {
+		return nil, err
+	}
+	if err := handleAsserts(root.HostDir(), stackpath, asserts); err != nil {
+		return nil, err
+	}
+
+	if impure {
+		logger.Debug().Msg("not caching evaluation calling impure functions")
+		return generated, nil
+	}
+	if c.readonly {
+		return generated, nil
+	}
+
+	entry := cacheEntry{
+		Key:     key,
+		Vendor:  sources,
+		Asserts: asserts,
+	}
+	for _, call := range calls {
+		cached, err := newCacheCall(call)
+		if err != nil {
+			logger.Debug().Err(err).Msg("not caching evaluation with unsupported call")
+			return generated, nil
+		}
+		entry.Calls = append(entry.Calls, cached)
+	}
+	for _, file := range generated {
+		entry.Files = append(entry.Files, newCacheFile(file))
+	}
+
+	if err := c.save(st.Dir, entry); err != nil {
+		logger.Debug().Err(err).Msg("unable to save cache entry")
+	}
+	return generated, nil
+}
+
+// evalStack evaluates the stack without caching.
+func evalStack(
+	root *config.Root,
+	stackpath string,
+	vendorRequests chan<- event.VendorRequest,
+	eval stackEvalFunc,
+) ([]GenFile, error) {
+	generated, asserts, err := eval(vendorRequests)
+	if err != nil {
+		return nil, err
+	}
+	if err := handleAsserts(root.HostDir(), stackpath, asserts); err != nil {
+		return nil, err
+	}
+	return generated, nil
+}
+
+// lookup returns the cache entry of the stack if it matches the key and the
+// filesystem calls made by the cached evaluation still have the same results.
+func (c *Cache) lookup(
+	logger zerolog.Logger,
+	stackpath string,
+	stackdir project.Path,
+	key string,
+) (cacheEntry, bool) {
+	data, err := os.ReadFile(c.entryPath(stackdir))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Debug().Err(err).Msg("unable to read cache entry")
+		}
+		return cacheEntry{}, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		logger.Debug().Err(err).Msg("ignoring invalid cache entry")
+		return cacheEntry{}, false
+	}
+
+	if entry.Key != key {
+		logger.Debug().Msg("cache entry is outdated")
+		return cacheEntry{}, false
+	}
+
+	for _, cached := range entry.Calls {
+		var args ctyjson.SimpleJSONValue
+		if err := json.Unmarshal(cached.Args, &args); err != nil {
+			logger.Debug().Err(err).Msg("ignoring invalid cache entry")
+			return cacheEntry{}, false
+		}
+
+		result, err := stdlib.Recall(stackpath, stdlib.Call{
+			Name: cached.Name,
+			Args: args.AsValueSlice(),
+		})
+		if err != nil {
+			logger.Debug().Err(err).
+				Str("function", cached.Name).
+				Msg("cache entry is outdated")
+			return cacheEntry{}, false
+		}
+
+		resultHash, err := ctyHash(result)
+		if err != nil || resultHash != cached.Result {
+			logger.Debug().
+				Str("function", cached.Name).
+				Msg("cache entry is outdated")
+			return cacheEntry{}, false
+		}
+	}
+	return entry, true
+}
+
+// save atomically writes the cache entry of the stack.
+func (c *Cache) save(stackdir project.Path, entry cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return errors.E(err, "encoding cache entry")
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return errors.E(err, "creating cache dir")
+	}
+	tmp, err := os.CreateTemp(c.dir, ".entry-*")
+	if err != nil {
+		return errors.E(err, "creating cache entry")
+	}
+	_, err = tmp.Write(data)
+	errs := errors.L(err, tmp.Close())
+	if err := errs.AsError(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return errors.E(err, "writing cache entry")
+	}
+	if err := os.Rename(tmp.Name(), c.entryPath(stackdir)); err != nil {
+		_ = os.Remove(tmp.Name())
+		return errors.E(err, "saving cache entry")
+	}
+	return nil
+}
+
+// entryPath returns the path of the cache entry of the stack. There's a
+// single entry per stack, so outdated entries are replaced.
+func (c *Cache) entryPath(stackdir project.Path) string {
+	sum := sha256.Sum256([]byte(stackdir.String()))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// cacheKey computes the hash of the inputs of the evaluation of the stack,
+// except for the filesystem calls which are only known after the evaluation.
+func cacheKey(root *config.Root, st *config.Stack, vendorDir project.Path) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "version=%s\n", terramate.Version())
+	fmt.Fprintf(h, "vendordir=%s\n", vendorDir)
+	fmt.Fprintf(h, "stack=%s\n", st.Dir)
+
+	var traversals []hhcl.Traversal
+	dir := st.Dir
+	for {
+		if cfg, ok := root.Lookup(dir); ok {
+			traversals = append(traversals, configVariables(cfg.Node)...)
+			for _, file := range cfg.Node.Files() {
+				fmt.Fprintf(h, "file=%s\n", file)
+				if err := hashFile(h, file); err != nil {
+					return "", err
+				}
+			}
+		}
+		if p := dir.Dir(); p != dir {
+			dir = p
+		} else {
+			break
+		}
+	}
+
+	// only the other stacks referenced by the stack are part of the key, so
+	// changes to unrelated stacks don't invalidate the entry.
+	runtime, err := referencedRuntime(root, st, traversals)
+	if err != nil {
+		return "", err
+	}
+	metadata := cty.ObjectVal(runtime)
+	data, err := ctyjson.Marshal(metadata, metadata.Type())
+	if err != nil {
+		return "", errors.E(err, "encoding runtime metadata")
+	}
+	fmt.Fprintf(h, "runtime=%s\n", data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// referencedRuntime returns the runtime of the stack with only the entries of
+// terramate.stacks.by_path referenced by the stack globals and the given
+// traversals. The whole runtime is returned if any stack may be referenced.
+func referencedRuntime(root *config.Root, st *config.Stack, traversals []hhcl.Traversal) (project.Runtime, error) {
+	refs, ok, err := globals.StackRefs(root, st, traversals)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return globals.StackRuntime(root, st), nil
+	}
+	byPath, err := globals.StacksByPath(root, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	runtime := root.Runtime()
+	stacksNs := runtime["stacks"].AsValueMap()
+	stacksNs["by_path"] = cty.ObjectVal(byPath)
+	runtime["stacks"] = cty.ObjectVal(stacksNs)
+	runtime.Merge(st.RuntimeValues(root))
+	return runtime, nil
+}
+
+// configVariables returns the traversals of the expressions of the asserts and
+// generate blocks of the config.
+func configVariables(cfg hcl.Config) []hhcl.Traversal {
+	traversals := assertsVariables(cfg.Asserts)
+	for _, block := range cfg.Generate.HCLs {
+		traversals = append(traversals, genBlockVariables(block.Lets, block.Condition, block.Asserts, block.ForEach)...)
+		if block.Content != nil {
+			traversals = append(traversals, bodyVariables(block.Content.Body)...)
+		}
+	}
+	for _, block := range cfg.Generate.Files {
+		traversals = append(traversals, genBlockVariables(block.Lets, block.Condition, block.Asserts, block.ForEach)...)
+		if block.Content != nil {
+			traversals = append(traversals, block.Content.Expr.Variables()...)
+		}
+	}
+	for _, block := range cfg.Generate.Data {
+		traversals = append(traversals, genBlockVariables(block.Lets, block.Condition, block.Asserts, block.ForEach)...)
+		if block.Content != nil {
+			traversals = append(traversals, block.Content.Expr.Variables()...)
+		}
+	}
+	return traversals
+}
+
+func genBlockVariables(
+	lets *ast.MergedBlock,
+	condition *hclsyntax.Attribute,
+	asserts []hcl.AssertConfig,
+	forEach *hcl.ForEachConfig,
+) []hhcl.Traversal {
+	traversals := assertsVariables(asserts)
+	if lets != nil {
+		for _, block := range lets.RawOrigins {
+			traversals = append(traversals, bodyVariables(block.Body)...)
+		}
+	}
+	if condition != nil {
+		traversals = append(traversals, condition.Expr.Variables()...)
+	}
+	if forEach != nil {
+		traversals = append(traversals, forEach.Expr.Variables()...)
+		if forEach.Label != nil {
+			traversals = append(traversals, forEach.Label.Variables()...)
+		}
+	}
+	return traversals
+}
+
+func assertsVariables(asserts []hcl.AssertConfig) []hhcl.Traversal {
+	var traversals []hhcl.Traversal
+	for _, assert := range asserts {
+		for _, expr := range []hhcl.Expression{assert.Assertion, assert.Message, assert.Warning} {
+			if expr != nil {
+				traversals = append(traversals, expr.Variables()...)
+			}
+		}
+	}
+	return traversals
+}
+
+func bodyVariables(body *hclsyntax.Body) []hhcl.Traversal {
+	var traversals []hhcl.Traversal
+	for _, attr := range body.Attributes {
+		traversals = append(traversals, attr.Expr.Variables()...)
+	}
+	for _, block := range body.Blocks {
+		traversals = append(traversals, bodyVariables(block.Body)...)
+	}
+	return traversals
+}
+
+func hashFile(h hash.Hash, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.E(err, "hashing config file")
+	}
+	defer func() { _ = f.Close() }()
+
+	fh := sha256.New()
+	if _, err := io.Copy(fh, f); err != nil {
+		return errors.E(err, "hashing config file")
+	}
+	fmt.Fprintf(h, "%x\n", fh.Sum(nil))
+	return nil
+}
+
+func ctyHash(val cty.Value) (string, error) {
+	data, err := json.Marshal(ctyjson.SimpleJSONValue{Value: val})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func newCacheCall(call stdlib.Call) (cacheCall, error) {
+	args, err := json.Marshal(ctyjson.SimpleJSONValue{Value: cty.TupleVal(call.Args)})
+	if err != nil {
+		return cacheCall{}, errors.E(err, "encoding %s arguments", call.Name)
+	}
+	result, err := ctyHash(call.Result)
+	if err != nil {
+		return cacheCall{}, errors.E(err, "encoding %s result", call.Name)
+	}
+	return cacheCall{
+		Name:   call.Name,
+		Args:   args,
+		Result: result,
+	}, nil
+}
+
+func newCacheFile(file GenFile) cacheFile {
+	rng := file.Range()
+	return cacheFile{
+		Label:     file.Label(),
+		Context:   file.Context(),
+		Header:    file.Header(),
+		Body:      file.Body(),
+		Condition: file.Condition(),
+		Range: hhcl.Range{
+			Filename: rng.HostPath(),
+			Start: hhcl.Pos{
+				Line:   rng.Start().Line(),
+				Column: rng.Start().Column(),
+				Byte:   rng.Start().Byte(),
+			},
+			End: hhcl.Pos{
+				Line:   rng.End().Line(),
+				Column: rng.End().Column(),
+				Byte:   rng.End().Byte(),
+			},
+		},
+		Asserts: file.Asserts(),
+	}
+}
+
+func (f cachedGenFile) Header() string           { return f.file.Header }
+func (f cachedGenFile) Body() string             { return f.file.Body }
+func (f cachedGenFile) Label() string            { return f.file.Label }
+func (f cachedGenFile) Context() string          { return f.file.Context }
+func (f cachedGenFile) Range() info.Range        { return f.rng }
+func (f cachedGenFile) Condition() bool          { return f.file.Condition }
+func (f cachedGenFile) Asserts() []config.Assert { return f.file.Asserts }
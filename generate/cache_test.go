@@ -0,0 +1,313 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/event"
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/project"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGenerateCacheSkipsUnchangedStacks(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:stack"})
+	rootEntry := s.RootEntry()
+	rootEntry.CreateFile("generate.tm", Doc(
+		Globals(Str("content", "v1")),
+		GenerateFile(
+			Labels("file.txt"),
+			Expr("content", "global.content"),
+		),
+	).String())
+
+	cachedir := t.TempDir()
+	opts := generate.Options{Cache: generate.NewCache(cachedir)}
+
+	report := generate.DoWithOptions(s.Config(), project.NewPath("/modules"), nil, opts)
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/stack"),
+				Created: []string{"file.txt"},
+			},
+		},
+	})
+
+	// tampering the cached body makes the use of the cache observable.
+	replaceInCacheEntries(t, cachedir, `"body":"v1"`, `"body":"cached"`)
+
+	stack := s.StackEntry("stack")
+	stack.RemoveFile("file.txt")
+
+	report = generate.DoWithOptions(s.Config(), project.NewPath("/modules"), nil, opts)
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/stack"),
+				Created: []string{"file.txt"},
+			},
+		},
+	})
+	assert.EqualStrings(t, "cached", stack.ReadFile("file.txt"))
+
+	rootEntry.CreateFile("generate.tm", Doc(
+		Globals(Str("content", "v2")),
+		GenerateFile(
+			Labels("file.txt"),
+			Expr("content", "global.content"),
+		),
+	).String())
+
+	report = generate.DoWithOptions(s.ReloadConfig(), project.NewPath("/modules"), nil, opts)
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/stack"),
+				Changed: []string{"file.txt"},
+			},
+		},
+	})
+	assert.EqualStrings(t, "v2", stack.ReadFile("file.txt"))
+}
+
+func TestGenerateCacheIsReadOnlyInDryRun(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:stack"})
+	s.RootEntry().CreateFile("generate.tm", GenerateFile(
+		Labels("file.txt"),
+		Str("content", "v1"),
+	).String())
+
+	cachedir := filepath.Join(t.TempDir(), "cache")
+	cache := generate.NewCache(cachedir)
+
+	report := generate.DoWithOptions(s.Config(), project.NewPath("/modules"), nil,
+		generate.Options{DryRun: true, Cache: cache})
+	assert.IsTrue(t, report.HasChanges(), "dry run must report the file creation")
+	_, err := os.Stat(cachedir)
+	assert.IsTrue(t, os.IsNotExist(err), "dry run must not save cache entries")
+
+	generate.DoWithOptions(s.Config(), project.NewPath("/modules"), nil, generate.Options{Cache: cache})
+	stack := s.StackEntry("stack")
+	assert.EqualStrings(t, "v1", stack.ReadFile("file.txt"))
+
+	// tampering the cached body makes the use of the cache observable.
+	replaceInCacheEntries(t, cachedir, `"body":"v1"`, `"body":"cached"`)
+
+	report = generate.DoWithOptions(s.Config(), project.NewPath("/modules"), nil,
+		generate.Options{DryRun: true, Cache: cache})
+	assert.IsTrue(t, report.HasChanges(), "dry run must use the cached entry")
+	assert.EqualStrings(t, "v1", stack.ReadFile("file.txt"))
+}
+
+func TestGenerateCacheIsInvalidatedByImportedFiles(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:stack"})
+	imported := s.RootEntry().CreateDir("imported")
+	imported.CreateFile("globals.tm", Globals(Str("content", "v1")).String())
+	s.RootEntry().CreateFile("generate.tm", Doc(
+		Import(Str("source", "/imported/globals.tm")),
+		GenerateFile(
+			Labels("file.txt"),
+			Expr("content", "global.content"),
+		),
+	).String())
+
+	opts := generate.Options{Cache: generate.NewCache(t.TempDir())}
+
+	generate.DoWithOptions(s.Config(), project.NewPath("/modules"), nil, opts)
+	stack := s.StackEntry("stack")
+	assert.EqualStrings(t, "v1", stack.ReadFile("file.txt"))
+
+	imported.CreateFile("globals.tm", Globals(Str("content", "v2")).String())
+
+	report := generate.DoWithOptions(s.ReloadConfig(), project.NewPath("/modules"), nil, opts)
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/stack"),
+				Changed: []string{"file.txt"},
+			},
+		},
+	})
+	assert.EqualStrings(t, "v2", stack.ReadFile("file.txt"))
+}
+
+func TestGenerateCacheIsInvalidatedByReferencedStacksOnly(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:app",
+		"s:net/vpc",
+		"s:other",
+	})
+	s.RootEntry().CreateFile("generate.tm", Doc(
+		Terramate(
+			Config(
+				Block("stacks",
+					Expr("exported_globals", `["name"]`),
+				),
+			),
+		),
+		GenerateFile(
+			Labels("file.txt"),
+			Expr("content", `terramate.stacks.by_path["/net/vpc"].globals.name`),
+		),
+	).String())
+	vpc := s.StackEntry("net/vpc")
+	vpc.CreateFile("globals.tm", Globals(Str("name", "vpc-v1")).String())
+	other := s.StackEntry("other")
+	other.CreateFile("globals.tm", Globals(Str("name", "other-v1")).String())
+
+	cachedir := t.TempDir()
+	opts := generate.Options{Cache: generate.NewCache(cachedir)}
+
+	generate.DoWithOptions(s.Config(), project.NewPath("/modules"), nil, opts)
+
+	// tampering the cached body makes the use of the cache observable.
+	replaceInCacheEntries(t, cachedir, `"body":"vpc-v1"`, `"body":"cached"`)
+
+	other.CreateFile("globals.tm", Globals(Str("name", "other-v2")).String())
+
+	report := generate.DoWithOptions(s.ReloadConfig(), project.NewPath("/modules"), nil, opts)
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/app"),
+				Changed: []string{"file.txt"},
+			},
+			{
+				Dir:     project.NewPath("/net/vpc"),
+				Changed: []string{"file.txt"},
+			},
+		},
+	})
+	assert.EqualStrings(t, "cached", s.StackEntry("app").ReadFile("file.txt"))
+	assert.EqualStrings(t, "vpc-v1", other.ReadFile("file.txt"))
+
+	vpc.CreateFile("globals.tm", Globals(Str("name", "vpc-v2")).String())
+
+	generate.DoWithOptions(s.ReloadConfig(), project.NewPath("/modules"), nil, opts)
+	assert.EqualStrings(t, "vpc-v2", s.StackEntry("app").ReadFile("file.txt"))
+	assert.EqualStrings(t, "vpc-v2", other.ReadFile("file.txt"))
+}
+
+func TestGenerateCacheIsInvalidatedByReadFiles(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:stack"})
+	stack := s.StackEntry("stack")
+	stack.CreateFile("data.txt", "v1")
+	s.RootEntry().CreateFile("generate.tm", GenerateFile(
+		Labels("file.txt"),
+		Expr("content", `tm_file("data.txt")`),
+	).String())
+
+	opts := generate.Options{Cache: generate.NewCache(t.TempDir())}
+
+	generate.DoWithOptions(s.Config(), project.NewPath("/modules"), nil, opts)
+	assert.EqualStrings(t, "v1", stack.ReadFile("file.txt"))
+
+	stack.CreateFile("data.txt", "v2")
+
+	report := generate.DoWithOptions(s.Config(), project.NewPath("/modules"), nil, opts)
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/stack"),
+				Changed: []string{"file.txt"},
+			},
+		},
+	})
+	assert.EqualStrings(t, "v2", stack.ReadFile("file.txt"))
+}
+
+func TestGenerateCacheIgnoresImpureFunctions(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:stack"})
+	s.RootEntry().CreateFile("generate.tm", GenerateFile(
+		Labels("file.txt"),
+		Expr("content", `tm_timestamp()`),
+	).String())
+
+	cachedir := t.TempDir()
+	opts := generate.Options{Cache: generate.NewCache(cachedir)}
+
+	report := generate.DoWithOptions(s.Config(), project.NewPath("/modules"), nil, opts)
+	assert.IsTrue(t, !report.HasFailures(), "unexpected failures: %s", report.Full())
+
+	entries, err := os.ReadDir(cachedir)
+	assert.NoError(t, err)
+	assert.EqualInts(t, 0, len(entries), "want no cache entries")
+}
+
+func TestGenerateCacheReplaysVendorRequests(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:stack"})
+	s.RootEntry().CreateFile("generate.tm", GenerateFile(
+		Labels("file.txt"),
+		Expr("content", `tm_vendor("github.com/terramate-io/terramate?ref=v1")`),
+	).String())
+
+	opts := generate.Options{Cache: generate.NewCache(t.TempDir())}
+
+	generateWithVendor := func() []string {
+		events := make(chan event.VendorRequest)
+		done := make(chan []string)
+		go func() {
+			var sources []string
+			for ev := range events {
+				sources = append(sources, ev.Source.Raw)
+			}
+			done <- sources
+		}()
+		report := generate.DoWithOptions(s.Config(), project.NewPath("/modules"), events, opts)
+		close(events)
+		assert.IsTrue(t, !report.HasFailures(), "unexpected failures: %s", report.Full())
+		return <-done
+	}
+
+	want := []string{"github.com/terramate-io/terramate?ref=v1"}
+	assertEqualStringList(t, generateWithVendor(), want)
+	// the second generation uses the cache.
+	assertEqualStringList(t, generateWithVendor(), want)
+}
+
+func replaceInCacheEntries(t *testing.T, cachedir, oldstr, newstr string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(cachedir)
+	assert.NoError(t, err)
+	assert.IsTrue(t, len(entries) > 0, "want cache entries")
+
+	for _, entry := range entries {
+		path := filepath.Join(cachedir, entry.Name())
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.IsTrue(t, strings.Contains(string(data), oldstr),
+			"cache entry %s doesn't contain %s", path, oldstr)
+		data = []byte(strings.ReplaceAll(string(data), oldstr, newstr))
+		assert.NoError(t, os.WriteFile(path, data, 0644))
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+// Package diff implements line based unified diffs of generated files.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DevNull is the name used for the missing side of created and deleted files.
+const DevNull = "/dev/null"
+
+// contextLines is the number of unchanged lines around each change.
+const contextLines = 3
+
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+type op struct {
+	kind opKind
+	line string
+
+	// oldLine and newLine are the number of old and new lines before this
+	// operation.
+	oldLine int
+	newLine int
+}
+
+// Unified returns the unified diff between the old and new texts, using the
+// given names in the header. It returns an empty string if the texts are equal.
+func Unified(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+
+	ops := diffLines(splitLines(oldText), splitLines(newText))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n", oldName)
+	fmt.Fprintf(&b, "+++ %s\n", newName)
+
+	i := 0
+	for i < len(ops) {
+		if ops[i].kind == opEqual {
+			i++
+			continue
+		}
+
+		start := i - contextLines
+		if start < 0 {
+			start = 0
+		}
+
+		// extend the hunk while the next change is close enough for their
+		// context lines to overlap.
+		end := i
+		for {
+			for end < len(ops) && ops[end].kind != opEqual {
+				end++
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == opEqual && next-end < 2*contextLines {
+				next++
+			}
+			if next < len(ops) && ops[next].kind != opEqual {
+				end = next
+				continue
+			}
+			break
+		}
+
+		stop := end + contextLines
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+
+		writeHunk(&b, ops[start:stop])
+		i = stop
+	}
+	return b.String()
+}
+
+func writeHunk(b *strings.Builder, ops []op) {
+	oldCount, newCount := 0, 0
+	for _, o := range ops {
+		if o.kind != opInsert {
+			oldCount++
+		}
+		if o.kind != opDelete {
+			newCount++
+		}
+	}
+
+	oldStart := ops[0].oldLine
+	if oldCount > 0 {
+		oldStart++
+	}
+	newStart := ops[0].newLine
+	if newCount > 0 {
+		newStart++
+	}
+
+	fmt.Fprintf(b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+	for _, o := range ops {
+		b.WriteByte(byte(o.kind))
+		b.WriteString(o.line)
+		if !strings.HasSuffix(o.line, "\n") {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// diffLines computes the operations transforming the old lines into the new
+// lines using their longest common subsequence.
+func diffLines(oldLines, newLines []string) []op {
+	n, m := len(oldLines), len(newLines)
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// oldLines[i:] and newLines[j:].
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]op, 0, n+m)
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && oldLines[i] == newLines[j]:
+			ops = append(ops, op{kind: opEqual, line: oldLines[i], oldLine: i, newLine: j})
+			i++
+			j++
+		case j < m && (i == n || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, op{kind: opInsert, line: newLines[j], oldLine: i, newLine: j})
+			j++
+		default:
+			ops = append(ops, op{kind: opDelete, line: oldLines[i], oldLine: i, newLine: j})
+			i++
+		}
+	}
+	return ops
+}
+
+// splitLines splits the text into lines, keeping the line terminators.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
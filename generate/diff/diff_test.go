@@ -0,0 +1,110 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/generate/diff"
+)
+
+func TestUnified(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name    string
+		oldName string
+		newName string
+		old     string
+		new     string
+		want    string
+	}
+
+	for _, tc := range []testcase{
+		{
+			name:    "equal texts",
+			oldName: "a/file",
+			newName: "b/file",
+			old:     "a\nb\n",
+			new:     "a\nb\n",
+			want:    "",
+		},
+		{
+			name:    "created file",
+			oldName: diff.DevNull,
+			newName: "b/file",
+			new:     "a\nb\n",
+			want: "--- /dev/null\n" +
+				"+++ b/file\n" +
+				"@@ -0,0 +1,2 @@\n" +
+				"+a\n" +
+				"+b\n",
+		},
+		{
+			name:    "deleted file",
+			oldName: "a/file",
+			newName: diff.DevNull,
+			old:     "a\nb\n",
+			want: "--- a/file\n" +
+				"+++ /dev/null\n" +
+				"@@ -1,2 +0,0 @@\n" +
+				"-a\n" +
+				"-b\n",
+		},
+		{
+			name:    "changes with overlapping context",
+			oldName: "a/file",
+			newName: "b/file",
+			old:     "1\n2\n3\n4\n5\n6\n7\n8\n",
+			new:     "1\n2\n3\nX\n5\n6\n7\n8\nY\n",
+			want: "--- a/file\n" +
+				"+++ b/file\n" +
+				"@@ -1,8 +1,9 @@\n" +
+				" 1\n" +
+				" 2\n" +
+				" 3\n" +
+				"-4\n" +
+				"+X\n" +
+				" 5\n" +
+				" 6\n" +
+				" 7\n" +
+				" 8\n" +
+				"+Y\n",
+		},
+		{
+			name:    "distant changes in multiple hunks",
+			oldName: "a/file",
+			newName: "b/file",
+			old:     "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n",
+			new:     "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nL\nm\nn",
+			want: "--- a/file\n" +
+				"+++ b/file\n" +
+				"@@ -1,5 +1,5 @@\n" +
+				" a\n" +
+				"-b\n" +
+				"+B\n" +
+				" c\n" +
+				" d\n" +
+				" e\n" +
+				"@@ -9,5 +9,6 @@\n" +
+				" i\n" +
+				" j\n" +
+				" k\n" +
+				"-l\n" +
+				"+L\n" +
+				" m\n" +
+				"+n\n" +
+				"\\ No newline at end of file\n",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := diff.Unified(tc.oldName, tc.newName, tc.old, tc.new)
+			assert.EqualStrings(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,307 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+// Package gendata implements generate_json and generate_yaml code generation.
+package gendata
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/event"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/terramate-io/terramate/lets"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/stack"
+	"github.com/terramate-io/terramate/stdlib"
+	ctyyaml "github.com/zclconf/go-cty-yaml"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+const (
+	// ErrInvalidContentType indicates the content attribute
+	// has an invalid type.
+	ErrInvalidContentType errors.Kind = "invalid content type"
+
+	// ErrInvalidConditionType indicates the condition attribute
+	// has an invalid type.
+	ErrInvalidConditionType errors.Kind = "invalid condition type"
+
+	// ErrContentEval indicates an error when evaluating the content attribute.
+	ErrContentEval errors.Kind = "evaluating content"
+
+	// ErrConditionEval indicates an error when evaluating the condition attribute.
+	ErrConditionEval errors.Kind = "evaluating condition"
+
+	// ErrContentEncoding indicates an error when encoding the content attribute.
+	ErrContentEncoding errors.Kind = "encoding content"
+)
+
+const (
+	// JSON is the format of the generate_json blocks.
+	JSON = "json"
+
+	// YAML is the format of the generate_yaml blocks.
+	YAML = "yaml"
+)
+
+// YAMLHeader is the header string used by generate_yaml code generation.
+// JSON doesn't support comments, so generate_json files have no header.
+const YAMLHeader = "# TERRAMATE: GENERATED AUTOMATICALLY DO NOT EDIT"
+
+// File represents a generated file from a single generate_json or
+// generate_yaml block.
+type File struct {
+	label     string
+	format    string
+	origin    info.Range
+	body      string
+	condition bool
+	asserts   []config.Assert
+}
+
+// Label of the original block.
+func (f File) Label() string {
+	return f.label
+}
+
+// Format of the generated file, either [JSON] or [YAML].
+func (f File) Format() string {
+	return f.format
+}
+
+// Body returns the file body.
+func (f File) Body() string {
+	return f.body
+}
+
+// Range returns the range information of the block.
+func (f File) Range() info.Range {
+	return f.origin
+}
+
+// Condition returns the result of the evaluation of the
+// condition attribute for the generated code.
+func (f File) Condition() bool {
+	return f.condition
+}
+
+// Context of the block.
+func (f File) Context() string {
+	return "stack"
+}
+
+// Asserts returns all (if any) of the evaluated assert configs of the
+// block. If [File.Condition] returns false then assert configs will always be
+// empty since they are not evaluated at all in that case.
+func (f File) Asserts() []config.Assert {
+	return f.asserts
+}
+
+// Header returns the header of the file, if the format supports comments.
+func (f File) Header() string {
+	if f.format == YAML {
+		return YAMLHeader + "\n\n"
+	}
+	return ""
+}
+
+func (f File) String() string {
+	return fmt.Sprintf("generate_%s %q (condition %t) (body %q) (origin %q)",
+		f.Format(), f.Label(), f.Condition(), f.Body(), f.Range().Path())
+}
+
+// Load loads and evaluates all generate_json and generate_yaml blocks for
+// a given stack. It will navigate the file system from the stack dir until
+// it reaches rootdir, loading the blocks found on Terramate configuration
+// files.
+//
+// Metadata and globals for the stack are used on the evaluation of the
+// blocks.
+func Load(
+	root *config.Root,
+	st *config.Stack,
+	globals *eval.Object,
+	vendorDir project.Path,
+	vendorRequests chan<- event.VendorRequest,
+) ([]File, error) {
+	var files []File
+
+	for _, block := range loadGenDataBlocks(root, st.Dir) {
+		if !config.StackFilterMatches(root, st, block.StackFilters) {
+			// files of filtered out stacks are removed like on a false
+			// condition.
+			if block.ForEach == nil {
+				files = append(files, File{
+					label:     block.Label,
+					format:    block.Format,
+					origin:    block.Range,
+					condition: false,
+				})
+			}
+			continue
+		}
+
+		evalctx := stack.NewEvalCtx(root, st, globals)
+		err := config.EvalForEach(evalctx.Context, block.Label, block.ForEach,
+			func(name string, evalctx *eval.Context) error {
+				vendorTargetDir := project.NewPath(path.Join(
+					st.Dir.String(),
+					path.Dir(name)))
+
+				evalctx.SetFunction(stdlib.Name("vendor"), stdlib.VendorFunc(vendorTargetDir, vendorDir, vendorRequests))
+
+				genBlock := block
+				genBlock.Label = name
+				file, err := Eval(genBlock, evalctx)
+				if err != nil {
+					return err
+				}
+				files = append(files, file)
+				return nil
+			})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].String() < files[j].String()
+	})
+
+	return files, nil
+}
+
+// Eval the generate_json or generate_yaml block. If the block has a for_each
+// attribute then the caller must use [config.EvalForEach] to set the label and
+// evaluation context of each generated file.
+func Eval(block hcl.GenDataBlock, evalctx *eval.Context) (File, error) {
+	file := File{
+		label:  block.Label,
+		format: block.Format,
+		origin: block.Range,
+	}
+
+	err := lets.Load(block.Lets, evalctx)
+	if err != nil {
+		return File{}, err
+	}
+
+	file.condition = true
+	if block.Condition != nil {
+		value, err := evalctx.Eval(block.Condition.Expr)
+		if err != nil {
+			return File{}, errors.E(ErrConditionEval, err)
+		}
+		if value.Type() != cty.Bool {
+			return File{}, errors.E(
+				ErrInvalidConditionType,
+				"condition has type %s but must be boolean",
+				value.Type().FriendlyName(),
+			)
+		}
+		file.condition = value.True()
+	}
+
+	if !file.condition {
+		return file, nil
+	}
+
+	asserts := make([]config.Assert, len(block.Asserts))
+	assertsErrs := errors.L()
+	assertFailed := false
+
+	for i, assertCfg := range block.Asserts {
+		assert, err := config.EvalAssert(evalctx, assertCfg)
+		if err != nil {
+			assertsErrs.Append(err)
+			continue
+		}
+		asserts[i] = assert
+		if !assert.Assertion && !assert.Warning {
+			assertFailed = true
+		}
+	}
+
+	if err := assertsErrs.AsError(); err != nil {
+		return File{}, err
+	}
+
+	file.asserts = asserts
+	if assertFailed {
+		return file, nil
+	}
+
+	value, err := evalctx.Eval(block.Content.Expr)
+	if err != nil {
+		return File{}, errors.E(ErrContentEval, err)
+	}
+
+	if !value.IsWhollyKnown() || value.IsNull() ||
+		!(value.Type().IsObjectType() || value.Type().IsMapType()) {
+		return File{}, errors.E(
+			ErrInvalidContentType,
+			block.Content.Expr.Range(),
+			"content has type %s but must be an object",
+			value.Type().FriendlyName(),
+		)
+	}
+
+	body, err := encode(value, block.Format)
+	if err != nil {
+		return File{}, errors.E(ErrContentEncoding, block.Content.Expr.Range(), err)
+	}
+	file.body = body
+	return file, nil
+}
+
+// encode serializes the value in the given format. The object keys are
+// always sorted, so the same value always produces the same body.
+func encode(value cty.Value, format string) (string, error) {
+	switch format {
+	case JSON:
+		data, err := ctyjson.Marshal(value, value.Type())
+		if err != nil {
+			return "", err
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, data, "", "  "); err != nil {
+			return "", err
+		}
+		out.WriteString("\n")
+		return out.String(), nil
+	case YAML:
+		data, err := ctyyaml.Standard.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	default:
+		panic(errors.E(errors.ErrInternal, "unsupported format %q", format))
+	}
+}
+
+// loadGenDataBlocks loads all generate_json and generate_yaml blocks from the
+// cfgdir up to the project root.
+func loadGenDataBlocks(root *config.Root, cfgdir project.Path) []hcl.GenDataBlock {
+	res := []hcl.GenDataBlock{}
+	cfg, ok := root.Lookup(cfgdir)
+	if ok && !cfg.IsEmptyConfig() {
+		res = append(res, cfg.Node.Generate.Data...)
+	}
+
+	parentCfgDir := cfgdir.Dir()
+	if parentCfgDir == cfgdir {
+		return res
+	}
+
+	return append(res, loadGenDataBlocks(root, parentCfgDir)...)
+}
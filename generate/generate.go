@@ -9,14 +9,18 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/terramate-io/terramate/config"
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/event"
+	"github.com/terramate-io/terramate/generate/diff"
+	"github.com/terramate-io/terramate/generate/gendata"
 	"github.com/terramate-io/terramate/generate/genfile"
 	"github.com/terramate-io/terramate/generate/genhcl"
 	"github.com/terramate-io/terramate/globals"
@@ -50,6 +54,39 @@ const (
 	ErrAssertion errors.Kind = "assertion failed"
 )
 
+// Options are the options controlling how code is generated.
+type Options struct {
+	// DryRun computes the code generation report without changing any file.
+	// The report has the unified diff of each created, changed or deleted file.
+	// The cache is only read, no new entries are saved.
+	DryRun bool
+
+	// Parallel is the maximum number of stacks generated concurrently.
+	// If zero, it defaults to the number of CPUs.
+	Parallel int
+
+	// Cache is used for skipping the evaluation of stacks whose inputs didn't
+	// change. If nil, all stacks are evaluated.
+	Cache *Cache
+}
+
+// workers returns the number of concurrent workers for stack generation.
+func (opts Options) workers() int {
+	if opts.Parallel > 0 {
+		return opts.Parallel
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// cache returns the cache used for generating code, which is read-only in a
+// dry run.
+func (opts Options) cache() *Cache {
+	if opts.DryRun {
+		return opts.Cache.readOnly()
+	}
+	return opts.Cache
+}
+
 // GenFile represents a generated file loaded from a Terramate configuration.
 type GenFile interface {
 	// Header is the header of the generated file, if any.
@@ -90,6 +127,12 @@ type LoadResult struct {
 // a non-nil error. In this case the error is not specific to generating code
 // for a specific dir.
 func Load(root *config.Root, vendorDir project.Path) ([]LoadResult, error) {
+	return LoadWithOptions(root, vendorDir, Options{})
+}
+
+// LoadWithOptions loads all the generated files like [Load] but using the
+// given options. Only the [Options.Cache] is used.
+func LoadWithOptions(root *config.Root, vendorDir project.Path, opts Options) ([]LoadResult, error) {
 	stacks, err := config.LoadAllStacks(root.Tree())
 	if err != nil {
 		return nil, err
@@ -98,14 +141,21 @@ func Load(root *config.Root, vendorDir project.Path) ([]LoadResult, error) {
 
 	for i, st := range stacks {
 		res := LoadResult{Dir: st.Dir()}
-		loadres := globals.ForStack(root, st.Stack)
-		if err := loadres.AsError(); err != nil {
-			res.Err = err
+		var globalsErr error
+		generated, err := opts.Cache.loadStack(root, st.Stack, vendorDir, nil,
+			func(vendorRequests chan<- event.VendorRequest) ([]GenFile, []config.Assert, error) {
+				loadres := globals.ForStack(root, st.Stack)
+				if err := loadres.AsError(); err != nil {
+					globalsErr = err
+					return nil, nil, err
+				}
+				return evalStackCodeCfgs(root, st.Stack, loadres.Globals, vendorDir, vendorRequests)
+			})
+		if globalsErr != nil {
+			res.Err = globalsErr
 			results[i] = res
 			continue
 		}
-
-		generated, err := loadStackCodeCfgs(root, st.Stack, loadres.Globals, vendorDir, nil)
 		if err != nil {
 			res.Err = errors.E(err, "while loading configs of stack %s", st.Dir())
 			results[i] = res
@@ -120,22 +170,27 @@ func Load(root *config.Root, vendorDir project.Path) ([]LoadResult, error) {
 			continue
 		}
 		res := LoadResult{Dir: dircfg.Dir()}
-		evalctx := eval.NewContext(stdlib.Functions(dircfg.HostDir()))
+		evalctx, err := rootEvalContext(root, dircfg.HostDir())
+		if err != nil {
+			return nil, err
+		}
 
 		var generated []GenFile
-		for _, block := range dircfg.Node.Generate.Files {
-			if block.Context != genfile.RootContext {
-				continue
-			}
-
-			file, err := genfile.Eval(block, evalctx)
+		for _, block := range rootGenBlocks(dircfg) {
+			err := config.EvalForEach(evalctx, block.label, block.forEach,
+				func(label string, evalctx *eval.Context) error {
+					file, err := block.eval(label, evalctx)
+					if err != nil {
+						return err
+					}
+					generated = append(generated, file)
+					return nil
+				})
 			if err != nil {
 				res.Err = errors.L(res.Err, err).AsError()
 				results = append(results, res)
 				continue
 			}
-
-			generated = append(generated, file)
 		}
 		if len(generated) > 0 {
 			res.Files = generated
@@ -158,10 +213,10 @@ func Load(root *config.Root, vendorDir project.Path) ([]LoadResult, error) {
 //
 // - context=root
 //
-// In this case, all of the generate_file blocks with context=root from the
-// project are loaded, checked for conflicts, evaluated using a "Root Evaluation
-// Context" and generated. A Root Evaluation Context contains just the Project
-// Metadata.
+// In this case, all of the generate_file and generate_hcl blocks with
+// context=root from the project are loaded, checked for conflicts, evaluated
+// using a "Root Evaluation Context" and generated. A Root Evaluation Context
+// contains the Project Metadata and the metadata of all stacks.
 //
 // The given vendorDir is used when calculating the vendor path using tm_vendor
 // on the generate blocks. The vendorRequests channel will be used on tm_vendor
@@ -177,19 +232,44 @@ func Do(
 	vendorDir project.Path,
 	vendorRequests chan<- event.VendorRequest,
 ) Report {
-	stackReport := forEachStack(root, vendorDir,
-		vendorRequests, doStackGeneration)
-	rootReport := doRootGeneration(root)
+	return DoWithOptions(root, vendorDir, vendorRequests, Options{})
+}
+
+// DoWithOptions generates code for the entire configuration like [Do] but
+// using the given options.
+func DoWithOptions(
+	root *config.Root,
+	vendorDir project.Path,
+	vendorRequests chan<- event.VendorRequest,
+	opts Options,
+) Report {
+	stackReport := forEachStack(root, vendorDir, vendorRequests, opts,
+		func(
+			root *config.Root,
+			stack *config.Stack,
+			generated []GenFile,
+		) dirReport {
+			return doStackGeneration(root, stack, generated, opts)
+		})
+	if stackReport.BootstrapErr != nil {
+		return stackReport
+	}
+	rootFiles, rootReport := doRootGeneration(root, opts)
 	report := mergeReports(stackReport, rootReport)
-	return cleanupOrphaned(root, report)
+	if rootReport.HasFailures() {
+		// The files owned by the context=root blocks are unknown, so the
+		// generated files outside of stacks can't be safely deleted.
+		report.sort()
+		return report
+	}
+	return cleanupOrphaned(root, report, rootFiles, opts)
 }
 
 func doStackGeneration(
 	root *config.Root,
 	stack *config.Stack,
-	globals *eval.Object,
-	vendorDir project.Path,
-	vendorRequests chan<- event.VendorRequest,
+	generated []GenFile,
+	opts Options,
 ) dirReport {
 	stackpath := stack.HostDir(root)
 	logger := log.With().
@@ -201,12 +281,6 @@ func doStackGeneration(
 
 	logger.Debug().Msg("generating files")
 
-	generated, err := loadStackCodeCfgs(root, stack, globals, vendorDir, vendorRequests)
-	if err != nil {
-		report.err = err
-		return report
-	}
-
 	errsmap := checkFileConflict(generated)
 	if len(errsmap) > 0 {
 		errs := errors.L()
@@ -217,7 +291,7 @@ func doStackGeneration(
 		return report
 	}
 
-	err = validateStackGeneratedFiles(root, stackpath, generated)
+	err := validateStackGeneratedFiles(root, stackpath, generated)
 	if err != nil {
 		report.err = err
 		return report
@@ -249,11 +323,15 @@ func doStackGeneration(
 		oldFileBody, oldExists := allFiles[filename]
 
 		if !oldExists || oldFileBody != body {
-			err := writeGeneratedCode(path, file)
+			err := saveGeneratedCode(path, file, opts)
 			if err != nil {
 				report.err = errors.E(err, "saving file %q", filename)
 				return report
 			}
+			if opts.DryRun {
+				report.addDiff(filename, fileDiff(stack.Dir.Join(filename),
+					oldFileBody, oldExists, body, true))
+			}
 		}
 
 		if !oldExists {
@@ -284,6 +362,13 @@ func doStackGeneration(
 
 		report.addDeletedFile(filename)
 
+		if opts.DryRun {
+			report.addDiff(filename, fileDiff(stack.Dir.Join(filename),
+				allFiles[filename], true, "", false))
+			delete(allFiles, filename)
+			continue
+		}
+
 		path := filepath.Join(stackpath, filename)
 		err = os.Remove(path)
 		if err != nil {
@@ -298,14 +383,49 @@ func doStackGeneration(
 	return report
 }
 
-func doRootGeneration(root *config.Root) Report {
+func doRootGeneration(root *config.Root, opts Options) ([]GenFile, Report) {
 	logger := log.With().
 		Str("action", "generate.doRootGeneration").
 		Logger()
 
 	report := Report{}
-	evalctx := eval.NewContext(stdlib.Functions(root.HostDir()))
-	evalctx.SetNamespace("terramate", root.Runtime())
+	evalctx, err := rootEvalContext(root, root.HostDir())
+	if err != nil {
+		report.BootstrapErr = err
+		return nil, report
+	}
+
+	files, failedDir, err := loadRootGenFiles(root, evalctx)
+	if err != nil {
+		report.addFailure(failedDir, err)
+		return nil, report
+	}
+
+	logger.Debug().Msg("checking context=root conflicts")
+
+	errsmap := checkFileConflict(files)
+	if len(errsmap) > 0 {
+		for file, err := range errsmap {
+			targetDir := path.Dir(file)
+			report.addFailure(project.NewPath(targetDir), err)
+		}
+		return nil, report
+	}
+
+	logger.Debug().Msg("no conflicts found")
+
+	generateRootFiles(root, files, &report, opts)
+	return files, report
+}
+
+// loadRootGenFiles loads, validates and evaluates all the generate blocks
+// with context=root of the project using the given evaluation context. In case
+// of failure, the target directory of the failed block is returned with the
+// error.
+func loadRootGenFiles(root *config.Root, evalctx *eval.Context) ([]GenFile, project.Path, error) {
+	logger := log.With().
+		Str("action", "generate.loadRootGenFiles").
+		Logger()
 
 	var files []GenFile
 	for _, cfg := range root.Tree().AsList() {
@@ -320,60 +440,110 @@ func doRootGeneration(root *config.Root) Report {
 			continue
 		}
 
-		blocks := cfg.Node.Generate.Files
-		if len(blocks) == 0 {
-			continue
-		}
-
-		for _, block := range blocks {
-			logger := genFileBlockLogger(logger, block)
-
-			if block.Context != genfile.RootContext {
-				logger.Debug().Msg("ignoring block")
-				continue
-			}
+		for _, block := range rootGenBlocks(cfg) {
+			logger := genBlockLogger(logger, block.name, block.label, genfile.RootContext)
 
 			// TODO(i4k): generate report must be redesigned for context=root
 			// Here we use path.Clean("/"+path.Dir(label)) to ensure the
 			// report.Dir is always absolute.
-			targetDir := project.NewPath(path.Clean("/" + path.Dir(block.Label)))
-			err := validateRootGenerateBlock(root, block)
-			if err != nil {
-				report.addFailure(targetDir, err)
-				return report
-			}
+			targetDir := project.NewPath(path.Clean("/" + path.Dir(block.label)))
+			err := config.EvalForEach(evalctx, block.label, block.forEach,
+				func(label string, evalctx *eval.Context) error {
+					targetDir = project.NewPath(path.Clean("/" + path.Dir(label)))
 
-			logger.Debug().Msg("block validated successfully")
+					err := validateRootGenerateBlock(root, block.name, label, block.rng)
+					if err != nil {
+						return err
+					}
 
-			file, err := genfile.Eval(block, evalctx)
-			if err != nil {
-				report.addFailure(targetDir, err)
-				return report
-			}
+					logger.Debug().Msg("block validated successfully")
+
+					file, err := block.eval(label, evalctx)
+					if err != nil {
+						return err
+					}
 
-			logger.Debug().Msg("block evaluated successfully")
+					logger.Debug().Msg("block evaluated successfully")
 
-			files = append(files, file)
+					files = append(files, file)
+					return nil
+				})
+			if err != nil {
+				return nil, targetDir, err
+			}
 		}
 	}
+	return files, project.Path{}, nil
+}
 
-	logger.Debug().Msg("checking generate_file.context=root conflicts")
+// rootGenBlock is a generate block with context=root.
+type rootGenBlock struct {
+	name    string
+	label   string
+	rng     info.Range
+	forEach *hcl.ForEachConfig
+	eval    func(label string, evalctx *eval.Context) (GenFile, error)
+}
 
-	errsmap := checkFileConflict(files)
-	if len(errsmap) > 0 {
-		if len(errsmap) > 0 {
-			for file, err := range errsmap {
-				targetDir := path.Dir(file)
-				report.addFailure(project.NewPath(targetDir), err)
-			}
-			return report
+// rootGenBlocks returns the generate_file and generate_hcl blocks with
+// context=root of the given config directory.
+func rootGenBlocks(cfg *config.Tree) []rootGenBlock {
+	var blocks []rootGenBlock
+	for _, block := range cfg.Node.Generate.Files {
+		if block.Context != genfile.RootContext {
+			continue
 		}
+		block := block
+		blocks = append(blocks, rootGenBlock{
+			name:    "generate_file",
+			label:   block.Label,
+			rng:     block.Range,
+			forEach: block.ForEach,
+			eval: func(label string, evalctx *eval.Context) (GenFile, error) {
+				genBlock := block
+				genBlock.Label = label
+				file, err := genfile.Eval(genBlock, evalctx)
+				if err != nil {
+					return nil, err
+				}
+				return file, nil
+			},
+		})
 	}
+	for _, block := range cfg.Node.Generate.HCLs {
+		if block.Context != genhcl.RootContext {
+			continue
+		}
+		block := block
+		blocks = append(blocks, rootGenBlock{
+			name:    "generate_hcl",
+			label:   block.Label,
+			rng:     block.Range,
+			forEach: block.ForEach,
+			eval: func(label string, evalctx *eval.Context) (GenFile, error) {
+				genBlock := block
+				genBlock.Label = label
+				file, err := genhcl.Eval(genBlock, evalctx)
+				if err != nil {
+					return nil, err
+				}
+				return file, nil
+			},
+		})
+	}
+	return blocks
+}
 
-	logger.Debug().Msg("no conflicts found")
-
-	generateRootFiles(root, files, &report)
-	return report
+// rootEvalContext creates the evaluation context of the context=root blocks,
+// which has the project metadata, including the metadata of all stacks.
+func rootEvalContext(root *config.Root, basedir string) (*eval.Context, error) {
+	runtime, err := globals.RootRuntime(root)
+	if err != nil {
+		return nil, err
+	}
+	evalctx := eval.NewContext(stdlib.Functions(basedir))
+	evalctx.SetNamespace("terramate", runtime)
+	return evalctx, nil
 }
 
 func handleAsserts(rootdir string, dir string, asserts []config.Assert) error {
@@ -446,6 +616,22 @@ processSubdirs:
 		}
 
 		for _, entry := range entries {
+			if entry.Name() == ManifestFilename && entry.Type().IsRegular() {
+				manifestPath := filepath.Join(absSubdir, entry.Name())
+				tracked, ok, err := readManifest(manifestPath)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					genfiles = append(genfiles, filepath.ToSlash(
+						filepath.Join(relSubdir, entry.Name())))
+					for _, file := range tracked {
+						genfiles = append(genfiles, path.Join(filepath.ToSlash(relSubdir), file))
+					}
+				}
+				continue
+			}
+
 			if config.Skip(entry.Name()) {
 				continue
 			}
@@ -489,6 +675,13 @@ processSubdirs:
 // DetectOutdated will verify if the given config has outdated code
 // and return a list of filenames that are outdated, ordered lexicographically.
 func DetectOutdated(root *config.Root, vendorDir project.Path) ([]string, error) {
+	return DetectOutdatedWithOptions(root, vendorDir, Options{})
+}
+
+// DetectOutdatedWithOptions detects the outdated code like [DetectOutdated]
+// but using the given options. Only [Options.Parallel] and [Options.Cache]
+// are used.
+func DetectOutdatedWithOptions(root *config.Root, vendorDir project.Path, opts Options) ([]string, error) {
 	logger := log.With().
 		Str("action", "generate.DetectOutdated()").
 		Logger()
@@ -503,16 +696,26 @@ func DetectOutdated(root *config.Root, vendorDir project.Path) ([]string, error)
 
 	logger.Debug().Msg("checking outdated code inside stacks")
 
-	for _, stack := range stacks {
-		outdated, err := stackOutdated(root, stack.Stack, vendorDir)
-		if err != nil {
+	type stackResult struct {
+		outdated []string
+		err      error
+	}
+
+	results := make([]stackResult, len(stacks))
+	forEachIndex(len(stacks), opts.workers(), func(i int) {
+		outdated, err := stackOutdated(root, stacks[i].Stack, vendorDir, opts.Cache)
+		results[i] = stackResult{outdated: outdated, err: err}
+	})
+
+	for i, stack := range stacks {
+		if err := results[i].err; err != nil {
 			errs.Append(err)
 			continue
 		}
 
 		// We want results relative to root
 		stackRelPath := stack.Dir().String()[1:]
-		for _, file := range outdated {
+		for _, file := range results[i].outdated {
 			outdatedFiles = append(outdatedFiles,
 				path.Join(stackRelPath, file))
 		}
@@ -535,15 +738,59 @@ func DetectOutdated(root *config.Root, vendorDir project.Path) ([]string, error)
 		errs.Append(err)
 	}
 
+	var rootFiles []GenFile
+	evalctx, err := rootEvalContext(root, root.HostDir())
+	if err == nil {
+		rootFiles, _, err = loadRootGenFiles(root, evalctx)
+	}
+	if err != nil {
+		errs.Append(err)
+	}
+
 	if err := errs.AsError(); err != nil {
 		return nil, err
 	}
 
-	outdatedFiles = append(outdatedFiles, orphanedFiles...)
+	outdatedOrphans, err := rootOutdated(root, orphanedFiles, rootFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	outdatedFiles = append(outdatedFiles, outdatedOrphans...)
 	sort.Strings(outdatedFiles)
 	return outdatedFiles, nil
 }
 
+// rootOutdated returns the generated files outside of stacks which are
+// outdated, ie. the ones which are not generated by context=root blocks or
+// whose content on disk differs from the generated one.
+func rootOutdated(root *config.Root, genfilesOnFs []string, rootFiles []GenFile) ([]string, error) {
+	generated := map[string]GenFile{}
+	for _, file := range rootFiles {
+		if file.Condition() {
+			generated[path.Clean(file.Label())] = file
+		}
+	}
+
+	outdated := []string{}
+	for _, filename := range genfilesOnFs {
+		file, ok := generated["/"+filename]
+		if !ok {
+			outdated = append(outdated, filename)
+			continue
+		}
+
+		body, err := os.ReadFile(filepath.Join(root.HostDir(), filename))
+		if err != nil {
+			return nil, errors.E(err, "checking for outdated code")
+		}
+		if string(body) != file.Header()+file.Body() {
+			outdated = append(outdated, filename)
+		}
+	}
+	return outdated, nil
+}
+
 // stackOutdated will verify if a given stack has outdated code and return a list
 // of filenames that are outdated, ordered lexicographically.
 // If the stack has an invalid configuration it will return an error.
@@ -551,19 +798,21 @@ func stackOutdated(
 	root *config.Root,
 	st *config.Stack,
 	vendorDir project.Path,
+	cache *Cache,
 ) ([]string, error) {
 	logger := log.With().
 		Str("action", "generate.stackOutdated").
 		Stringer("stack", st).
 		Logger()
 
-	report := globals.ForStack(root, st)
-	if err := report.AsError(); err != nil {
-		return nil, errors.E(err, "checking for outdated code")
-	}
-
-	globals := report.Globals
-	generated, err := loadStackCodeCfgs(root, st, globals, vendorDir, nil)
+	generated, err := cache.loadStack(root, st, vendorDir, nil,
+		func(vendorRequests chan<- event.VendorRequest) ([]GenFile, []config.Assert, error) {
+			report := globals.ForStack(root, st)
+			if err := report.AsError(); err != nil {
+				return nil, nil, errors.E(err, "checking for outdated code")
+			}
+			return evalStackCodeCfgs(root, st, report.Globals, vendorDir, vendorRequests)
+		})
 	if err != nil {
 		return nil, err
 	}
@@ -666,6 +915,32 @@ func updateOutdatedFiles(
 	return nil
 }
 
+// saveGeneratedCode writes the generated file into the target path. If
+// opts.DryRun is set then it only checks that the file can be written.
+func saveGeneratedCode(target string, genfile GenFile, opts Options) error {
+	if !opts.DryRun {
+		return writeGeneratedCode(target, genfile)
+	}
+	if genfile.Header() != "" {
+		return checkFileCanBeOverwritten(target)
+	}
+	return nil
+}
+
+// fileDiff returns the unified diff of the file at the given project path.
+// The exists flags tell if the file exists before and after the generation.
+func fileDiff(file project.Path, oldBody string, oldExists bool, newBody string, newExists bool) string {
+	oldName := "a" + file.String()
+	newName := "b" + file.String()
+	if !oldExists {
+		oldName = diff.DevNull
+	}
+	if !newExists {
+		newName = diff.DevNull
+	}
+	return diff.Unified(oldName, newName, oldBody, newBody)
+}
+
 func writeGeneratedCode(target string, genfile GenFile) error {
 	body := genfile.Header() + genfile.Body()
 
@@ -740,15 +1015,18 @@ func readFile(path string) (string, bool, error) {
 type forEachStackFunc func(
 	*config.Root,
 	*config.Stack,
-	*eval.Object,
-	project.Path,
-	chan<- event.VendorRequest,
+	[]GenFile,
 ) dirReport
 
+// forEachStack calls fn with the generated files of each stack of the project
+// using the concurrent workers defined by opts. The stack reports are added
+// into the returned report in the stacks order, independently of the order
+// they finish.
 func forEachStack(
 	root *config.Root,
 	vendorDir project.Path,
 	vendorRequests chan<- event.VendorRequest,
+	opts Options,
 	fn forEachStackFunc,
 ) Report {
 	report := Report{}
@@ -759,20 +1037,63 @@ func forEachStack(
 		return report
 	}
 
-	for _, elem := range stacks {
-		globalsReport := globals.ForStack(root, elem.Stack)
-		if err := globalsReport.AsError(); err != nil {
-			report.addFailure(elem.Dir(), errors.E(ErrLoadingGlobals, err))
-			continue
+	stackReports := make([]dirReport, len(stacks))
+	forEachIndex(len(stacks), opts.workers(), func(i int) {
+		elem := stacks[i]
+		generated, err := opts.cache().loadStack(root, elem.Stack, vendorDir, vendorRequests,
+			func(vendorRequests chan<- event.VendorRequest) ([]GenFile, []config.Assert, error) {
+				globalsReport := globals.ForStack(root, elem.Stack)
+				if err := globalsReport.AsError(); err != nil {
+					return nil, nil, errors.E(ErrLoadingGlobals, err)
+				}
+				return evalStackCodeCfgs(root, elem.Stack, globalsReport.Globals, vendorDir, vendorRequests)
+			})
+		if err != nil {
+			stackReports[i] = dirReport{err: err}
+			return
 		}
 
-		stackReport := fn(root, elem.Stack, globalsReport.Globals, vendorDir, vendorRequests)
-		report.addDirReport(elem.Dir(), stackReport)
+		stackReports[i] = fn(root, elem.Stack, generated)
+	})
+
+	for i, elem := range stacks {
+		report.addDirReport(elem.Dir(), stackReports[i])
 	}
 
 	return report
 }
 
+// forEachIndex calls fn for each index in the [0, n) range using at most the
+// given number of concurrent workers. It returns after all calls finish.
+func forEachIndex(n int, workers int, fn func(i int)) {
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
 func allStackGeneratedFiles(
 	root *config.Root,
 	dir string,
@@ -811,7 +1132,7 @@ func allStackGeneratedFiles(
 	return allFiles, nil
 }
 
-func generateRootFiles(root *config.Root, genfiles []GenFile, report *Report) {
+func generateRootFiles(root *config.Root, genfiles []GenFile, report *Report, opts Options) {
 	logger := log.With().
 		Str("action", "generate.generateRootFiles()").
 		Logger()
@@ -880,6 +1201,19 @@ func generateRootFiles(root *config.Root, genfiles []GenFile, report *Report) {
 			dirReport := dirReport{}
 			dir := path.Dir(label)
 
+			if opts.DryRun {
+				body, err := os.ReadFile(abspath)
+				if err != nil {
+					dirReport.err = errors.E(err, "reading generated file")
+				} else {
+					dirReport.addDeletedFile(path.Base(label))
+					dirReport.addDiff(path.Base(label), fileDiff(project.NewPath(label),
+						string(body), true, "", false))
+				}
+				report.addDirReport(project.NewPath(dir), dirReport)
+				continue
+			}
+
 			err := os.Remove(abspath)
 			if err != nil {
 				dirReport.err = errors.E(err, "deleting file")
@@ -911,12 +1245,16 @@ func generateRootFiles(root *config.Root, genfiles []GenFile, report *Report) {
 				Bool("fileChanged", body != diskContent).
 				Msg("writing file")
 
-			err := writeGeneratedCode(abspath, genfile)
+			err := saveGeneratedCode(abspath, genfile, opts)
 			if err != nil {
 				dirReport.err = errors.E(err, "saving file %s", label)
 				report.addDirReport(dir, dirReport)
 				continue
 			}
+			if opts.DryRun {
+				dirReport.addDiff(filename, fileDiff(project.NewPath(label),
+					diskContent, existOnDisk, body, true))
+			}
 
 			logger.Debug().Msg("successfully written")
 		}
@@ -937,10 +1275,6 @@ func genFileLogger(logger zerolog.Logger, genfile GenFile) zerolog.Logger {
 	return genBlockLogger(logger, "generate_file", genfile.Label(), genfile.Context())
 }
 
-func genFileBlockLogger(logger zerolog.Logger, block hcl.GenFileBlock) zerolog.Logger {
-	return genBlockLogger(logger, "generate_file", block.Label, block.Context)
-}
-
 func genBlockLogger(logger zerolog.Logger, blockname, label, context string) zerolog.Logger {
 	return logger.With().
 		Str(fmt.Sprintf("%s.label", blockname), label).
@@ -951,7 +1285,7 @@ func genBlockLogger(logger zerolog.Logger, blockname, label, context string) zer
 func hasGenHCLHeader(code string) bool {
 	// When changing headers we need to support old ones (or break).
 	// For now keeping them here, to avoid breaks.
-	for _, header := range []string{genhcl.Header, genhcl.HeaderV0} {
+	for _, header := range []string{genhcl.Header, genhcl.HeaderV0, gendata.YAMLHeader} {
 		if strings.HasPrefix(code, header) {
 			return true
 		}
@@ -1029,11 +1363,10 @@ func validateStackGeneratedFiles(root *config.Root, stackpath string, generated
 	return errs.AsError()
 }
 
-func validateRootGenerateBlock(root *config.Root, block hcl.GenFileBlock) error {
-	target := block.Label
+func validateRootGenerateBlock(root *config.Root, blockname, target string, rng info.Range) error {
 	if !path.IsAbs(target) {
 		return errors.E(
-			ErrInvalidGenBlockLabel, block.Range,
+			ErrInvalidGenBlockLabel, rng,
 			"%s: is not an absolute path", target,
 		)
 	}
@@ -1058,7 +1391,7 @@ func validateRootGenerateBlock(root *config.Root, block hcl.GenFileBlock) error
 			}
 			return errors.E(
 				ErrInvalidGenBlockLabel, err,
-				block.Range,
+				rng,
 				"%s: checking if dest dir is a symlink",
 				target,
 			)
@@ -1066,7 +1399,7 @@ func validateRootGenerateBlock(root *config.Root, block hcl.GenFileBlock) error
 		if (info.Mode() & fs.ModeSymlink) == fs.ModeSymlink {
 			return errors.E(
 				ErrInvalidGenBlockLabel, err,
-				block.Range,
+				rng,
 				"%s: generates code inside a symlink",
 				target,
 			)
@@ -1074,9 +1407,10 @@ func validateRootGenerateBlock(root *config.Root, block hcl.GenFileBlock) error
 
 		if config.IsStack(root, destdir) {
 			return errors.E(ErrInvalidGenBlockLabel,
-				block.Range,
-				"%s: generate_file.context=root generates inside a stack %s",
+				rng,
+				"%s: %s.context=root generates inside a stack %s",
 				target,
+				blockname,
 				project.PrjAbsPath(root.HostDir(), destdir),
 			)
 		}
@@ -1181,28 +1515,36 @@ func loadAsserts(root *config.Root, st *config.Stack, globals *eval.Object) ([]c
 	return asserts, nil
 }
 
-func loadStackCodeCfgs(
+// evalStackCodeCfgs evaluates the generate blocks of the stack, returning the
+// generated files and all the evaluated assertions of the stack and the
+// generate blocks. The assertions must be handled by the caller.
+func evalStackCodeCfgs(
 	root *config.Root,
 	st *config.Stack,
 	globals *eval.Object,
 	vendorDir project.Path,
 	vendorRequests chan<- event.VendorRequest,
-) ([]GenFile, error) {
+) ([]GenFile, []config.Assert, error) {
 	asserts, err := loadAsserts(root, st, globals)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var genfilesConfigs []GenFile
 
 	genfiles, err := genfile.Load(root, st, globals, vendorDir, vendorRequests)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	genhcls, err := genhcl.Load(root, st, globals, vendorDir, vendorRequests)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	gendatas, err := gendata.Load(root, st, globals, vendorDir, vendorRequests)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	for _, f := range genfiles {
@@ -1213,6 +1555,14 @@ func loadStackCodeCfgs(
 		genfilesConfigs = append(genfilesConfigs, f)
 	}
 
+	for _, f := range gendatas {
+		genfilesConfigs = append(genfilesConfigs, f)
+	}
+
+	if manifest, ok := newManifestFile(genfiles, gendatas); ok {
+		genfilesConfigs = append(genfilesConfigs, manifest)
+	}
+
 	sort.Slice(genfilesConfigs, func(i, j int) bool {
 		return genfilesConfigs[i].Label() < genfilesConfigs[j].Label()
 	})
@@ -1221,15 +1571,10 @@ func loadStackCodeCfgs(
 		asserts = append(asserts, gen.Asserts()...)
 	}
 
-	err = handleAsserts(root.HostDir(), st.HostDir(root), asserts)
-	if err != nil {
-		return nil, err
-	}
-
-	return genfilesConfigs, nil
+	return genfilesConfigs, asserts, nil
 }
 
-func cleanupOrphaned(root *config.Root, report Report) Report {
+func cleanupOrphaned(root *config.Root, report Report, rootFiles []GenFile, opts Options) Report {
 	logger := log.With().
 		Str("action", "generate.cleanupOrphaned()").
 		Logger()
@@ -1249,13 +1594,40 @@ func cleanupOrphaned(root *config.Root, report Report) Report {
 		return report
 	}
 
+	// files of context=root blocks were already handled by generateRootFiles.
+	rootLabels := map[string]bool{}
+	for _, file := range rootFiles {
+		rootLabels[path.Clean(file.Label())] = true
+	}
+
 	deletedFiles := map[project.Path][]string{}
+	deletedDiffs := map[project.Path]map[string]string{}
 	deleteFailures := map[project.Path]*errors.List{}
 
 	for _, genfile := range orphanedGenFiles {
+		if rootLabels["/"+genfile] {
+			continue
+		}
+
 		genfileAbspath := filepath.Join(root.HostDir(), genfile)
 		dir := project.NewPath("/" + filepath.ToSlash(filepath.Dir(genfile)))
-		if err := os.Remove(genfileAbspath); err != nil {
+		filename := filepath.Base(genfile)
+
+		var err error
+		if opts.DryRun {
+			var body []byte
+			body, err = os.ReadFile(genfileAbspath)
+			if err == nil {
+				if deletedDiffs[dir] == nil {
+					deletedDiffs[dir] = map[string]string{}
+				}
+				deletedDiffs[dir][filename] = fileDiff(dir.Join(filename),
+					string(body), true, "", false)
+			}
+		} else {
+			err = os.Remove(genfileAbspath)
+		}
+		if err != nil {
 			if deleteFailures[dir] == nil {
 				deleteFailures[dir] = errors.L()
 			}
@@ -1263,8 +1635,6 @@ func cleanupOrphaned(root *config.Root, report Report) Report {
 			continue
 		}
 
-		filename := filepath.Base(genfile)
-
 		log.Info().
 			Stringer("dir", dir).
 			Str("file", filename).
@@ -1281,6 +1651,7 @@ func cleanupOrphaned(root *config.Root, report Report) Report {
 			Result: Result{
 				Dir:     failedDir,
 				Deleted: delFiles,
+				Diffs:   deletedDiffs[failedDir],
 			},
 			Error: errs,
 		})
@@ -1290,6 +1661,7 @@ func cleanupOrphaned(root *config.Root, report Report) Report {
 		report.Successes = append(report.Successes, Result{
 			Dir:     dir,
 			Deleted: deletedFiles,
+			Diffs:   deletedDiffs[dir],
 		})
 	}
 
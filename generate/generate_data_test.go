@@ -0,0 +1,298 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/generate/gendata"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGenerateJSONAndYAML(t *testing.T) {
+	t.Parallel()
+
+	testCodeGeneration(t, []testcase{
+		{
+			name: "keys are sorted",
+			layout: []string{
+				"s:stack",
+			},
+			configs: []hclconfig{
+				{
+					path: "/stack",
+					add: Doc(
+						GenerateJSON(
+							Labels("file.json"),
+							Expr("content", `{
+								b    = 1
+								a    = { d = "x", c = [true, null] }
+								name = terramate.stack.name
+							}`),
+						),
+						GenerateYAML(
+							Labels("file.yml"),
+							Expr("content", `{
+								b    = 1
+								a    = { d = "x", c = [true, null] }
+								name = terramate.stack.name
+							}`),
+						),
+					),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/stack",
+					files: map[string]fmt.Stringer{
+						"file.json": stringer(`{
+  "a": {
+    "c": [
+      true,
+      null
+    ],
+    "d": "x"
+  },
+  "b": 1,
+  "name": "stack"
+}`),
+						"file.yml": stringer(`"a":
+  "c":
+  - true
+  - null
+  "d": "x"
+"b": 1
+"name": "stack"`),
+						generate.ManifestFilename: stringer("file.json"),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/stack"),
+						Created: []string{generate.ManifestFilename, "file.json", "file.yml"},
+					},
+				},
+			},
+		},
+		{
+			name: "lets and condition",
+			layout: []string{
+				"s:stack",
+			},
+			configs: []hclconfig{
+				{
+					path: "/",
+					add: Doc(
+						GenerateJSON(
+							Labels("enabled.json"),
+							Lets(
+								Expr("name", `"${terramate.stack.name}-app"`),
+							),
+							Expr("content", `{ name = let.name }`),
+						),
+						GenerateYAML(
+							Labels("disabled.yml"),
+							Bool("condition", false),
+							Expr("content", `{ name = "disabled" }`),
+						),
+					),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/stack",
+					files: map[string]fmt.Stringer{
+						"enabled.json": stringer(`{
+  "name": "stack-app"
+}`),
+						generate.ManifestFilename: stringer("enabled.json"),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/stack"),
+						Created: []string{generate.ManifestFilename, "enabled.json"},
+					},
+				},
+			},
+		},
+		{
+			name: "content must be an object",
+			layout: []string{
+				"s:stack",
+			},
+			configs: []hclconfig{
+				{
+					path: "/stack",
+					add: GenerateJSON(
+						Labels("file.json"),
+						Expr("content", `["a", "b"]`),
+					),
+				},
+			},
+			wantReport: generate.Report{
+				Failures: []generate.FailureResult{
+					{
+						Result: generate.Result{
+							Dir: project.NewPath("/stack"),
+						},
+						Error: errors.E(gendata.ErrInvalidContentType),
+					},
+				},
+			},
+		},
+		{
+			name: "conflicts with other generate blocks",
+			layout: []string{
+				"s:stack",
+			},
+			configs: []hclconfig{
+				{
+					path: "/stack",
+					add: Doc(
+						GenerateJSON(
+							Labels("file"),
+							Expr("content", `{}`),
+						),
+						GenerateFile(
+							Labels("file"),
+							Str("content", "data"),
+						),
+					),
+				},
+			},
+			wantReport: generate.Report{
+				Failures: []generate.FailureResult{
+					{
+						Result: generate.Result{
+							Dir: project.NewPath("/stack"),
+						},
+						Error: errors.E(generate.ErrConflictingConfig),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestGenerateYAMLOrphanedFileIsDeleted(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:stack"})
+	cfg := s.RootEntry().CreateFile("generate.tm", GenerateYAML(
+		Labels("file.yml"),
+		Expr("content", `{ a = 1 }`),
+	).String())
+
+	report := s.Generate()
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/stack"),
+				Created: []string{"file.yml"},
+			},
+		},
+	})
+
+	outdated, err := generate.DetectOutdated(s.Config(), project.NewPath("/modules"))
+	assert.NoError(t, err)
+	assertEqualStringList(t, outdated, []string{})
+
+	cfg.Write("")
+
+	outdated, err = generate.DetectOutdated(s.ReloadConfig(), project.NewPath("/modules"))
+	assert.NoError(t, err)
+	assertEqualStringList(t, outdated, []string{"stack/file.yml"})
+
+	report = s.Generate()
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/stack"),
+				Deleted: []string{"file.yml"},
+			},
+		},
+	})
+}
+
+func TestGenerateJSONOrphanedFileIsDeleted(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:stack"})
+	cfg := s.RootEntry().CreateFile("generate.tm", GenerateJSON(
+		Labels("file.json"),
+		Expr("content", `{ a = 1 }`),
+	).String())
+
+	report := s.Generate()
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/stack"),
+				Created: []string{generate.ManifestFilename, "file.json"},
+			},
+		},
+	})
+
+	outdated, err := generate.DetectOutdated(s.Config(), project.NewPath("/modules"))
+	assert.NoError(t, err)
+	assertEqualStringList(t, outdated, []string{})
+
+	cfg.Write("")
+
+	outdated, err = generate.DetectOutdated(s.ReloadConfig(), project.NewPath("/modules"))
+	assert.NoError(t, err)
+	assertEqualStringList(t, outdated, []string{"stack/" + generate.ManifestFilename, "stack/file.json"})
+
+	report = s.Generate()
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/stack"),
+				Deleted: []string{generate.ManifestFilename, "file.json"},
+			},
+		},
+	})
+	test.DoesNotExist(t, filepath.Join(s.RootDir(), "stack"), "file.json")
+}
+
+func TestGenerateJSONOfRemovedStackIsDeleted(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:stack"})
+	s.RootEntry().CreateFile("generate.tm", GenerateJSON(
+		Labels("file.json"),
+		Expr("content", `{ a = 1 }`),
+	).String())
+
+	s.Generate()
+
+	s.StackEntry("stack").DeleteStackConfig()
+
+	s.ReloadConfig()
+	report := s.Generate()
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/stack"),
+				Deleted: []string{generate.ManifestFilename, "file.json"},
+			},
+		},
+	})
+}
@@ -0,0 +1,286 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/project"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGenerateForEach(t *testing.T) {
+	t.Parallel()
+
+	testCodeGeneration(t, []testcase{
+		{
+			name: "generate_hcl generates a file for each element",
+			layout: []string{
+				"s:stacks/stack-1",
+				"s:stacks/stack-2",
+			},
+			configs: []hclconfig{
+				{
+					path: "/stacks",
+					add: Doc(
+						Globals(
+							Expr("regions", `["eu-west-1", "us-east-1"]`),
+						),
+						GenerateHCL(
+							Labels("provider_$${element.value}.tf"),
+							Expr("for_each", `global.regions`),
+							Content(
+								Block("provider",
+									Labels("aws"),
+									Expr("region", `element.value`),
+								),
+							),
+						),
+					),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/stacks/stack-1",
+					files: map[string]fmt.Stringer{
+						"provider_eu-west-1.tf": Block("provider",
+							Labels("aws"),
+							Str("region", "eu-west-1"),
+						),
+						"provider_us-east-1.tf": Block("provider",
+							Labels("aws"),
+							Str("region", "us-east-1"),
+						),
+					},
+				},
+				{
+					dir: "/stacks/stack-2",
+					files: map[string]fmt.Stringer{
+						"provider_eu-west-1.tf": Block("provider",
+							Labels("aws"),
+							Str("region", "eu-west-1"),
+						),
+						"provider_us-east-1.tf": Block("provider",
+							Labels("aws"),
+							Str("region", "us-east-1"),
+						),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/stacks/stack-1"),
+						Created: []string{"provider_eu-west-1.tf", "provider_us-east-1.tf"},
+					},
+					{
+						Dir:     project.NewPath("/stacks/stack-2"),
+						Created: []string{"provider_eu-west-1.tf", "provider_us-east-1.tf"},
+					},
+				},
+			},
+		},
+		{
+			name: "elements generating the same file conflict",
+			layout: []string{
+				"s:stack",
+			},
+			configs: []hclconfig{
+				{
+					path: "/stack",
+					add: GenerateFile(
+						Labels("$${tm_lower(element.value)}.txt"),
+						Expr("for_each", `["a", "A"]`),
+						Expr("content", `element.value`),
+					),
+				},
+			},
+			wantReport: generate.Report{
+				Failures: []generate.FailureResult{
+					{
+						Result: generate.Result{
+							Dir: project.NewPath("/stack"),
+						},
+						Error: errors.E(generate.ErrConflictingConfig),
+					},
+				},
+			},
+		},
+		{
+			name: "elements conflicting with other blocks",
+			layout: []string{
+				"s:stack",
+			},
+			configs: []hclconfig{
+				{
+					path: "/stack",
+					add: Doc(
+						GenerateFile(
+							Labels("$${element.value}.txt"),
+							Expr("for_each", `["a", "b"]`),
+							Expr("content", `element.value`),
+						),
+						GenerateFile(
+							Labels("b.txt"),
+							Str("content", "b"),
+						),
+					),
+				},
+			},
+			wantReport: generate.Report{
+				Failures: []generate.FailureResult{
+					{
+						Result: generate.Result{
+							Dir: project.NewPath("/stack"),
+						},
+						Error: errors.E(generate.ErrConflictingConfig),
+					},
+				},
+			},
+		},
+		{
+			name: "null label fails",
+			layout: []string{
+				"s:stack",
+			},
+			configs: []hclconfig{
+				{
+					path: "/stack",
+					add: GenerateFile(
+						Labels("$${element.value}"),
+						Expr("for_each", `tm_tolist(["a", null])`),
+						Expr("content", `"content"`),
+					),
+				},
+			},
+			wantReport: generate.Report{
+				Failures: []generate.FailureResult{
+					{
+						Result: generate.Result{
+							Dir: project.NewPath("/stack"),
+						},
+						Error: errors.E(config.ErrSchema),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestGenerateForEachRemovedElementFileIsDeleted(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:stack"})
+	rootEntry := s.RootEntry()
+	generateConfig := func(regions string) string {
+		return Doc(
+			Globals(
+				Expr("regions", regions),
+			),
+			GenerateHCL(
+				Labels("$${element.value}.tf"),
+				Expr("for_each", `global.regions`),
+				Content(
+					Expr("region", `element.value`),
+				),
+			),
+		).String()
+	}
+	rootEntry.CreateFile("generate.tm", generateConfig(`["eu", "us"]`))
+
+	report := s.Generate()
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/stack"),
+				Created: []string{"eu.tf", "us.tf"},
+			},
+		},
+	})
+
+	rootEntry.CreateFile("generate.tm", generateConfig(`["eu"]`))
+
+	outdated, err := generate.DetectOutdated(s.ReloadConfig(), project.NewPath("/modules"))
+	assert.NoError(t, err)
+	assertEqualStringList(t, outdated, []string{"stack/us.tf"})
+
+	report = s.Generate()
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/stack"),
+				Deleted: []string{"us.tf"},
+			},
+		},
+	})
+}
+
+func TestGenerateFileForEachRemovedElementFileIsDeleted(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:stack"})
+	rootEntry := s.RootEntry()
+	generateConfig := func(regions string) string {
+		return Doc(
+			Globals(
+				Expr("regions", regions),
+			),
+			GenerateFile(
+				Labels("$${element.value}.txt"),
+				Expr("for_each", `global.regions`),
+				Expr("content", `element.value`),
+			),
+		).String()
+	}
+	rootEntry.CreateFile("generate.tm", generateConfig(`["eu", "us"]`))
+
+	report := s.Generate()
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/stack"),
+				Created: []string{generate.ManifestFilename, "eu.txt", "us.txt"},
+			},
+		},
+	})
+
+	rootEntry.CreateFile("generate.tm", generateConfig(`["eu"]`))
+
+	outdated, err := generate.DetectOutdated(s.ReloadConfig(), project.NewPath("/modules"))
+	assert.NoError(t, err)
+	assertEqualStringList(t, outdated, []string{"stack/" + generate.ManifestFilename, "stack/us.txt"})
+
+	report = s.Generate()
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/stack"),
+				Changed: []string{generate.ManifestFilename},
+				Deleted: []string{"us.txt"},
+			},
+		},
+	})
+	assert.EqualStrings(t, "eu", s.StackEntry("stack").ReadFile("eu.txt"))
+
+	rootEntry.CreateFile("generate.tm", generateConfig(`[]`))
+
+	s.ReloadConfig()
+	report = s.Generate()
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/stack"),
+				Deleted: []string{generate.ManifestFilename, "eu.txt"},
+			},
+		},
+	})
+}
@@ -0,0 +1,270 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/project"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGenerateHCLRootContext(t *testing.T) {
+	t.Parallel()
+
+	testCodeGeneration(t, []testcase{
+		{
+			name: "generate_hcl.context=root has access to all stacks metadata",
+			layout: []string{
+				`s:stacks/stack-1:tags=["k8s"]`,
+				"s:stacks/stack-2",
+			},
+			configs: []hclconfig{
+				{
+					path: "/source",
+					add: GenerateHCL(
+						Labels("/target/stacks.tf"),
+						Expr("context", "root"),
+						Content(
+							Block("locals",
+								Expr("count", `tm_length(terramate.stacks.all)`),
+								Expr("first", `terramate.stacks.all[0].name`),
+								Expr("last", `terramate.stacks.all[1].path.absolute`),
+								Expr("tag", `terramate.stacks.all[0].tags[0]`),
+							),
+						),
+					),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/target",
+					files: map[string]fmt.Stringer{
+						"stacks.tf": Block("locals",
+							Number("count", 2),
+							Str("first", "stack-1"),
+							Str("last", "/stacks/stack-2"),
+							Str("tag", "k8s"),
+						),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/target"),
+						Created: []string{"stacks.tf"},
+					},
+				},
+			},
+		},
+		{
+			name: "generate_file.context=root has access to all stacks metadata",
+			layout: []string{
+				"s:stacks/stack-1",
+				"s:stacks/stack-2",
+			},
+			configs: []hclconfig{
+				{
+					path: "/",
+					add: GenerateFile(
+						Labels("/CODEOWNERS"),
+						Expr("context", "root"),
+						Expr("content", `tm_join("\n", [for st in terramate.stacks.all : "${st.path.absolute} @${st.name}"])`),
+					),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/",
+					files: map[string]fmt.Stringer{
+						"CODEOWNERS": stringer("/stacks/stack-1 @stack-1\n/stacks/stack-2 @stack-2"),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/"),
+						Created: []string{"CODEOWNERS"},
+					},
+				},
+			},
+		},
+		{
+			name: "context=root has access to the stacks by path",
+			layout: []string{
+				"s:stacks/stack-1:id=stack-1-id",
+				"s:stacks/stack-2",
+			},
+			configs: []hclconfig{
+				{
+					path: "/",
+					add: Terramate(
+						Config(
+							Block("stacks",
+								Expr("exported_globals", `["owner"]`),
+							),
+						),
+					),
+				},
+				{
+					path: "/stacks/stack-1",
+					add: Globals(
+						Str("owner", "team-a"),
+					),
+				},
+				{
+					path: "/source",
+					add: GenerateHCL(
+						Labels("/target/stacks.tf"),
+						Expr("context", "root"),
+						Content(
+							Block("locals",
+								Expr("first_owner", `terramate.stacks.all[0].globals.owner`),
+								Expr("id", `terramate.stacks.by_path["/stacks/stack-1"].id`),
+								Expr("owner", `terramate.stacks.by_path["/stacks/stack-1"].globals.owner`),
+							),
+						),
+					),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/target",
+					files: map[string]fmt.Stringer{
+						"stacks.tf": Block("locals",
+							Str("first_owner", "team-a"),
+							Str("id", "stack-1-id"),
+							Str("owner", "team-a"),
+						),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/target"),
+						Created: []string{"stacks.tf"},
+					},
+				},
+			},
+		},
+		{
+			name: "generate_hcl.context=root is disallowed to generate inside stacks",
+			layout: []string{
+				"s:stack",
+			},
+			configs: []hclconfig{
+				{
+					path: "/",
+					add: GenerateHCL(
+						Labels("/stack/file.tf"),
+						Expr("context", "root"),
+						Content(
+							Str("a", "b"),
+						),
+					),
+				},
+			},
+			wantReport: generate.Report{
+				Failures: []generate.FailureResult{
+					{
+						Result: generate.Result{
+							Dir: project.NewPath("/stack"),
+						},
+						Error: errors.E(generate.ErrInvalidGenBlockLabel),
+					},
+				},
+			},
+		},
+		{
+			name: "generate_hcl and generate_file with context=root and same label - fails",
+			configs: []hclconfig{
+				{
+					path: "/",
+					add: Doc(
+						GenerateHCL(
+							Labels("/target/file.tf"),
+							Expr("context", "root"),
+							Content(
+								Str("a", "b"),
+							),
+						),
+						GenerateFile(
+							Labels("/target/file.tf"),
+							Expr("context", "root"),
+							Str("content", "a = 1"),
+						),
+					),
+				},
+			},
+			wantReport: generate.Report{
+				Failures: []generate.FailureResult{
+					{
+						Result: generate.Result{
+							Dir: project.NewPath("/target"),
+						},
+						Error: errors.E(generate.ErrConflictingConfig),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestGenerateHCLRootContextOutdatedAndOrphanedFiles(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:stack"})
+	rootEntry := s.RootEntry()
+	generateConfig := func(value string) string {
+		return GenerateHCL(
+			Labels("/target/file.tf"),
+			Expr("context", "root"),
+			Content(
+				Str("value", value),
+			),
+		).String()
+	}
+	rootEntry.CreateFile("generate.tm", generateConfig("v1"))
+
+	report := s.Generate()
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/target"),
+				Created: []string{"file.tf"},
+			},
+		},
+	})
+
+	outdated, err := generate.DetectOutdated(s.ReloadConfig(), project.NewPath("/modules"))
+	assert.NoError(t, err)
+	assertEqualStringList(t, outdated, []string{})
+
+	rootEntry.CreateFile("generate.tm", generateConfig("v2"))
+
+	outdated, err = generate.DetectOutdated(s.ReloadConfig(), project.NewPath("/modules"))
+	assert.NoError(t, err)
+	assertEqualStringList(t, outdated, []string{"target/file.tf"})
+
+	rootEntry.CreateFile("generate.tm", "")
+
+	report = s.GenerateWith(s.ReloadConfig(), project.NewPath("/modules"))
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/target"),
+				Deleted: []string{"file.tf"},
+			},
+		},
+	})
+}
@@ -0,0 +1,157 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/project"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGenerateStackFilter(t *testing.T) {
+	t.Parallel()
+
+	layout := []string{
+		`s:dev/app:tags=["k8s"]`,
+		`s:prod/app:tags=["k8s"]`,
+		`s:prod/db:tags=["db"]`,
+	}
+
+	testCodeGeneration(t, []testcase{
+		{
+			name:   "filtered out stacks are not evaluated",
+			layout: layout,
+			configs: []hclconfig{
+				{
+					path: "/prod",
+					add: Globals(
+						Str("env", "prod"),
+					),
+				},
+				{
+					path: "/",
+					add: GenerateFile(
+						Labels("k8s.txt"),
+						Block("stack_filter",
+							Expr("project_paths", `["/prod/**"]`),
+							Expr("tags", `["k8s"]`),
+						),
+						Expr("content", `global.env`),
+					),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/prod/app",
+					files: map[string]fmt.Stringer{
+						"k8s.txt": stringer("prod"),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/prod/app"),
+						Created: []string{"k8s.txt"},
+					},
+				},
+			},
+		},
+		{
+			name:   "stack matching any of the filters",
+			layout: layout,
+			configs: []hclconfig{
+				{
+					path: "/",
+					add: GenerateHCL(
+						Labels("file.hcl"),
+						Block("stack_filter",
+							Expr("repository_paths", `["/dev/*"]`),
+						),
+						Block("stack_filter",
+							Expr("tags", `["db"]`),
+						),
+						Content(
+							Expr("stack", `terramate.stack.path.absolute`),
+						),
+					),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/dev/app",
+					files: map[string]fmt.Stringer{
+						"file.hcl": Doc(
+							Str("stack", "/dev/app"),
+						),
+					},
+				},
+				{
+					dir: "/prod/db",
+					files: map[string]fmt.Stringer{
+						"file.hcl": Doc(
+							Str("stack", "/prod/db"),
+						),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/dev/app"),
+						Created: []string{"file.hcl"},
+					},
+					{
+						Dir:     project.NewPath("/prod/db"),
+						Created: []string{"file.hcl"},
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestGenerateStackFilterRemovesFilesOfFilteredOutStacks(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{`s:stack:tags=["k8s"]`})
+	rootEntry := s.RootEntry()
+	generateConfig := func(tags string) string {
+		return GenerateFile(
+			Labels("file.txt"),
+			Block("stack_filter",
+				Expr("tags", tags),
+			),
+			Str("content", "data"),
+		).String()
+	}
+	rootEntry.CreateFile("generate.tm", generateConfig(`["k8s"]`))
+
+	report := s.Generate()
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/stack"),
+				Created: []string{"file.txt"},
+			},
+		},
+	})
+
+	rootEntry.CreateFile("generate.tm", generateConfig(`["other"]`))
+
+	report = s.GenerateWith(s.ReloadConfig(), project.NewPath("/modules"))
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/stack"),
+				Deleted: []string{"file.txt"},
+			},
+		},
+	})
+}
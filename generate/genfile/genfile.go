@@ -59,6 +59,7 @@ type File struct {
 	body      string
 	condition bool
 	asserts   []config.Assert
+	forEach   bool
 }
 
 // Label of the original generate_file block.
@@ -94,6 +95,12 @@ func (f File) Asserts() []config.Assert {
 	return f.asserts
 }
 
+// ForEach tells if the file was generated by a generate_file block with a
+// for_each attribute.
+func (f File) ForEach() bool {
+	return f.forEach
+}
+
 // Header returns the header of this file.
 func (f File) Header() string {
 	// For now we don't support headers for arbitrary files
@@ -136,19 +143,41 @@ func Load(
 			continue
 		}
 
-		name := genFileBlock.Label
-		evalctx := stack.NewEvalCtx(root, st, globals)
-		vendorTargetDir := project.NewPath(path.Join(
-			st.Dir.String(),
-			path.Dir(name)))
-
-		evalctx.SetFunction(stdlib.Name("vendor"), stdlib.VendorFunc(vendorTargetDir, vendorDir, vendorRequests))
+		if !config.StackFilterMatches(root, st, genFileBlock.StackFilters) {
+			// same as condition = false, but nothing is evaluated.
+			if genFileBlock.ForEach == nil {
+				files = append(files, File{
+					label:     genFileBlock.Label,
+					origin:    genFileBlock.Range,
+					condition: false,
+					context:   genFileBlock.Context,
+				})
+			}
+			continue
+		}
 
-		file, err := Eval(genFileBlock, evalctx.Context)
+		evalctx := stack.NewEvalCtx(root, st, globals)
+		err := config.EvalForEach(evalctx.Context, genFileBlock.Label, genFileBlock.ForEach,
+			func(name string, evalctx *eval.Context) error {
+				vendorTargetDir := project.NewPath(path.Join(
+					st.Dir.String(),
+					path.Dir(name)))
+
+				evalctx.SetFunction(stdlib.Name("vendor"), stdlib.VendorFunc(vendorTargetDir, vendorDir, vendorRequests))
+
+				genBlock := genFileBlock
+				genBlock.Label = name
+				file, err := Eval(genBlock, evalctx)
+				if err != nil {
+					return err
+				}
+				file.forEach = genFileBlock.ForEach != nil
+				files = append(files, file)
+				return nil
+			})
 		if err != nil {
 			return nil, err
 		}
-		files = append(files, file)
 	}
 
 	sort.Slice(files, func(i, j int) bool {
@@ -158,7 +187,9 @@ func Load(
 	return files, nil
 }
 
-// Eval the generate_file block.
+// Eval the generate_file block. If the block has a for_each attribute then
+// the caller must use [config.EvalForEach] to set the label and evaluation
+// context of each generated file.
 func Eval(block hcl.GenFileBlock, evalctx *eval.Context) (File, error) {
 	name := block.Label
 	err := lets.Load(block.Lets, evalctx)
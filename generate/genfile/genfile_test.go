@@ -922,6 +922,184 @@ stack_id=stack-id
 			},
 			wantErr: errors.E(genfile.ErrContentEval),
 		},
+		{
+			name:  "for_each generates a file for each element",
+			stack: "/stack",
+			configs: []hclconfig{
+				{
+					path: "/stack/test.tm",
+					add: GenerateFile(
+						Labels("$${element.key}.txt"),
+						Expr("for_each", `{ b = "B", a = "A" }`),
+						Expr("content", `element.value`),
+					),
+				},
+			},
+			want: []result{
+				{
+					name: "a.txt",
+					file: genFile{
+						condition: true,
+						body:      "A",
+					},
+				},
+				{
+					name: "b.txt",
+					file: genFile{
+						condition: true,
+						body:      "B",
+					},
+				},
+			},
+		},
+		{
+			name:  "for_each with iterator evaluates lets and condition for each element",
+			stack: "/stack",
+			configs: []hclconfig{
+				{
+					path: "/test.tm",
+					add: GenerateFile(
+						Labels("$${region.value}.txt"),
+						Expr("for_each", `["eu", "us", "sa"]`),
+						Expr("iterator", "region"),
+						Lets(
+							Expr("name", `"${terramate.stack.name}-${region.value}"`),
+						),
+						Expr("condition", `region.value != "sa"`),
+						Expr("content", `let.name`),
+					),
+				},
+			},
+			want: []result{
+				{
+					name: "eu.txt",
+					file: genFile{
+						condition: true,
+						body:      "stack-eu",
+					},
+				},
+				{
+					name: "sa.txt",
+					file: genFile{
+						condition: false,
+					},
+				},
+				{
+					name: "us.txt",
+					file: genFile{
+						condition: true,
+						body:      "stack-us",
+					},
+				},
+			},
+		},
+		{
+			name:  "iterator without for_each fails",
+			stack: "/stack",
+			configs: []hclconfig{
+				{
+					path: "/stack/test.tm",
+					add: GenerateFile(
+						Labels("test"),
+						Expr("iterator", "it"),
+						Str("content", "test"),
+					),
+				},
+			},
+			wantErr: errors.E(hcl.ErrTerramateSchema),
+		},
+		{
+			name:  "stack_filter skips evaluation of filtered out stacks",
+			stack: "/stack",
+			configs: []hclconfig{
+				{
+					path: "/test.tm",
+					add: Doc(
+						GenerateFile(
+							Labels("other.txt"),
+							Block("stack_filter",
+								Expr("project_paths", `["/other/**"]`),
+							),
+							Expr("content", `global.undefined`),
+						),
+						GenerateFile(
+							Labels("stack.txt"),
+							Block("stack_filter",
+								Expr("project_paths", `["/*"]`),
+							),
+							Str("content", "stack"),
+						),
+					),
+				},
+			},
+			want: []result{
+				{
+					name: "other.txt",
+					file: genFile{
+						condition: false,
+					},
+				},
+				{
+					name: "stack.txt",
+					file: genFile{
+						condition: true,
+						body:      "stack",
+					},
+				},
+			},
+		},
+		{
+			name:  "stack_filter with relative path pattern fails",
+			stack: "/stack",
+			configs: []hclconfig{
+				{
+					path: "/stack/test.tm",
+					add: GenerateFile(
+						Labels("test"),
+						Block("stack_filter",
+							Expr("project_paths", `["stack/**"]`),
+						),
+						Str("content", "test"),
+					),
+				},
+			},
+			wantErr: errors.E(hcl.ErrTerramateSchema),
+		},
+		{
+			name:  "stack_filter with invalid tag filter fails",
+			stack: "/stack",
+			configs: []hclconfig{
+				{
+					path: "/stack/test.tm",
+					add: GenerateFile(
+						Labels("test"),
+						Block("stack_filter",
+							Expr("tags", `["K8S"]`),
+						),
+						Str("content", "test"),
+					),
+				},
+			},
+			wantErr: errors.E(hcl.ErrTerramateSchema),
+		},
+		{
+			name:  "stack_filter on context=root fails",
+			stack: "/stack",
+			configs: []hclconfig{
+				{
+					path: "/test.tm",
+					add: GenerateFile(
+						Labels("/test"),
+						Expr("context", "root"),
+						Block("stack_filter",
+							Expr("tags", `["a"]`),
+						),
+						Str("content", "test"),
+					),
+				},
+			},
+			wantErr: errors.E(hcl.ErrTerramateSchema),
+		},
 	}
 
 	for _, tcase := range tcases {
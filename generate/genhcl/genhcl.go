@@ -33,12 +33,21 @@ import (
 // about the origin of the generated code.
 type HCL struct {
 	label     string
+	context   string
 	origin    info.Range
 	body      string
 	condition bool
 	asserts   []config.Assert
 }
 
+const (
+	// StackContext is the stack context name.
+	StackContext = "stack"
+
+	// RootContext is the root context name.
+	RootContext = "root"
+)
+
 const (
 	// Header is the current header string used by generate_hcl code generation.
 	Header = "// TERRAMATE: GENERATED AUTOMATICALLY DO NOT EDIT"
@@ -113,7 +122,7 @@ func (h HCL) Condition() bool {
 
 // Context of the generate_hcl block.
 func (h HCL) Context() string {
-	return "stack"
+	return h.context
 }
 
 func (h HCL) String() string {
@@ -129,7 +138,7 @@ func (h HCL) String() string {
 // directories. Any conflicts will be reported as an error.
 //
 // Metadata and globals for the stack are used on the evaluation of the
-// generate_hcl blocks.
+// generate_hcl blocks. Blocks with context=root are ignored.
 //
 // The rootdir MUST be an absolute path.
 func Load(
@@ -146,106 +155,143 @@ func Load(
 
 	var hcls []HCL
 	for _, hclBlock := range hclBlocks {
-		name := hclBlock.Label
-		evalctx := stack.NewEvalCtx(root, st, globals)
-
-		vendorTargetDir := project.NewPath(path.Join(
-			st.Dir.String(),
-			path.Dir(name)))
-
-		evalctx.SetFunction(
-			stdlib.Name("vendor"),
-			stdlib.VendorFunc(vendorTargetDir, vendorDir, vendorRequests),
-		)
-
-		err := lets.Load(hclBlock.Lets, evalctx.Context)
-		if err != nil {
-			return nil, err
+		if hclBlock.Context != StackContext {
+			continue
 		}
 
-		condition := true
-		if hclBlock.Condition != nil {
-			value, err := evalctx.Eval(hclBlock.Condition.Expr)
-			if err != nil {
-				return nil, errors.E(ErrConditionEval, err)
-			}
-			if value.Type() != cty.Bool {
-				return nil, errors.E(
-					ErrInvalidConditionType,
-					"condition has type %s but must be boolean",
-					value.Type().FriendlyName(),
-				)
+		if !config.StackFilterMatches(root, st, hclBlock.StackFilters) {
+			// the block is handled as having a false condition, without
+			// evaluating it, so files generated previously are removed.
+			if hclBlock.ForEach == nil {
+				hcls = append(hcls, HCL{
+					label:     hclBlock.Label,
+					context:   hclBlock.Context,
+					origin:    hclBlock.Range,
+					condition: false,
+				})
 			}
-			condition = value.True()
+			continue
 		}
 
-		if !condition {
-			hcls = append(hcls, HCL{
-				label:     name,
-				origin:    hclBlock.Range,
-				condition: condition,
-			})
+		evalctx := stack.NewEvalCtx(root, st, globals)
+		err := config.EvalForEach(evalctx.Context, hclBlock.Label, hclBlock.ForEach,
+			func(name string, evalctx *eval.Context) error {
+				vendorTargetDir := project.NewPath(path.Join(
+					st.Dir.String(),
+					path.Dir(name)))
+
+				evalctx.SetFunction(
+					stdlib.Name("vendor"),
+					stdlib.VendorFunc(vendorTargetDir, vendorDir, vendorRequests),
+				)
 
-			continue
+				genBlock := hclBlock
+				genBlock.Label = name
+				gen, err := Eval(genBlock, evalctx)
+				if err != nil {
+					return err
+				}
+				hcls = append(hcls, gen)
+				return nil
+			})
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		asserts := make([]config.Assert, len(hclBlock.Asserts))
-		assertsErrs := errors.L()
-		assertFailed := false
+	sort.SliceStable(hcls, func(i, j int) bool {
+		return hcls[i].Label() < hcls[j].Label()
+	})
 
-		for i, assertCfg := range hclBlock.Asserts {
-			assert, err := config.EvalAssert(evalctx.Context, assertCfg)
-			if err != nil {
-				assertsErrs.Append(err)
-				continue
-			}
-			asserts[i] = assert
-			if !assert.Assertion && !assert.Warning {
-				assertFailed = true
-			}
-		}
+	return hcls, nil
+}
 
-		if err := assertsErrs.AsError(); err != nil {
-			return nil, err
-		}
+// Eval the generate_hcl block. If the block has a for_each attribute then the
+// caller must use [config.EvalForEach] to set the label and evaluation context
+// of each generated file.
+func Eval(hclBlock hcl.GenHCLBlock, evalctx *eval.Context) (HCL, error) {
+	name := hclBlock.Label
+	err := lets.Load(hclBlock.Lets, evalctx)
+	if err != nil {
+		return HCL{}, err
+	}
 
-		if assertFailed {
-			hcls = append(hcls, HCL{
-				label:     name,
-				origin:    hclBlock.Range,
-				condition: condition,
-				asserts:   asserts,
-			})
-			continue
+	condition := true
+	if hclBlock.Condition != nil {
+		value, err := evalctx.Eval(hclBlock.Condition.Expr)
+		if err != nil {
+			return HCL{}, errors.E(ErrConditionEval, err)
+		}
+		if value.Type() != cty.Bool {
+			return HCL{}, errors.E(
+				ErrInvalidConditionType,
+				"condition has type %s but must be boolean",
+				value.Type().FriendlyName(),
+			)
 		}
+		condition = value.True()
+	}
 
-		evalctx.SetFunction(stdlib.Name("hcl_expression"), stdlib.HCLExpressionFunc())
+	if !condition {
+		return HCL{
+			label:     name,
+			context:   hclBlock.Context,
+			origin:    hclBlock.Range,
+			condition: condition,
+		}, nil
+	}
 
-		gen := hclwrite.NewEmptyFile()
-		if err := copyBody(gen.Body(), hclBlock.Content.Body, evalctx); err != nil {
-			return nil, errors.E(ErrContentEval, err, "generate_hcl %q", name)
-		}
+	asserts := make([]config.Assert, len(hclBlock.Asserts))
+	assertsErrs := errors.L()
+	assertFailed := false
 
-		formatted, err := fmt.FormatMultiline(string(gen.Bytes()), hclBlock.Range.HostPath())
+	for i, assertCfg := range hclBlock.Asserts {
+		assert, err := config.EvalAssert(evalctx, assertCfg)
 		if err != nil {
-			panic(errors.E(err,
-				"internal error: formatting generated code for generate_hcl %q:%s", name, string(gen.Bytes()),
-			))
+			assertsErrs.Append(err)
+			continue
+		}
+		asserts[i] = assert
+		if !assert.Assertion && !assert.Warning {
+			assertFailed = true
 		}
-		hcls = append(hcls, HCL{
+	}
+
+	if err := assertsErrs.AsError(); err != nil {
+		return HCL{}, err
+	}
+
+	if assertFailed {
+		return HCL{
 			label:     name,
+			context:   hclBlock.Context,
 			origin:    hclBlock.Range,
-			body:      formatted,
 			condition: condition,
 			asserts:   asserts,
-		})
+		}, nil
 	}
 
-	sort.SliceStable(hcls, func(i, j int) bool {
-		return hcls[i].Label() < hcls[j].Label()
-	})
+	evalctx.SetFunction(stdlib.Name("hcl_expression"), stdlib.HCLExpressionFunc())
 
-	return hcls, nil
+	gen := hclwrite.NewEmptyFile()
+	if err := copyBody(gen.Body(), hclBlock.Content.Body, evalctx); err != nil {
+		return HCL{}, errors.E(ErrContentEval, err, "generate_hcl %q", name)
+	}
+
+	formatted, err := fmt.FormatMultiline(string(gen.Bytes()), hclBlock.Range.HostPath())
+	if err != nil {
+		panic(errors.E(err,
+			"internal error: formatting generated code for generate_hcl %q:%s", name, string(gen.Bytes()),
+		))
+	}
+	return HCL{
+		label:     name,
+		context:   hclBlock.Context,
+		origin:    hclBlock.Range,
+		body:      formatted,
+		condition: condition,
+		asserts:   asserts,
+	}, nil
 }
 
 type dynBlockAttributes struct {
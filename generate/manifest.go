@@ -0,0 +1,109 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/generate/gendata"
+	"github.com/terramate-io/terramate/generate/genfile"
+	"github.com/terramate-io/terramate/hcl/info"
+)
+
+// ManifestFilename is the name of the generated file listing the files of a
+// stack which have no header but are tracked by Terramate: the ones generated
+// by generate_json blocks and by generate_file blocks with for_each. The
+// listed files are deleted once they are not generated anymore, like the files
+// with a header.
+const ManifestFilename = ".terramate-generated"
+
+// manifestHeader is the header of the manifest, which uses the YAML comment
+// syntax, so it's detected as a generated file.
+const manifestHeader = gendata.YAMLHeader
+
+// manifestFile is the generated manifest of the tracked files of a stack.
+type manifestFile struct {
+	files []string
+}
+
+// newManifestFile creates the manifest of the tracked files of a stack
+// amongst the given generated files. It returns false if no file is tracked.
+func newManifestFile(genfiles []genfile.File, gendatas []gendata.File) (GenFile, bool) {
+	var files []string
+	for _, f := range genfiles {
+		if f.ForEach() && f.Condition() {
+			files = append(files, f.Label())
+		}
+	}
+	for _, f := range gendatas {
+		if f.Header() == "" && f.Condition() {
+			files = append(files, f.Label())
+		}
+	}
+	if len(files) == 0 {
+		return nil, false
+	}
+	sort.Strings(files)
+	return manifestFile{files: files}, true
+}
+
+func (m manifestFile) Header() string {
+	return manifestHeader + "\n\n"
+}
+
+func (m manifestFile) Body() string {
+	return strings.Join(m.files, "\n") + "\n"
+}
+
+func (m manifestFile) Label() string            { return ManifestFilename }
+func (m manifestFile) Context() string          { return genfile.StackContext }
+func (m manifestFile) Range() info.Range        { return info.Range{} }
+func (m manifestFile) Condition() bool          { return true }
+func (m manifestFile) Asserts() []config.Assert { return nil }
+
+// readManifest returns the files listed in the manifest at the given path,
+// relative to its directory. Only the listed files which exist are returned.
+// It returns false if the file is not a generated manifest.
+func readManifest(manifestPath string) ([]string, bool, error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, false, errors.E(err, "reading generated files manifest")
+	}
+	if !hasGenHCLHeader(string(data)) {
+		return nil, false, nil
+	}
+
+	dir := filepath.Dir(manifestPath)
+	seen := map[string]bool{}
+	var files []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// the manifest is never trusted to point outside its directory.
+		file := path.Clean(line)
+		if path.IsAbs(file) || file == ".." || strings.HasPrefix(file, "../") || seen[file] {
+			continue
+		}
+		seen[file] = true
+
+		st, err := os.Lstat(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, false, errors.E(err, "checking generated file %q", file)
+		}
+		if st.Mode().IsRegular() {
+			files = append(files, file)
+		}
+	}
+	return files, true, nil
+}
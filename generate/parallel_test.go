@@ -0,0 +1,71 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGenerateParallelHasDeterministicReport(t *testing.T) {
+	t.Parallel()
+
+	const nstacks = 32
+
+	for _, parallel := range []int{1, 4, nstacks * 2} {
+		parallel := parallel
+
+		t.Run(fmt.Sprintf("parallel=%d", parallel), func(t *testing.T) {
+			t.Parallel()
+
+			s := sandbox.NoGit(t, true)
+
+			var want generate.Report
+			for i := 0; i < nstacks; i++ {
+				dir := fmt.Sprintf("/stacks/stack-%02d", i)
+				s.BuildTree([]string{"s:" + dir[1:]})
+
+				if i == nstacks/2 {
+					test.AppendFile(t, filepath.Join(s.RootDir(), dir), "conflict.tm", GenerateFile(
+						Labels("file.hcl"),
+						Str("content", "conflict"),
+					).String())
+
+					want.Failures = append(want.Failures, generate.FailureResult{
+						Result: generate.Result{
+							Dir: project.NewPath(dir),
+						},
+						Error: errors.E(generate.ErrConflictingConfig),
+					})
+					continue
+				}
+
+				want.Successes = append(want.Successes, generate.Result{
+					Dir:     project.NewPath(dir),
+					Created: []string{"file.hcl"},
+				})
+			}
+
+			s.RootEntry().CreateFile("generate.tm", GenerateHCL(
+				Labels("file.hcl"),
+				Content(
+					Expr("name", "terramate.stack.name"),
+				),
+			).String())
+
+			report := generate.DoWithOptions(s.Config(), project.NewPath("/modules"), nil, generate.Options{
+				Parallel: parallel,
+			})
+			assertEqualReports(t, report, want)
+		})
+	}
+}
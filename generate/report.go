@@ -22,6 +22,9 @@ type Result struct {
 	Changed []string
 	// Deleted contains filenames of all deleted files inside the stack
 	Deleted []string
+	// Diffs contains the unified diff of each created, changed and deleted
+	// file, by filename. It's only set when generating with [Options.DryRun].
+	Diffs map[string]string
 }
 
 // FailureResult represents a failure on code generation.
@@ -52,6 +55,54 @@ func (r Report) HasFailures() bool {
 	return r.BootstrapErr != nil || len(r.Failures) > 0
 }
 
+// HasChanges returns true if this report includes any created, changed or
+// deleted file.
+func (r Report) HasChanges() bool {
+	hasChanges := func(res Result) bool {
+		return len(res.Created) > 0 || len(res.Changed) > 0 || len(res.Deleted) > 0
+	}
+	for _, success := range r.Successes {
+		if hasChanges(success) {
+			return true
+		}
+	}
+	for _, failure := range r.Failures {
+		if hasChanges(failure.Result) {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff provides the unified diff of all created, changed and deleted files,
+// sorted by directory and filename. The diffs are only available when
+// generating with [Options.DryRun].
+func (r Report) Diff() string {
+	var diffs []string
+	addDiffs := func(res Result) {
+		filenames := make([]string, 0, len(res.Diffs))
+		for filename := range res.Diffs {
+			filenames = append(filenames, filename)
+		}
+		sort.Strings(filenames)
+		for _, filename := range filenames {
+			diffs = append(diffs, res.Diffs[filename])
+		}
+	}
+	results := make([]Result, 0, len(r.Successes)+len(r.Failures))
+	results = append(results, r.Successes...)
+	for _, failure := range r.Failures {
+		results = append(results, failure.Result)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Dir.String() < results[j].Dir.String()
+	})
+	for _, res := range results {
+		addDiffs(res)
+	}
+	return strings.Join(diffs, "")
+}
+
 // Full provides a full report of the generated code, including information per stack.
 func (r Report) Full() string {
 	if r.empty() {
@@ -232,6 +283,7 @@ func (r *Report) addDirReport(path project.Path, sr dirReport) {
 				other.Created = append(other.Created, sr.created...)
 				other.Changed = append(other.Changed, sr.changed...)
 				other.Deleted = append(other.Deleted, sr.deleted...)
+				other.Diffs = mergeDiffs(other.Diffs, sr.diffs)
 				r.Successes[i] = other
 				return
 			}
@@ -241,6 +293,7 @@ func (r *Report) addDirReport(path project.Path, sr dirReport) {
 			Created: sr.created,
 			Changed: sr.changed,
 			Deleted: sr.deleted,
+			Diffs:   sr.diffs,
 		})
 		return
 	}
@@ -250,6 +303,7 @@ func (r *Report) addDirReport(path project.Path, sr dirReport) {
 			other.Created = append(other.Created, sr.created...)
 			other.Changed = append(other.Changed, sr.changed...)
 			other.Deleted = append(other.Deleted, sr.deleted...)
+			other.Diffs = mergeDiffs(other.Diffs, sr.diffs)
 			r.Failures[i] = other
 			return
 		}
@@ -260,6 +314,7 @@ func (r *Report) addDirReport(path project.Path, sr dirReport) {
 			Created: sr.created,
 			Changed: sr.changed,
 			Deleted: sr.deleted,
+			Diffs:   sr.diffs,
 		},
 		Error: sr.err,
 	})
@@ -275,6 +330,7 @@ type dirReport struct {
 	created []string
 	changed []string
 	deleted []string
+	diffs   map[string]string
 	err     error
 }
 
@@ -290,6 +346,13 @@ func (s *dirReport) addChangedFile(filename string) {
 	s.changed = append(s.changed, filename)
 }
 
+func (s *dirReport) addDiff(filename string, diff string) {
+	if s.diffs == nil {
+		s.diffs = map[string]string{}
+	}
+	s.diffs[filename] = diff
+}
+
 func (s dirReport) isSuccess() bool {
 	return s.err == nil
 }
@@ -309,6 +372,20 @@ func joinResults[T any](results ...[]T) []T {
 	return all
 }
 
+func mergeDiffs(d1, d2 map[string]string) map[string]string {
+	if len(d2) == 0 {
+		return d1
+	}
+	merged := map[string]string{}
+	for filename, diff := range d1 {
+		merged[filename] = diff
+	}
+	for filename, diff := range d2 {
+		merged[filename] = diff
+	}
+	return merged
+}
+
 func mergeReports(r1, r2 Report) Report {
 	merged := Report{}
 	merged.BootstrapErr = errors.L(r1.BootstrapErr, r2.BootstrapErr).AsError()
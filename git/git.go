@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -395,6 +396,21 @@ func (git *Git) RevParse(rev string) (string, error) {
 	return git.exec("rev-parse", rev)
 }
 
+// HasCommits tells if the repository has any commit.
+func (git *Git) HasCommits() (bool, error) {
+	out, err := git.exec("rev-list", "--max-count=1", "--all")
+	if err != nil {
+		return false, fmt.Errorf("rev-list: %w", err)
+	}
+	return out != "", nil
+}
+
+// ShowFile returns the content of the file at the given revision.
+// The path is relative to the working directory.
+func (git *Git) ShowFile(rev, path string) (string, error) {
+	return git.exec("cat-file", "blob", rev+":./"+filepath.ToSlash(path))
+}
+
 // FetchRemoteRev will fetch from the remote repo the commit id and ref name
 // for the given remote and reference. This will make use of the network
 // to fetch data from the remote configured on the git repo.
@@ -629,6 +645,37 @@ func (git *Git) ListUncommitted(dirs ...string) ([]string, error) {
 	return removeEmptyLines(strings.Split(out, "\n")), nil
 }
 
+// ListSymlinks lists the symbolic links in the index (staging area) in the
+// directories provided in dirs. The paths are relative to the working dir.
+func (git *Git) ListSymlinks(dirs ...string) ([]string, error) {
+	args := []string{"--stage"}
+
+	if len(dirs) > 0 {
+		args = append(args, "--")
+		args = append(args, dirs...)
+	}
+
+	log.Debug().
+		Str("action", "ListSymlinks()").
+		Str("workingDir", git.config.WorkingDir).
+		Msg("List symbolic links.")
+	out, err := git.exec("ls-files", args...)
+	if err != nil {
+		return nil, fmt.Errorf("ls-files: %w", err)
+	}
+
+	// each line has the format: <mode> <object> <stage>\t<file>
+	var symlinks []string
+	for _, line := range removeEmptyLines(strings.Split(out, "\n")) {
+		info, file, ok := strings.Cut(line, "\t")
+		if !ok || !strings.HasPrefix(info, "120000 ") {
+			continue
+		}
+		symlinks = append(symlinks, file)
+	}
+	return symlinks, nil
+}
+
 // ShowCommitMetadata returns common metadata associated with the given object.
 // An object name can be a commit SHA or a symbolic name, i.e. HEAD, branch-name, etc.
 func (git *Git) ShowCommitMetadata(objectName string) (*CommitMetadata, error) {
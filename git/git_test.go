@@ -8,6 +8,8 @@ package git_test
 import (
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -136,6 +138,80 @@ func TestRevParse(t *testing.T) {
 	assert.EqualStrings(t, CookedCommitID, out, "commit mismatch")
 }
 
+func TestHasCommits(t *testing.T) {
+	t.Parallel()
+
+	git := test.NewGitWrapper(t, test.EmptyRepo(t, false), []string{})
+	hasCommits, err := git.HasCommits()
+	assert.NoError(t, err)
+	assert.IsTrue(t, !hasCommits, "empty repository has no commits")
+
+	git = test.NewGitWrapper(t, mkOneCommitRepo(t), []string{})
+	hasCommits, err = git.HasCommits()
+	assert.NoError(t, err)
+	assert.IsTrue(t, hasCommits, "repository has commits")
+}
+
+func TestShowFile(t *testing.T) {
+	t.Parallel()
+
+	repodir := test.EmptyRepo(t, false)
+	test.WriteFile(t, repodir, "README.md", "# Test")
+	test.WriteFile(t, filepath.Join(repodir, "dir"), "file.txt", "content")
+
+	gw := test.NewGitWrapper(t, repodir, []string{})
+	assert.NoError(t, gw.Add("README.md", "dir/file.txt"))
+	assert.NoError(t, gw.Commit("add files"))
+
+	content, err := gw.ShowFile("HEAD", "README.md")
+	assert.NoError(t, err)
+	assert.EqualStrings(t, "# Test", content)
+
+	_, err = gw.ShowFile("HEAD", "missing.txt")
+	assert.Error(t, err, "missing path")
+
+	_, err = gw.ShowFile("missing-rev", "README.md")
+	assert.Error(t, err, "missing revision")
+
+	// the path is relative to the working directory.
+	subgw := test.NewGitWrapper(t, filepath.Join(repodir, "dir"), []string{})
+	content, err = subgw.ShowFile("HEAD", "file.txt")
+	assert.NoError(t, err)
+	assert.EqualStrings(t, "content", content)
+}
+
+func TestListSymlinks(t *testing.T) {
+	t.Parallel()
+
+	repodir := test.EmptyRepo(t, false)
+	test.WriteFile(t, repodir, "target.txt", "target")
+	test.WriteFile(t, filepath.Join(repodir, "dir"), "file.txt", "file")
+	test.MkdirAll(t, filepath.Join(repodir, "dir", "nested"))
+	for _, link := range []string{"dir/link", "dir/nested/link", "dir/untracked", "other-link"} {
+		assert.NoError(t, os.Symlink(
+			filepath.Join(repodir, "target.txt"),
+			filepath.Join(repodir, filepath.FromSlash(link)),
+		))
+	}
+
+	gw := test.NewGitWrapper(t, repodir, []string{})
+	assert.NoError(t, gw.Add("target.txt", "dir/file.txt", "dir/link", "dir/nested/link", "other-link"))
+
+	assertSymlinks := func(want []string, dirs ...string) {
+		t.Helper()
+		got, err := gw.ListSymlinks(dirs...)
+		assert.NoError(t, err)
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatalf("unexpected symlinks for %v (-want +got):\n%s", dirs, diff)
+		}
+	}
+
+	assertSymlinks([]string{"dir/link", "dir/nested/link", "other-link"})
+	assertSymlinks([]string{"dir/link", "dir/nested/link"}, "dir")
+	assertSymlinks([]string{"dir/nested/link"}, "dir/nested")
+	assertSymlinks(nil, "missing")
+}
+
 func TestClone(t *testing.T) {
 	const (
 		filename = "test.txt"
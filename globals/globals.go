@@ -25,6 +25,7 @@ import (
 const (
 	ErrEval      errors.Kind = "global eval"
 	ErrRedefined errors.Kind = "global redefined"
+	ErrCycle     errors.Kind = "globals cycle detected"
 )
 
 type (
@@ -211,6 +212,26 @@ func (dirExprs HierarchicalExprs) SetOverride(
 	}
 }
 
+// Lookup returns the expression which defines the global at the given path.
+// The expression can be the one assigning the path itself or one of its parent
+// objects. The most specific config dir (closer to the stack) has precedence
+// because its expressions override the ones from parent dirs.
+func (dirExprs HierarchicalExprs) Lookup(path []string) (Expr, bool) {
+	sorted := dirExprs.sort()
+	for i := len(sorted) - 1; i >= 0; i-- {
+		for size := len(path); size >= 1; size-- {
+			if size > project.MaxGlobalLabels {
+				continue
+			}
+			key := NewGlobalAttrPath(path[:size-1], path[size-1])
+			if expr, ok := sorted[i].expressions[key]; ok {
+				return expr, true
+			}
+		}
+	}
+	return Expr{}, false
+}
+
 // Returns a sorted loaded exprs, sorting it by config dir path.
 // The loaded expressions are sorted by the config dir path
 // from smaller (root) to more specific (stack). Eg:
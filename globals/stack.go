@@ -4,18 +4,408 @@
 package globals
 
 import (
+	"path"
+	"sort"
+	"strings"
+
+	hhcl "github.com/hashicorp/hcl/v2"
 	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/project"
 	"github.com/terramate-io/terramate/stdlib"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // ForStack loads from the config tree all globals defined for a given stack.
+//
+// The globals can reference the exported globals of other stacks using the
+// terramate.stacks.by_path namespace indexed by a literal stack path. Those
+// stacks have their globals evaluated first and a cycle of such references
+// is reported as an [ErrCycle] error. The exported globals of the stacks
+// accessed through terramate.stacks.all or a computed terramate.stacks.by_path
+// key are not evaluated, so globals using them fail with an [ErrEval] error.
 func ForStack(root *config.Root, stack *config.Stack) EvalReport {
+	return forStack(root, stack, nil)
+}
+
+// StackRuntime returns the terramate namespace values of the given stack,
+// with the terramate.stacks.by_path object and the terramate.stacks.all list
+// having the metadata and exported globals of all stacks of the project.
+// Stacks whose globals fail to evaluate don't have their globals in them, the
+// failure is reported when the globals of the stack itself are evaluated.
+func StackRuntime(root *config.Root, stack *config.Stack) project.Runtime {
+	stacksNs, err := allStacksNamespace(root)
+	if err != nil {
+		stacksNs = stacksNamespace(cty.EmptyObjectVal)
+	}
+	runtime := projectRuntime(root, stacksNs)
+	runtime.Merge(stack.RuntimeValues(root))
+	return runtime
+}
+
+// RootRuntime returns the terramate namespace values of the project, outside
+// of any stack. The terramate.stacks.by_path and terramate.stacks.all values
+// are the same of [StackRuntime].
+func RootRuntime(root *config.Root) (project.Runtime, error) {
+	stacksNs, err := allStacksNamespace(root)
+	if err != nil {
+		return nil, err
+	}
+	return projectRuntime(root, stacksNs), nil
+}
+
+// allStacksNamespace returns the terramate.stacks.by_path and
+// terramate.stacks.all values with the metadata and exported globals of all
+// stacks of the project.
+func allStacksNamespace(root *config.Root) (map[string]cty.Value, error) {
+	stacksNs, err := root.CachedValue("terramate.stacks", func() (cty.Value, error) {
+		stacks, err := config.LoadAllStacks(root.Tree())
+		if err != nil {
+			return cty.NilVal, err
+		}
+		deps := make([]project.Path, len(stacks))
+		for i, st := range stacks {
+			deps[i] = st.Dir()
+		}
+		byPath, err := stacksByPath(root, deps, nil, true)
+		if err != nil {
+			return cty.NilVal, err
+		}
+		return cty.ObjectVal(stacksNamespace(byPath)), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stacksNs.AsValueMap(), nil
+}
+
+func forStack(root *config.Root, stack *config.Stack, visiting []project.Path) EvalReport {
+	tree, ok := root.Lookup(stack.Dir)
+	if !ok {
+		return NewEvalReport()
+	}
+
+	exprs, err := LoadExprs(tree)
+	if err != nil {
+		report := NewEvalReport()
+		report.BootstrapErr = err
+		return report
+	}
+
+	visiting = append(visiting[:len(visiting):len(visiting)], stack.Dir)
+	byPath, err := stacksByPath(root, exprs.stackDeps(), visiting, false)
+	if err != nil {
+		report := NewEvalReport()
+		report.BootstrapErr = err
+		return report
+	}
+
 	ctx := eval.NewContext(
 		stdlib.Functions(stack.HostDir(root)),
 	)
-	runtime := root.Runtime()
+	runtime := projectRuntime(root, stacksNamespace(byPath))
 	runtime.Merge(stack.RuntimeValues(root))
 	ctx.SetNamespace("terramate", runtime)
-	return ForDir(root, stack.Dir, ctx)
+	report := exprs.Eval(ctx)
+	if report.AsError() != nil {
+		return report
+	}
+
+	// the not evaluated exported globals are unknown values, so any global
+	// using them is unknown.
+	var unknown []string
+	for name, val := range report.Globals.AsValueMap() {
+		if !val.IsWhollyKnown() {
+			unknown = append(unknown, "global."+name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		report := NewEvalReport()
+		report.BootstrapErr = errors.E(ErrEval,
+			"evaluating %s of stack %s: globals can only use the exported globals of stacks "+
+				`accessed by a literal path, like terramate.stacks.by_path["/stack"].globals, `+
+				"and not through terramate.stacks.all or a computed terramate.stacks.by_path key",
+			strings.Join(unknown, ", "), stack.Dir)
+		return report
+	}
+	return report
+}
+
+// projectRuntime returns the project terramate namespace values with the
+// given values added to the terramate.stacks object.
+func projectRuntime(root *config.Root, stacksVals map[string]cty.Value) project.Runtime {
+	runtime := root.Runtime()
+	stacksNs := runtime["stacks"].AsValueMap()
+	for name, val := range stacksVals {
+		stacksNs[name] = val
+	}
+	runtime["stacks"] = cty.ObjectVal(stacksNs)
+	return runtime
+}
+
+// stacksNamespace returns the terramate.stacks.by_path object and the
+// terramate.stacks.all list with its values ordered by the stack path.
+func stacksNamespace(byPath cty.Value) map[string]cty.Value {
+	stacks := byPath.AsValueMap()
+	paths := make([]string, 0, len(stacks))
+	for p := range stacks {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	all := make([]cty.Value, len(paths))
+	for i, p := range paths {
+		all[i] = stacks[p]
+	}
+	return map[string]cty.Value{
+		"all":     cty.TupleVal(all),
+		"by_path": byPath,
+	}
+}
+
+// stacksByPath returns the terramate.stacks.by_path object, which maps the
+// path of each stack to its metadata. Only the stacks in deps have their
+// exported globals evaluated, the globals of the other stacks are unknown. If
+// ignoreErrs is true then the stacks whose globals fail to evaluate are kept
+// without globals.
+func stacksByPath(
+	root *config.Root,
+	deps []project.Path,
+	visiting []project.Path,
+	ignoreErrs bool,
+) (cty.Value, error) {
+	metadata, err := root.CachedValue("terramate.stacks.metadata", func() (cty.Value, error) {
+		stacks, err := config.LoadAllStacks(root.Tree())
+		if err != nil {
+			return cty.NilVal, err
+		}
+		vals := map[string]cty.Value{}
+		for _, st := range stacks {
+			vals[st.Dir().String()] = st.RuntimeValues(root)["stack"]
+		}
+		return cty.ObjectVal(vals), nil
+	})
+	if err != nil {
+		return cty.NilVal, err
+	}
+
+	byPath := metadata.AsValueMap()
+	if byPath == nil {
+		byPath = map[string]cty.Value{}
+	}
+	isDep := map[string]bool{}
+	for _, dep := range deps {
+		isDep[dep.String()] = true
+	}
+	for p, stackMetadata := range byPath {
+		if isDep[p] {
+			continue
+		}
+		stackVals := stackMetadata.AsValueMap()
+		stackVals["globals"] = cty.DynamicVal
+		byPath[p] = cty.ObjectVal(stackVals)
+	}
+	for _, dep := range deps {
+		stackMetadata, ok := byPath[dep.String()]
+		if !ok {
+			// not a stack, the evaluation fails on the missing key.
+			continue
+		}
+		exported, err := exportedGlobals(root, dep, visiting)
+		if err != nil {
+			if ignoreErrs {
+				continue
+			}
+			return cty.NilVal, err
+		}
+		stackVals := stackMetadata.AsValueMap()
+		stackVals["globals"] = exported
+		byPath[dep.String()] = cty.ObjectVal(stackVals)
+	}
+	return cty.ObjectVal(byPath), nil
+}
+
+// exportedGlobals returns the exported globals of the stack at dir. The
+// visiting stacks are the ones whose globals depend on the stack.
+func exportedGlobals(root *config.Root, dir project.Path, visiting []project.Path) (cty.Value, error) {
+	names := root.ExportedGlobals()
+	if len(names) == 0 {
+		return cty.EmptyObjectVal, nil
+	}
+
+	for i, visited := range visiting {
+		if visited == dir {
+			cycle := project.Paths(append(visiting[i:len(visiting):len(visiting)], dir))
+			return cty.NilVal, errors.E(ErrCycle,
+				"globals reference each other through terramate.stacks.by_path: %s",
+				strings.Join(cycle.Strings(), " -> "))
+		}
+	}
+
+	return root.CachedValue("terramate.stacks.by_path.globals:"+dir.String(), func() (cty.Value, error) {
+		st, err := config.LoadStack(root, dir)
+		if err != nil {
+			return cty.NilVal, err
+		}
+		report := forStack(root, st, visiting)
+		if err := report.AsError(); err != nil {
+			return cty.NilVal, errors.E(err, "evaluating globals of stack %s", dir)
+		}
+
+		globals := report.Globals.AsValueMap()
+		exported := map[string]cty.Value{}
+		for _, name := range names {
+			if val, ok := globals[name]; ok {
+				exported[name] = val
+			}
+		}
+		return cty.ObjectVal(exported), nil
+	})
+}
+
+// StackRefs returns the paths of the stacks referenced through
+// terramate.stacks.by_path by the globals of the given stack and by the given
+// traversals, ordered by path. It returns false if any of them accesses the
+// terramate.stacks.by_path or terramate.stacks.all values without a literal
+// stack path, in which case any stack may be referenced.
+func StackRefs(root *config.Root, stack *config.Stack, traversals []hhcl.Traversal) ([]project.Path, bool, error) {
+	if tree, ok := root.Lookup(stack.Dir); ok {
+		exprs, err := LoadExprs(tree)
+		if err != nil {
+			return nil, false, err
+		}
+		traversals = append(exprs.variables(), traversals...)
+	}
+
+	refs := map[project.Path]struct{}{}
+	for _, traversal := range traversals {
+		if ref, ok := stackRef(traversal); ok {
+			refs[ref] = struct{}{}
+		} else if anyStackRef(traversal) {
+			return nil, false, nil
+		}
+	}
+	return sortedPaths(refs), true, nil
+}
+
+// StacksByPath returns the entries of the terramate.stacks.by_path object of
+// the given stacks, keyed by the stack path. Paths which are not stacks have no
+// entry and stacks whose globals fail to evaluate have no globals.
+func StacksByPath(root *config.Root, paths []project.Path) (map[string]cty.Value, error) {
+	byPath, err := stacksByPath(root, paths, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	all := byPath.AsValueMap()
+	entries := map[string]cty.Value{}
+	for _, p := range paths {
+		if entry, ok := all[p.String()]; ok {
+			entries[p.String()] = entry
+		}
+	}
+	return entries, nil
+}
+
+// stackDeps returns the paths of the stacks whose exported globals are
+// referenced by the global expressions, ordered by path.
+func (dirExprs HierarchicalExprs) stackDeps() []project.Path {
+	deps := map[project.Path]struct{}{}
+	for _, traversal := range dirExprs.variables() {
+		if dep, ok := stackGlobalsRef(traversal); ok {
+			deps[dep] = struct{}{}
+		}
+	}
+	return sortedPaths(deps)
+}
+
+// variables returns the traversals of the global expressions which are
+// evaluated, ie. the most specific expression of each global.
+func (dirExprs HierarchicalExprs) variables() []hhcl.Traversal {
+	exprs := map[GlobalPathKey]Expr{}
+	for _, exprset := range dirExprs.sort() {
+		for key, expr := range exprset.expressions {
+			exprs[key] = expr
+		}
+	}
+
+	var traversals []hhcl.Traversal
+	for _, expr := range exprs {
+		traversals = append(traversals, expr.Variables()...)
+	}
+	return traversals
+}
+
+func sortedPaths(set map[project.Path]struct{}) []project.Path {
+	res := make([]project.Path, 0, len(set))
+	for p := range set {
+		res = append(res, p)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].String() < res[j].String()
+	})
+	return res
+}
+
+// stackGlobalsRef returns the stack path of a traversal referencing its
+// exported globals, like terramate.stacks.by_path["/stack"].globals.
+func stackGlobalsRef(traversal hhcl.Traversal) (project.Path, bool) {
+	ref, ok := stackRef(traversal)
+	if !ok {
+		return project.Path{}, false
+	}
+	if len(traversal) > 4 {
+		attr, ok := traversal[4].(hhcl.TraverseAttr)
+		if !ok || attr.Name != "globals" {
+			return project.Path{}, false
+		}
+	}
+	return ref, true
+}
+
+// stackRef returns the stack path of a traversal referencing an entry of
+// terramate.stacks.by_path by a literal path, like
+// terramate.stacks.by_path["/stack"].id.
+func stackRef(traversal hhcl.Traversal) (project.Path, bool) {
+	if len(traversal) < 4 || traversal.RootName() != "terramate" {
+		return project.Path{}, false
+	}
+	for i, name := range []string{"stacks", "by_path"} {
+		attr, ok := traversal[i+1].(hhcl.TraverseAttr)
+		if !ok || attr.Name != name {
+			return project.Path{}, false
+		}
+	}
+	index, ok := traversal[3].(hhcl.TraverseIndex)
+	if !ok || !index.Key.Type().Equals(cty.String) ||
+		!index.Key.IsKnown() || index.Key.IsNull() ||
+		!path.IsAbs(index.Key.AsString()) {
+		return project.Path{}, false
+	}
+	return project.NewPath(index.Key.AsString()), true
+}
+
+// anyStackRef tells if the traversal may access any entry of the
+// terramate.stacks.by_path or terramate.stacks.all values, like
+// terramate.stacks.all[0] or terramate.stacks.by_path used as an object.
+func anyStackRef(traversal hhcl.Traversal) bool {
+	if traversal.RootName() != "terramate" {
+		return false
+	}
+	if len(traversal) < 2 {
+		return true
+	}
+	attr, ok := traversal[1].(hhcl.TraverseAttr)
+	if !ok {
+		return true
+	}
+	if attr.Name != "stacks" {
+		return false
+	}
+	if len(traversal) < 3 {
+		return true
+	}
+	attr, ok = traversal[2].(hhcl.TraverseAttr)
+	return !ok || attr.Name == "by_path" || attr.Name == "all"
 }
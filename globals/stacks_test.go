@@ -0,0 +1,364 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals_test
+
+import (
+	"testing"
+
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test/hclwrite"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGlobalsStacksByPath(t *testing.T) {
+	t.Parallel()
+
+	exportedGlobals := func(names string) hclconfig {
+		return hclconfig{
+			path: "/",
+			add: Terramate(
+				Config(
+					Block("stacks",
+						Expr("exported_globals", names),
+					),
+				),
+			),
+		}
+	}
+
+	for _, tcase := range []testcase{
+		{
+			name: "metadata of other stacks",
+			layout: []string{
+				`s:net/vpc:id=vpc-stack;tags=["net"]`,
+				"s:app",
+			},
+			configs: []hclconfig{
+				{
+					path: "/app",
+					add: Globals(
+						Expr("vpc_id", `terramate.stacks.by_path["/net/vpc"].id`),
+						Expr("vpc_name", `terramate.stacks.by_path["/net/vpc"].name`),
+						Expr("vpc_tag", `terramate.stacks.by_path["/net/vpc"].tags[0]`),
+					),
+				},
+			},
+			want: map[string]*hclwrite.Block{
+				"/app": Globals(
+					Str("vpc_id", "vpc-stack"),
+					Str("vpc_name", "vpc"),
+					Str("vpc_tag", "net"),
+				),
+			},
+		},
+		{
+			name: "list of the stacks metadata",
+			layout: []string{
+				"s:net/vpc",
+				"s:app",
+			},
+			configs: []hclconfig{
+				{
+					path: "/app",
+					add: Globals(
+						Expr("count", `tm_length(terramate.stacks.all)`),
+						Expr("first", `terramate.stacks.all[0].path.absolute`),
+						Expr("vpc_name", `terramate.stacks.all[1].name`),
+					),
+				},
+			},
+			want: map[string]*hclwrite.Block{
+				"/app": Globals(
+					Number("count", 2),
+					Str("first", "/app"),
+					Str("vpc_name", "vpc"),
+				),
+			},
+		},
+		{
+			name: "no exported globals configured",
+			layout: []string{
+				"s:net/vpc",
+				"s:app",
+			},
+			configs: []hclconfig{
+				{
+					path: "/net/vpc",
+					add: Globals(
+						Str("vpc_id", "vpc-123"),
+					),
+				},
+				{
+					path: "/app",
+					add: Globals(
+						Expr("count", `tm_length(terramate.stacks.by_path["/net/vpc"].globals)`),
+					),
+				},
+			},
+			want: map[string]*hclwrite.Block{
+				"/net/vpc": Globals(
+					Str("vpc_id", "vpc-123"),
+				),
+				"/app": Globals(
+					Number("count", 0),
+				),
+			},
+		},
+		{
+			name: "only exported globals are available",
+			layout: []string{
+				"s:net/vpc",
+				"s:app",
+			},
+			configs: []hclconfig{
+				exportedGlobals(`["vpc_id", "undefined"]`),
+				{
+					path: "/net/vpc",
+					add: Globals(
+						Str("vpc_id", "vpc-123"),
+						Str("secret", "hidden"),
+					),
+				},
+				{
+					path: "/app",
+					add: Globals(
+						Expr("vpc_id", `terramate.stacks.by_path["/net/vpc"].globals.vpc_id`),
+						Expr("exported", `tm_join(",", tm_keys(terramate.stacks.by_path["/net/vpc"].globals))`),
+					),
+				},
+			},
+			want: map[string]*hclwrite.Block{
+				"/net/vpc": Globals(
+					Str("vpc_id", "vpc-123"),
+					Str("secret", "hidden"),
+				),
+				"/app": Globals(
+					Str("vpc_id", "vpc-123"),
+					Str("exported", "vpc_id"),
+				),
+			},
+		},
+		{
+			name: "exported globals referencing other stacks",
+			layout: []string{
+				"s:a",
+				"s:b",
+				"s:c",
+			},
+			configs: []hclconfig{
+				exportedGlobals(`["name"]`),
+				{
+					path: "/a",
+					add: Globals(
+						Expr("name", `"${terramate.stacks.by_path["/b"].globals.name}-a"`),
+					),
+				},
+				{
+					path: "/b",
+					add: Globals(
+						Expr("name", `"${terramate.stacks.by_path["/c"].globals.name}-b"`),
+					),
+				},
+				{
+					path: "/c",
+					add: Globals(
+						Str("name", "c"),
+					),
+				},
+			},
+			want: map[string]*hclwrite.Block{
+				"/a": Globals(Str("name", "c-b-a")),
+				"/b": Globals(Str("name", "c-b")),
+				"/c": Globals(Str("name", "c")),
+			},
+		},
+		{
+			name: "stacks referencing each other exported globals fails",
+			layout: []string{
+				"s:a",
+				"s:b",
+			},
+			configs: []hclconfig{
+				exportedGlobals(`["name"]`),
+				{
+					path: "/a",
+					add: Globals(
+						Expr("name", `terramate.stacks.by_path["/b"].globals.name`),
+					),
+				},
+				{
+					path: "/b",
+					add: Globals(
+						Expr("name", `terramate.stacks.by_path["/a"].globals.name`),
+					),
+				},
+			},
+			wantErr: errors.E(globals.ErrCycle),
+		},
+		{
+			name: "stack referencing its own exported globals fails",
+			layout: []string{
+				"s:a",
+			},
+			configs: []hclconfig{
+				exportedGlobals(`["name"]`),
+				{
+					path: "/a",
+					add: Globals(
+						Str("name", "a"),
+						Expr("other", `terramate.stacks.by_path["/a"].globals.name`),
+					),
+				},
+			},
+			wantErr: errors.E(globals.ErrCycle),
+		},
+		{
+			name: "metadata of stacks accessed by a computed path",
+			layout: []string{
+				"s:net/vpc",
+				"s:app",
+			},
+			configs: []hclconfig{
+				exportedGlobals(`["out"]`),
+				{
+					path: "/app",
+					add: Globals(
+						Str("vpc_path", "/net/vpc"),
+						Expr("vpc_name", `terramate.stacks.by_path[global.vpc_path].name`),
+						Expr("names", `tm_join(",", [for s in terramate.stacks.all : s.name])`),
+					),
+				},
+			},
+			want: map[string]*hclwrite.Block{
+				"/app": Globals(
+					Str("vpc_path", "/net/vpc"),
+					Str("vpc_name", "vpc"),
+					Str("names", "app,vpc"),
+				),
+			},
+		},
+		{
+			name: "exported globals through terramate.stacks.all fails",
+			layout: []string{
+				"s:a",
+				"s:b",
+			},
+			configs: []hclconfig{
+				exportedGlobals(`["out"]`),
+				{
+					path: "/",
+					add: Globals(
+						Expr("out", `terramate.stack.name`),
+						Expr("outs", `[for s in terramate.stacks.all : tm_try(s.globals.out, "NONE")]`),
+					),
+				},
+			},
+			wantErr: errors.E(globals.ErrEval),
+		},
+		{
+			name: "exported globals through a computed path fails",
+			layout: []string{
+				"s:a",
+				"s:b",
+			},
+			configs: []hclconfig{
+				exportedGlobals(`["out"]`),
+				{
+					path: "/",
+					add: Globals(
+						Expr("out", `terramate.stack.name`),
+						Str("a_path", "/a"),
+						Expr("a_out", `terramate.stacks.by_path[global.a_path].globals.out`),
+					),
+				},
+			},
+			wantErr: errors.E(globals.ErrEval),
+		},
+	} {
+		testGlobals(t, tcase)
+	}
+}
+
+func TestGlobalsStackRefs(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name     string
+		exprs    []string
+		want     []string
+		wantSome bool
+	}
+
+	for _, tc := range []testcase{
+		{
+			name:     "only the globals references",
+			want:     []string{"/b"},
+			wantSome: true,
+		},
+		{
+			name: "literal references",
+			exprs: []string{
+				`terramate.stacks.by_path["/c"].id`,
+				`terramate.stacks.by_path["/b"].globals.name`,
+				`terramate.stacks.list`,
+				`terramate.stack.name`,
+			},
+			want:     []string{"/b", "/c"},
+			wantSome: true,
+		},
+		{
+			name:  "dynamic reference",
+			exprs: []string{`terramate.stacks.by_path[global.name]`},
+		},
+		{
+			name:  "list of all stacks",
+			exprs: []string{`terramate.stacks.all[0].id`},
+		},
+		{
+			name:  "whole stacks object",
+			exprs: []string{`tm_keys(terramate.stacks)`},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := sandbox.NoGit(t, true)
+			s.BuildTree([]string{"s:a", "s:b", "s:c"})
+			s.DirEntry("a").CreateFile("globals.tm", Globals(
+				Expr("name", `terramate.stacks.by_path["/b"].name`),
+			).String())
+
+			var traversals []hhcl.Traversal
+			for _, exprStr := range tc.exprs {
+				expr, diags := hclsyntax.ParseExpression([]byte(exprStr), "test.tm", hhcl.InitialPos)
+				assert.IsTrue(t, !diags.HasErrors(), diags.Error())
+				traversals = append(traversals, expr.Variables()...)
+			}
+
+			root := s.Config()
+			st, err := config.LoadStack(root, project.NewPath("/a"))
+			assert.NoError(t, err)
+
+			refs, some, err := globals.StackRefs(root, st, traversals)
+			assert.NoError(t, err)
+			assert.IsTrue(t, some == tc.wantSome, "want %t but got %t", tc.wantSome, some)
+			if !some {
+				return
+			}
+			got := project.Paths(refs).Strings()
+			assert.EqualInts(t, len(tc.want), len(got), "refs %v", got)
+			for i, want := range tc.want {
+				assert.EqualStrings(t, want, got[i])
+			}
+		})
+	}
+}
@@ -5,21 +5,25 @@ package hcl
 
 import (
 	"fmt"
+	"math/big"
 	"os"
 	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/config/filter"
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/fs"
 	"github.com/terramate-io/terramate/hcl/ast"
 	"github.com/terramate-io/terramate/hcl/eval"
 	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/terramate-io/terramate/mapexpr"
 	"github.com/terramate-io/terramate/stdlib"
 	"github.com/zclconf/go-cty/cty"
 	"golang.org/x/exp/slices"
@@ -52,13 +56,17 @@ type Config struct {
 
 	// absdir is the absolute path to the configuration directory.
 	absdir string
+
+	// files are the absolute paths of the loaded files, including imports.
+	files []string
 }
 
 // GenerateConfig includes code generation related configurations, like
-// generate_file and generate_hcl.
+// generate_file, generate_hcl, generate_json and generate_yaml.
 type GenerateConfig struct {
 	Files []GenFileBlock
 	HCLs  []GenHCLBlock
+	Data  []GenDataBlock
 }
 
 // AssertConfig represents Terramate assert configuration block.
@@ -76,6 +84,23 @@ type RunConfig struct {
 
 	// Env contains environment definitions for run.
 	Env *RunEnv
+
+	// RunPolicy is the default timeout and retry policy of the commands.
+	RunPolicy
+}
+
+// RunPolicy represents the timeout and retry policy of the commands executed
+// by terramate run. Nil fields are not set.
+type RunPolicy struct {
+	// Timeout is the maximum duration of each command execution.
+	Timeout *time.Duration
+
+	// Retries is the number of times a failed command is retried.
+	Retries *int
+
+	// RetryOnExitCodes is the list of exit codes that are retried.
+	// If not set then any failure is retried.
+	RetryOnExitCodes []int
 }
 
 // RunEnv represents Terramate run environment.
@@ -111,11 +136,19 @@ type CloudConfig struct {
 	Organization string
 }
 
+// StacksConfig represents Terramate stacks configuration.
+type StacksConfig struct {
+	// ExportedGlobals are the names of the globals of each stack which are
+	// available to other stacks in the terramate.stacks.by_path namespace.
+	ExportedGlobals []string
+}
+
 // RootConfig represents the root config block of a Terramate configuration.
 type RootConfig struct {
 	Git         *GitConfig
 	Run         *RunConfig
 	Cloud       *CloudConfig
+	Stacks      *StacksConfig
 	Experiments []string
 }
 
@@ -186,6 +219,10 @@ type Stack struct {
 
 	// Watch is a list of files to be watched for changes.
 	Watch []string
+
+	// Run is the run policy of the stack defined in the stack.run block,
+	// overriding the terramate.config.run policy.
+	Run *RunPolicy
 }
 
 // GenHCLBlock represents a parsed generate_hcl block.
@@ -200,8 +237,36 @@ type GenHCLBlock struct {
 	Condition *hclsyntax.Attribute
 	// Content block.
 	Content *hclsyntax.Block
+	// Context of the generation (stack by default).
+	Context string
+	// Asserts represents all assert blocks
+	Asserts []AssertConfig
+	// ForEach is the for_each configuration of the block, if any.
+	ForEach *ForEachConfig
+	// StackFilters are the stack_filter blocks of the block.
+	StackFilters []StackFilterConfig
+}
+
+// GenDataBlock represents a parsed generate_json or generate_yaml block.
+type GenDataBlock struct {
+	// Range is the range of the entire block definition.
+	Range info.Range
+	// Label of the block.
+	Label string
+	// Format of the generated file, either "json" or "yaml".
+	Format string
+	// Lets is a block of local variables.
+	Lets *ast.MergedBlock
+	// Condition attribute of the block, if any.
+	Condition *hclsyntax.Attribute
+	// Content attribute of the block.
+	Content *hclsyntax.Attribute
 	// Asserts represents all assert blocks
 	Asserts []AssertConfig
+	// ForEach is the for_each configuration of the block, if any.
+	ForEach *ForEachConfig
+	// StackFilters are the stack_filter blocks of the block.
+	StackFilters []StackFilterConfig
 }
 
 // GenFileBlock represents a parsed generate_file block
@@ -220,6 +285,35 @@ type GenFileBlock struct {
 	Context string
 	// Asserts represents all assert blocks
 	Asserts []AssertConfig
+	// ForEach is the for_each configuration of the block, if any.
+	ForEach *ForEachConfig
+	// StackFilters are the stack_filter blocks of the block.
+	StackFilters []StackFilterConfig
+}
+
+// ForEachConfig represents the for_each and iterator attributes of a generate
+// block, which generates one file for each element of the for_each value.
+type ForEachConfig struct {
+	// Expr is the for_each expression.
+	Expr hcl.Expression
+	// Iterator is the name of the iterator variable.
+	Iterator string
+	// Label is the block label parsed as a template, it's evaluated for each
+	// element to compute the name of the generated file.
+	Label hclsyntax.Expression
+}
+
+// StackFilterConfig represents a stack_filter block of a generate block.
+// A stack matches the filter if it matches all the defined attributes.
+type StackFilterConfig struct {
+	// ProjectPaths are glob patterns matched against the stack path relative
+	// to the project root.
+	ProjectPaths []string
+	// RepositoryPaths are glob patterns matched against the stack path
+	// relative to the repository root.
+	RepositoryPaths []string
+	// Tags is the tag filter matched against the stack tags, if any.
+	Tags filter.TagClause
 }
 
 // Evaluator represents a Terramate evaluator
@@ -258,6 +352,10 @@ type TerramateParser struct {
 	// parsedFiles stores a map of all parsed files
 	parsedFiles map[string]parsedFile
 
+	// importedFiles stores all imported files, including the ones imported
+	// by the imported files.
+	importedFiles []string
+
 	strict bool
 	// if true, calling Parse() or MinimalParse() will fail.
 	parsed bool
@@ -602,6 +700,8 @@ func (p *TerramateParser) handleImport(importBlock *ast.Block) error {
 		}
 
 		p.addParsedFile(p.dir, external, file)
+		p.importedFiles = append(p.importedFiles, file)
+		p.importedFiles = append(p.importedFiles, importParser.importedFiles...)
 	}
 	return nil
 }
@@ -621,6 +721,14 @@ func (p *TerramateParser) sortedParsedFilenames() []string {
 	return filenames
 }
 
+// loadedFiles returns the parsed files of the directory and all the imported
+// files, sorted lexicographically.
+func (p *TerramateParser) loadedFiles() []string {
+	filenames := append(p.internalParsedFiles(), p.importedFiles...)
+	sort.Strings(filenames)
+	return filenames
+}
+
 func (p *TerramateParser) internalParsedFiles() []string {
 	filenames := []string{}
 	for fname, parsed := range p.parsedFiles {
@@ -638,14 +746,24 @@ func (p *TerramateParser) parseStack(stackblock *ast.Block) (*Stack, error) {
 		Logger()
 
 	errs := errors.L()
+	stack := &Stack{}
 	for _, block := range stackblock.Body.Blocks {
-		errs.Append(
-			errors.E(block.TypeRange, "unrecognized block %q", block.Type),
-		)
+		if block.Type != "run" {
+			errs.Append(
+				errors.E(block.TypeRange, "unrecognized block %q", block.Type),
+			)
+			continue
+		}
+		if stack.Run != nil {
+			errs.Append(
+				errors.E(ErrTerramateSchema, block.TypeRange, "duplicated stack.run block"),
+			)
+			continue
+		}
+		stack.Run = &RunPolicy{}
+		errs.Append(p.parseStackRunPolicy(stack.Run, block))
 	}
 
-	stack := &Stack{}
-
 	logger.Debug().Msg("Get stack attributes.")
 	attrs := ast.AsHCLAttributes(stackblock.Body.Attributes)
 	for _, attr := range ast.SortRawAttributes(attrs) {
@@ -729,6 +847,93 @@ func (p *TerramateParser) parseStack(stackblock *ast.Block) (*Stack, error) {
 	return stack, nil
 }
 
+func (p *TerramateParser) parseStackRunPolicy(policy *RunPolicy, block *hclsyntax.Block) error {
+	errs := errors.L()
+	if len(block.Labels) > 0 {
+		errs.Append(errors.E(ErrTerramateSchema, block.LabelRanges[0],
+			"stack.run block must have no labels"))
+	}
+	for _, subblock := range block.Body.Blocks {
+		errs.Append(errors.E(ErrTerramateSchema, subblock.TypeRange,
+			"unrecognized block stack.run.%s", subblock.Type))
+	}
+	attrs := ast.AsHCLAttributes(block.Body.Attributes)
+	for _, attr := range ast.SortRawAttributes(attrs) {
+		value, err := p.evalctx.Eval(attr.Expr)
+		if err != nil {
+			errs.Append(errors.E(err, "failed to evaluate stack.run.%s attribute", attr.Name))
+			continue
+		}
+		ok, err := parseRunPolicyAttr(policy, attr, value, "stack.run")
+		if err != nil {
+			errs.Append(err)
+			continue
+		}
+		if !ok {
+			errs.Append(errors.E(ErrTerramateSchema, attr.NameRange,
+				"unrecognized attribute stack.run.%s", attr.Name))
+		}
+	}
+	return errs.AsError()
+}
+
+// parseRunPolicyAttr parses the attribute into the policy if it's one of
+// the run policy attributes, returning false otherwise.
+func parseRunPolicyAttr(policy *RunPolicy, attr *hcl.Attribute, value cty.Value, blockname string) (bool, error) {
+	switch attr.Name {
+	case "timeout":
+		if value.Type() != cty.String {
+			return true, hclAttrErr(attr, "%s.timeout is not a string but %q",
+				blockname, value.Type().FriendlyName())
+		}
+		timeout, err := time.ParseDuration(value.AsString())
+		if err != nil {
+			return true, errors.E(ErrTerramateSchema, attr.Expr.Range(), err,
+				"%s.timeout is not a valid duration", blockname)
+		}
+		if timeout <= 0 {
+			return true, hclAttrErr(attr, "%s.timeout must be greater than zero", blockname)
+		}
+		policy.Timeout = &timeout
+	case "retries":
+		retries, err := nonNegativeInt(value)
+		if err != nil {
+			return true, hclAttrErr(attr, "%s.retries %s", blockname, err)
+		}
+		policy.Retries = &retries
+	case "retry_on_exit_codes":
+		if !value.Type().IsTupleType() && !value.Type().IsListType() {
+			return true, hclAttrErr(attr, "%s.retry_on_exit_codes must be a list(number) but found a %q",
+				blockname, value.Type().FriendlyName())
+		}
+		codes := []int{}
+		for it := value.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			code, err := nonNegativeInt(elem)
+			if err != nil {
+				return true, hclAttrErr(attr, "%s.retry_on_exit_codes element %s", blockname, err)
+			}
+			codes = append(codes, code)
+		}
+		policy.RetryOnExitCodes = codes
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+func nonNegativeInt(value cty.Value) (int, error) {
+	if value.Type() != cty.Number || value.IsNull() {
+		return 0, errors.E("must be a number but is %q", value.Type().FriendlyName())
+	}
+	bf := value.AsBigFloat()
+	i, acc := bf.Int64()
+	if acc != big.Exact || i < 0 {
+		return 0, errors.E("must be a non-negative integer but is %s", bf.String())
+	}
+	return int(i), nil
+}
+
 // NewConfig creates a new HCL config with dir as config directory path.
 func NewConfig(dir string) (Config, error) {
 	st, err := os.Stat(dir)
@@ -766,12 +971,17 @@ func (c Config) Experiments() []string {
 // AbsDir returns the absolute path of the configuration directory.
 func (c Config) AbsDir() string { return c.absdir }
 
+// Files returns the absolute paths of all files loaded for the configuration,
+// including the imported ones, sorted lexicographically.
+func (c Config) Files() []string { return c.files }
+
 // IsEmpty returns true if the config is empty, false otherwise.
 func (c Config) IsEmpty() bool {
 	return c.Stack == nil && c.Terramate == nil &&
 		c.Vendor == nil && len(c.Asserts) == 0 &&
 		len(c.Globals) == 0 &&
-		len(c.Generate.Files) == 0 && len(c.Generate.HCLs) == 0
+		len(c.Generate.Files) == 0 && len(c.Generate.HCLs) == 0 &&
+		len(c.Generate.Data) == 0
 }
 
 // HasGlobals tells if the configuration has any globals defined.
@@ -844,8 +1054,9 @@ func IsRootConfig(rootdir string) (bool, error) {
 // generate_hcl blocks are validated, so the caller can expect valid blocks only or an error.
 func parseGenerateHCLBlock(block *ast.Block) (GenHCLBlock, error) {
 	var (
-		content *hclsyntax.Block
-		asserts []AssertConfig
+		content      *hclsyntax.Block
+		asserts      []AssertConfig
+		stackFilters []StackFilterConfig
 	)
 
 	err := validateGenerateHCLBlock(block)
@@ -869,6 +1080,13 @@ func parseGenerateHCLBlock(block *ast.Block) (GenHCLBlock, error) {
 				continue
 			}
 			asserts = append(asserts, assertCfg)
+		case "stack_filter":
+			stackFilter, err := parseStackFilterConfig(subBlock)
+			if err != nil {
+				errs.Append(err)
+				continue
+			}
+			stackFilters = append(stackFilters, stackFilter)
 		case "content":
 			if content != nil {
 				errs.Append(errors.E(subBlock.Range,
@@ -888,6 +1106,21 @@ func parseGenerateHCLBlock(block *ast.Block) (GenHCLBlock, error) {
 			errors.E(ErrTerramateSchema, `"generate_hcl" block requires a content block`, block.Range))
 	}
 
+	context := "stack"
+	if contextAttr, ok := block.Body.Attributes["context"]; ok {
+		context = hcl.ExprAsKeyword(contextAttr.Expr)
+		if context != "stack" && context != "root" {
+			errs.Append(errors.E(contextAttr.Expr.Range(),
+				"generate_hcl.context supported values are \"stack\" and \"root\""+
+					" but given %q", context))
+		}
+	}
+
+	if context == "root" && len(stackFilters) > 0 {
+		errs.Append(errors.E(ErrTerramateSchema, block.Range,
+			"generate_hcl with context=root doesn't support stack_filter blocks"))
+	}
+
 	mergedLets := ast.MergedLabelBlocks{}
 	for labelType, mergedBlock := range letsConfig.MergedLabelBlocks {
 		if labelType.Type == "lets" {
@@ -897,6 +1130,9 @@ func parseGenerateHCLBlock(block *ast.Block) (GenHCLBlock, error) {
 		}
 	}
 
+	foreach, err := parseForEachConfig(block)
+	errs.Append(err)
+
 	if err := errs.AsError(); err != nil {
 		return GenHCLBlock{}, err
 	}
@@ -907,12 +1143,15 @@ func parseGenerateHCLBlock(block *ast.Block) (GenHCLBlock, error) {
 	}
 
 	return GenHCLBlock{
-		Range:     block.Range,
-		Label:     block.Labels[0],
-		Lets:      lets,
-		Asserts:   asserts,
-		Content:   content,
-		Condition: block.Body.Attributes["condition"],
+		Range:        block.Range,
+		Label:        block.Labels[0],
+		Lets:         lets,
+		Asserts:      asserts,
+		Content:      content,
+		Condition:    block.Body.Attributes["condition"],
+		Context:      context,
+		ForEach:      foreach,
+		StackFilters: stackFilters,
 	}, nil
 }
 
@@ -924,7 +1163,10 @@ func parseGenerateFileBlock(block *ast.Block) (GenFileBlock, error) {
 		return GenFileBlock{}, err
 	}
 
-	var asserts []AssertConfig
+	var (
+		asserts      []AssertConfig
+		stackFilters []StackFilterConfig
+	)
 
 	letsConfig := NewCustomRawConfig(map[string]mergeHandler{
 		"lets": (*RawConfig).mergeLabeledBlock,
@@ -942,6 +1184,13 @@ func parseGenerateFileBlock(block *ast.Block) (GenFileBlock, error) {
 				continue
 			}
 			asserts = append(asserts, assertCfg)
+		case "stack_filter":
+			stackFilter, err := parseStackFilterConfig(subBlock)
+			if err != nil {
+				errs.Append(err)
+				continue
+			}
+			stackFilters = append(stackFilters, stackFilter)
 		default:
 			// already validated but sanity checks...
 			panic(errors.E(errors.ErrInternal, "unexpected block type %s", subBlock.Type))
@@ -958,6 +1207,11 @@ func parseGenerateFileBlock(block *ast.Block) (GenFileBlock, error) {
 		}
 	}
 
+	if context == "root" && len(stackFilters) > 0 {
+		errs.Append(errors.E(ErrTerramateSchema, block.Range,
+			"generate_file with context=root doesn't support stack_filter blocks"))
+	}
+
 	mergedLets := ast.MergedLabelBlocks{}
 	for labelType, mergedBlock := range letsConfig.MergedLabelBlocks {
 		if labelType.Type == "lets" {
@@ -967,6 +1221,9 @@ func parseGenerateFileBlock(block *ast.Block) (GenFileBlock, error) {
 		}
 	}
 
+	foreach, err := parseForEachConfig(block)
+	errs.Append(err)
+
 	if err := errs.AsError(); err != nil {
 		return GenFileBlock{}, err
 	}
@@ -977,16 +1234,201 @@ func parseGenerateFileBlock(block *ast.Block) (GenFileBlock, error) {
 	}
 
 	return GenFileBlock{
-		Range:     block.Range,
-		Label:     block.Labels[0],
-		Lets:      lets,
-		Asserts:   asserts,
-		Content:   block.Body.Attributes["content"],
-		Condition: block.Body.Attributes["condition"],
-		Context:   context,
+		Range:        block.Range,
+		Label:        block.Labels[0],
+		Lets:         lets,
+		Asserts:      asserts,
+		Content:      block.Body.Attributes["content"],
+		Condition:    block.Body.Attributes["condition"],
+		Context:      context,
+		ForEach:      foreach,
+		StackFilters: stackFilters,
+	}, nil
+}
+
+// parseGenerateDataBlock parses a generate_json or generate_yaml block.
+func parseGenerateDataBlock(block *ast.Block) (GenDataBlock, error) {
+	err := validateGenerateDataBlock(block)
+	if err != nil {
+		return GenDataBlock{}, err
+	}
+
+	var (
+		asserts      []AssertConfig
+		stackFilters []StackFilterConfig
+	)
+
+	letsConfig := NewCustomRawConfig(map[string]mergeHandler{
+		"lets": (*RawConfig).mergeLabeledBlock,
+	})
+
+	errs := errors.L()
+	for _, subBlock := range block.Blocks {
+		switch subBlock.Type {
+		case "lets":
+			errs.AppendWrap(ErrTerramateSchema, letsConfig.mergeBlocks(ast.Blocks{subBlock}))
+		case "assert":
+			assertCfg, err := parseAssertConfig(subBlock)
+			if err != nil {
+				errs.Append(err)
+				continue
+			}
+			asserts = append(asserts, assertCfg)
+		case "stack_filter":
+			stackFilter, err := parseStackFilterConfig(subBlock)
+			if err != nil {
+				errs.Append(err)
+				continue
+			}
+			stackFilters = append(stackFilters, stackFilter)
+		default:
+			// already validated but sanity checks...
+			panic(errors.E(errors.ErrInternal, "unexpected block type %s", subBlock.Type))
+		}
+	}
+
+	mergedLets := ast.MergedLabelBlocks{}
+	for labelType, mergedBlock := range letsConfig.MergedLabelBlocks {
+		if labelType.Type == "lets" {
+			mergedLets[labelType] = mergedBlock
+
+			errs.AppendWrap(ErrTerramateSchema, validateLets(mergedBlock))
+		}
+	}
+
+	foreach, err := parseForEachConfig(block)
+	errs.Append(err)
+
+	if err := errs.AsError(); err != nil {
+		return GenDataBlock{}, err
+	}
+
+	lets, ok := mergedLets[ast.NewEmptyLabelBlockType("lets")]
+	if !ok {
+		lets = ast.NewMergedBlock("lets", []string{})
+	}
+
+	return GenDataBlock{
+		Range:        block.Range,
+		Label:        block.Labels[0],
+		Format:       strings.TrimPrefix(block.Type, "generate_"),
+		Lets:         lets,
+		Asserts:      asserts,
+		Content:      block.Body.Attributes["content"],
+		Condition:    block.Body.Attributes["condition"],
+		ForEach:      foreach,
+		StackFilters: stackFilters,
 	}, nil
 }
 
+// parseForEachConfig parses the for_each and iterator attributes of a generate
+// block. It returns nil if the block has no for_each attribute.
+func parseForEachConfig(block *ast.Block) (*ForEachConfig, error) {
+	foreach, hasForEach := block.Body.Attributes["for_each"]
+	iteratorAttr, hasIterator := block.Body.Attributes["iterator"]
+	if !hasForEach {
+		if hasIterator {
+			return nil, errors.E(ErrTerramateSchema, iteratorAttr.NameRange,
+				"%s.iterator requires the for_each attribute", block.Type)
+		}
+		return nil, nil
+	}
+
+	iterator := mapexpr.DefaultIterator
+	if hasIterator {
+		var err error
+		iterator, err = mapexpr.ParseIterator(iteratorAttr.AsHCLAttribute())
+		if err != nil {
+			return nil, errors.E(ErrTerramateSchema, err)
+		}
+	}
+
+	// block labels can't have template sequences, so the template is written
+	// with escaped sequences, eg.: "$${element.value}.tf".
+	labelRange := block.Block.LabelRanges[0]
+	label, diags := hclsyntax.ParseTemplate([]byte(block.Labels[0]), labelRange.Filename, labelRange.Start)
+	if diags.HasErrors() {
+		return nil, errors.E(ErrTerramateSchema, diags)
+	}
+
+	return &ForEachConfig{
+		Expr:     foreach.Expr,
+		Iterator: iterator,
+		Label:    label,
+	}, nil
+}
+
+// parseStackFilterConfig parses a stack_filter block of a generate block.
+func parseStackFilterConfig(block *ast.Block) (StackFilterConfig, error) {
+	cfg := StackFilterConfig{}
+	errs := errors.L()
+
+	errs.Append(checkNoBlocks(block))
+
+	for _, attr := range block.Attributes.SortedList() {
+		attrVal, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			errs.Append(errors.E(ErrTerramateSchema, diags,
+				"evaluating %s.%s attribute", block.Type, attr.Name))
+			continue
+		}
+
+		switch attr.Name {
+		case "project_paths", "repository_paths":
+			var patterns []string
+			if err := assignSet(attr.Attribute, &patterns, attrVal); err != nil {
+				errs.Append(err)
+				continue
+			}
+			for _, pattern := range patterns {
+				errs.Append(validateStackFilterPattern(attr, pattern))
+			}
+			if attr.Name == "project_paths" {
+				cfg.ProjectPaths = patterns
+			} else {
+				cfg.RepositoryPaths = patterns
+			}
+		case "tags":
+			var tags []string
+			if err := assignSet(attr.Attribute, &tags, attrVal); err != nil {
+				errs.Append(err)
+				continue
+			}
+			clauses, found, err := filter.ParseTagClauses(tags...)
+			if err != nil {
+				errs.Append(errors.E(ErrTerramateSchema, attr.Expr.Range(), err))
+				continue
+			}
+			if found {
+				cfg.Tags = clauses
+			}
+		default:
+			errs.Append(errors.E(ErrTerramateSchema, attr.NameRange,
+				"unrecognized attribute %s.%s", block.Type, attr.Name,
+			))
+		}
+	}
+
+	if err := errs.AsError(); err != nil {
+		return StackFilterConfig{}, err
+	}
+	return cfg, nil
+}
+
+func validateStackFilterPattern(attr ast.Attribute, pattern string) error {
+	if !path.IsAbs(pattern) {
+		return errors.E(ErrTerramateSchema, attr.Expr.Range(),
+			"stack_filter.%s pattern %q must be an absolute path", attr.Name, pattern)
+	}
+	for _, elem := range strings.Split(pattern, "/") {
+		if _, err := path.Match(elem, ""); err != nil {
+			return errors.E(ErrTerramateSchema, attr.Expr.Range(), err,
+				"invalid stack_filter.%s pattern %q", attr.Name, pattern)
+		}
+	}
+	return nil
+}
+
 func validateImportBlock(block *ast.Block) error {
 	errs := errors.L()
 	if len(block.Labels) != 0 {
@@ -1037,6 +1479,18 @@ func validateGenerateHCLBlock(block *ast.Block) error {
 				Name:     "condition",
 				Required: false,
 			},
+			{
+				Name:     "context",
+				Required: false,
+			},
+			{
+				Name:     "for_each",
+				Required: false,
+			},
+			{
+				Name:     "iterator",
+				Required: false,
+			},
 		},
 		Blocks: []hcl.BlockHeaderSchema{
 			{
@@ -1051,6 +1505,10 @@ func validateGenerateHCLBlock(block *ast.Block) error {
 				Type:       "assert",
 				LabelNames: []string{},
 			},
+			{
+				Type:       "stack_filter",
+				LabelNames: []string{},
+			},
 		},
 	}
 
@@ -1066,6 +1524,59 @@ func validateGenerateHCLBlock(block *ast.Block) error {
 	return errs.AsError()
 }
 
+func validateGenerateDataBlock(block *ast.Block) error {
+	errs := errors.L()
+	if len(block.Labels) != 1 {
+		errs.Append(errors.E(ErrTerramateSchema, block.OpenBraceRange,
+			"%s must have single label instead got %v",
+			block.Type, block.Labels,
+		))
+	} else if block.Labels[0] == "" {
+		errs.Append(errors.E(ErrTerramateSchema, block.OpenBraceRange,
+			"%s label can't be empty", block.Type))
+	}
+	schema := &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{
+				Name:     "content",
+				Required: true,
+			},
+			{
+				Name:     "condition",
+				Required: false,
+			},
+			{
+				Name:     "for_each",
+				Required: false,
+			},
+			{
+				Name:     "iterator",
+				Required: false,
+			},
+		},
+		Blocks: []hcl.BlockHeaderSchema{
+			{
+				Type:       "lets",
+				LabelNames: []string{},
+			},
+			{
+				Type:       "assert",
+				LabelNames: []string{},
+			},
+			{
+				Type:       "stack_filter",
+				LabelNames: []string{},
+			},
+		},
+	}
+
+	_, diags := block.Body.Content(schema)
+	if diags.HasErrors() {
+		errs.Append(errors.E(ErrTerramateSchema, diags))
+	}
+	return errs.AsError()
+}
+
 func validateLets(block *ast.MergedBlock) error {
 	if block.Type != "lets" {
 		return errors.E(block.RawOrigins[0].TypeRange,
@@ -1105,6 +1616,14 @@ func validateGenerateFileBlock(block *ast.Block) error {
 				Name:     "context",
 				Required: false,
 			},
+			{
+				Name:     "for_each",
+				Required: false,
+			},
+			{
+				Name:     "iterator",
+				Required: false,
+			},
 		},
 		Blocks: []hcl.BlockHeaderSchema{
 			{
@@ -1115,6 +1634,10 @@ func validateGenerateFileBlock(block *ast.Block) error {
 				Type:       "assert",
 				LabelNames: []string{},
 			},
+			{
+				Type:       "stack_filter",
+				LabelNames: []string{},
+			},
 		},
 	}
 
@@ -1437,7 +1960,7 @@ func (p *TerramateParser) parseRootConfig(cfg *RootConfig, block *ast.MergedBloc
 		p.Experiments = cfg.Experiments
 	}
 
-	errs.AppendWrap(ErrTerramateSchema, block.ValidateSubBlocks("git", "run", "cloud"))
+	errs.AppendWrap(ErrTerramateSchema, block.ValidateSubBlocks("git", "run", "cloud", "stacks"))
 
 	gitBlock, ok := block.Blocks[ast.NewEmptyLabelBlockType("git")]
 	if ok {
@@ -1462,6 +1985,13 @@ func (p *TerramateParser) parseRootConfig(cfg *RootConfig, block *ast.MergedBloc
 		errs.Append(parseCloudConfig(cfg.Cloud, cloudBlock))
 	}
 
+	stacksBlock, ok := block.Blocks[ast.NewEmptyLabelBlockType("stacks")]
+	if ok {
+		cfg.Stacks = &StacksConfig{}
+
+		errs.Append(parseStacksConfig(cfg.Stacks, stacksBlock))
+	}
+
 	return errs.AsError()
 }
 
@@ -1477,6 +2007,11 @@ func parseRunConfig(runCfg *RunConfig, runBlock *ast.MergedBlock) error {
 			continue
 		}
 
+		if ok, err := parseRunPolicyAttr(&runCfg.RunPolicy, attr.Attribute, value, "terramate.config.run"); ok {
+			errs.Append(err)
+			continue
+		}
+
 		switch attr.Name {
 		case "check_gen_code":
 			if value.Type() != cty.Bool {
@@ -1641,6 +2176,37 @@ func parseCloudConfig(cloud *CloudConfig, cloudBlock *ast.MergedBlock) error {
 	return errs.AsError()
 }
 
+func parseStacksConfig(stacks *StacksConfig, stacksBlock *ast.MergedBlock) error {
+	errs := errors.L()
+
+	errs.AppendWrap(ErrTerramateSchema, stacksBlock.ValidateSubBlocks())
+
+	for _, attr := range stacksBlock.Attributes.SortedList() {
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			errs.Append(errors.E(diags,
+				"failed to evaluate terramate.config.stacks.%s attribute", attr.Name,
+			))
+			continue
+		}
+
+		switch attr.Name {
+		case "exported_globals":
+			if err := assignSet(attr.Attribute, &stacks.ExportedGlobals, value); err != nil {
+				errs.Append(err)
+			}
+
+		default:
+			errs.Append(errors.E(
+				attr.NameRange,
+				"unrecognized attribute terramate.config.stacks.%s",
+				attr.Name,
+			))
+		}
+	}
+	return errs.AsError()
+}
+
 func (p *TerramateParser) parseTerramateSchema() (Config, error) {
 	logger := log.With().
 		Str("action", "parseTerramateSchema()").
@@ -1649,6 +2215,7 @@ func (p *TerramateParser) parseTerramateSchema() (Config, error) {
 
 	config := Config{
 		absdir: p.dir,
+		files:  p.loadedFiles(),
 	}
 
 	errKind := ErrTerramateSchema
@@ -1724,6 +2291,13 @@ func (p *TerramateParser) parseTerramateSchema() (Config, error) {
 				config.Generate.Files = append(config.Generate.Files, genfile)
 			}
 
+		case "generate_json", "generate_yaml":
+			gendata, err := parseGenerateDataBlock(block)
+			errs.Append(err)
+			if err == nil {
+				config.Generate.Data = append(config.Generate.Data, gendata)
+			}
+
 		case "script":
 			if !p.hasExperimentalFeature("scripts") {
 				errs.Append(
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
@@ -657,7 +658,116 @@ func TestHCLParserConfigRun(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "run timeout and retry policy",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    run {
+						      timeout             = "1h30m"
+						      retries             = 2
+						      retry_on_exit_codes = [1, 3]
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Run: &hcl.RunConfig{
+								CheckGenCode: true,
+								RunPolicy: hcl.RunPolicy{
+									Timeout:          durationPtr(90 * time.Minute),
+									Retries:          intPtr(2),
+									RetryOnExitCodes: []int{1, 3},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "run.timeout must be a valid duration",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    run {
+						      timeout = "1 hour"
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "run.retries must be a non-negative integer",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    run {
+						      retries = -1
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "run.retry_on_exit_codes must be a list of numbers",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    run {
+						      retry_on_exit_codes = ["1"]
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
 	} {
 		testParser(t, tc)
 	}
 }
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
+
+func intPtr(i int) *int {
+	return &i
+}
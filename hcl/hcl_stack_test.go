@@ -5,6 +5,7 @@ package hcl_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/hcl"
@@ -76,6 +77,52 @@ func TestHCLParserStack(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "stack with run policy",
+			input: []cfgfile{
+				{
+					filename: "stack.tm",
+					body: `
+						stack {
+							run {
+								timeout = "10m"
+								retries = 1
+							}
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Stack: &hcl.Stack{
+						Run: &hcl.RunPolicy{
+							Timeout: durationPtr(10 * time.Minute),
+							Retries: intPtr(1),
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "stack with unrecognized run attribute",
+			input: []cfgfile{
+				{
+					filename: "stack.tm",
+					body: `
+						stack {
+							run {
+								check_gen_code = false
+							}
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
 		{
 			name: "tm_vendor is not available on stack block",
 			input: []cfgfile{
@@ -45,6 +45,8 @@ func NewTopLevelRawConfig() RawConfig {
 		"vendor":        (*RawConfig).addBlock,
 		"generate_file": (*RawConfig).addBlock,
 		"generate_hcl":  (*RawConfig).addBlock,
+		"generate_json": (*RawConfig).addBlock,
+		"generate_yaml": (*RawConfig).addBlock,
 		"assert":        (*RawConfig).addBlock,
 		"import":        func(r *RawConfig, b *ast.Block) error { return nil },
 	})
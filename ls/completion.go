@@ -0,0 +1,441 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package tmls
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/stdlib"
+	"github.com/zclconf/go-cty/cty"
+	lsp "go.lsp.dev/protocol"
+)
+
+// blockSchema describes the attributes and sub blocks accepted by a block.
+type blockSchema struct {
+	attrs  []string
+	blocks []string
+}
+
+var assertSchema = blockSchema{
+	attrs: []string{"assertion", "message", "warning"},
+}
+
+var stackFilterSchema = blockSchema{
+	attrs: []string{"project_paths", "repository_paths", "tags"},
+}
+
+// schemas maps the path of nested block types (joined by dots) to its schema.
+// The empty path is the top level of a Terramate file.
+var schemas = map[string]blockSchema{
+	"": {
+		blocks: []string{
+			"assert", "generate_file", "generate_hcl", "generate_json",
+			"generate_yaml", "globals",
+			"import", "script", "stack", "terramate", "vendor",
+		},
+	},
+	"terramate": {
+		attrs:  []string{"required_version", "required_version_allow_prereleases"},
+		blocks: []string{"config"},
+	},
+	"terramate.config": {
+		attrs:  []string{"experiments"},
+		blocks: []string{"cloud", "git", "run", "stacks"},
+	},
+	"terramate.config.git": {
+		attrs: []string{
+			"check_remote", "check_uncommitted", "check_untracked",
+			"default_branch", "default_branch_base_ref", "default_remote",
+		},
+	},
+	"terramate.config.run": {
+		attrs:  []string{"check_gen_code", "retries", "retry_on_exit_codes", "timeout"},
+		blocks: []string{"env"},
+	},
+	"terramate.config.cloud": {
+		attrs: []string{"organization"},
+	},
+	"terramate.config.stacks": {
+		attrs: []string{"exported_globals"},
+	},
+	"stack": {
+		attrs: []string{
+			"after", "before", "description", "id", "name",
+			"tags", "wanted_by", "wants", "watch",
+		},
+		blocks: []string{"run"},
+	},
+	"stack.run": {
+		attrs: []string{"retries", "retry_on_exit_codes", "timeout"},
+	},
+	"generate_hcl": {
+		attrs:  []string{"condition", "context", "for_each", "iterator"},
+		blocks: []string{"assert", "content", "lets", "stack_filter"},
+	},
+	"generate_hcl.assert":       assertSchema,
+	"generate_hcl.stack_filter": stackFilterSchema,
+	"generate_file": {
+		attrs:  []string{"condition", "content", "context", "for_each", "iterator"},
+		blocks: []string{"assert", "lets", "stack_filter"},
+	},
+	"generate_file.assert":       assertSchema,
+	"generate_file.stack_filter": stackFilterSchema,
+	"generate_json": {
+		attrs:  []string{"condition", "content", "for_each", "iterator"},
+		blocks: []string{"assert", "lets", "stack_filter"},
+	},
+	"generate_json.assert":       assertSchema,
+	"generate_json.stack_filter": stackFilterSchema,
+	"generate_yaml": {
+		attrs:  []string{"condition", "content", "for_each", "iterator"},
+		blocks: []string{"assert", "lets", "stack_filter"},
+	},
+	"generate_yaml.assert":       assertSchema,
+	"generate_yaml.stack_filter": stackFilterSchema,
+	"assert":                     assertSchema,
+	"script": {
+		attrs:  []string{"description"},
+		blocks: []string{"job"},
+	},
+	"script.job": {
+		attrs: []string{"command", "commands"},
+	},
+	"import": {
+		attrs: []string{"source"},
+	},
+	"vendor": {
+		attrs:  []string{"dir"},
+		blocks: []string{"manifest"},
+	},
+	"vendor.manifest": {
+		blocks: []string{"default"},
+	},
+	"vendor.manifest.default": {
+		attrs: []string{"files"},
+	},
+}
+
+// completionItems computes the completion items available at the given
+// position of the file content.
+func (s *Server) completionItems(fname string, content string, pos lsp.Position) []lsp.CompletionItem {
+	offset := positionOffset(content, pos)
+	before := content[:offset]
+	word := wordBefore(before)
+
+	if strings.Contains(word, ".") {
+		parts := strings.Split(word, ".")
+		path, partial := parts[:len(parts)-1], parts[len(parts)-1]
+		switch path[0] {
+		case "global":
+			return s.globalsCompletion(fname, path[1:], partial)
+		case "terramate":
+			return s.metadataCompletion(fname, path[1:], partial)
+		}
+		return nil
+	}
+
+	blocks, inExpr := cursorContext(before)
+	if inExpr || strings.HasPrefix(word, "tm_") {
+		return s.expressionCompletion(fname, word)
+	}
+
+	schema, ok := schemas[strings.Join(blocks, ".")]
+	if !ok {
+		return nil
+	}
+
+	var items []lsp.CompletionItem
+	for _, attr := range schema.attrs {
+		if strings.HasPrefix(attr, word) {
+			items = append(items, lsp.CompletionItem{
+				Label:  attr,
+				Kind:   lsp.CompletionItemKindProperty,
+				Detail: "attribute",
+			})
+		}
+	}
+	for _, block := range schema.blocks {
+		if strings.HasPrefix(block, word) {
+			items = append(items, lsp.CompletionItem{
+				Label:  block,
+				Kind:   lsp.CompletionItemKindStruct,
+				Detail: "block",
+			})
+		}
+	}
+	return items
+}
+
+// expressionCompletion returns the functions and namespaces that can be used
+// in expressions and which start with the given prefix.
+func (s *Server) expressionCompletion(fname string, prefix string) []lsp.CompletionItem {
+	var items []lsp.CompletionItem
+	for _, ns := range []string{"global", "terramate"} {
+		if strings.HasPrefix(ns, prefix) {
+			items = append(items, lsp.CompletionItem{
+				Label:  ns,
+				Kind:   lsp.CompletionItemKindModule,
+				Detail: "namespace",
+			})
+		}
+	}
+
+	var funcnames []string
+	for name := range stdlib.Functions(s.baseDir(fname)) {
+		if strings.HasPrefix(name, prefix) {
+			funcnames = append(funcnames, name)
+		}
+	}
+	sort.Strings(funcnames)
+	for _, name := range funcnames {
+		items = append(items, lsp.CompletionItem{
+			Label:  name,
+			Kind:   lsp.CompletionItemKindFunction,
+			Detail: "function",
+		})
+	}
+	return items
+}
+
+// globalsCompletion returns the keys of the global object at the given path.
+// The globals are evaluated for the directory of the file, so keys defined in
+// parent directories are also available.
+func (s *Server) globalsCompletion(fname string, path []string, partial string) []lsp.CompletionItem {
+	root, dir, ok := s.loadRoot(fname)
+	if !ok {
+		return nil
+	}
+
+	evalctx := eval.NewContext(stdlib.Functions(s.baseDir(fname)))
+	evalctx.SetNamespace("terramate", metadataFor(root, dir))
+	report := globals.ForDir(root, dir, evalctx)
+	return objectCompletion(report.Globals.AsValueMap(), path, partial)
+}
+
+// metadataCompletion returns the keys of the terramate metadata object at the
+// given path.
+func (s *Server) metadataCompletion(fname string, path []string, partial string) []lsp.CompletionItem {
+	root, dir, ok := s.loadRoot(fname)
+	if !ok {
+		return nil
+	}
+	return objectCompletion(metadataFor(root, dir), path, partial)
+}
+
+// metadataFor returns the terramate metadata available for the given dir.
+// If the dir is not a stack, the stack metadata is still provided because
+// files outside stacks (eg.: generate blocks) are evaluated for each child
+// stack.
+func metadataFor(root *config.Root, dir project.Path) map[string]cty.Value {
+	runtime := root.Runtime()
+	st, found, err := config.TryLoadStack(root, dir)
+	if err != nil || !found {
+		// only the keys matter for completion.
+		st = &config.Stack{Dir: dir, ID: "id", Name: path.Base(dir.String())}
+	}
+	runtime.Merge(st.RuntimeValues(root))
+	return runtime
+}
+
+func (s *Server) loadRoot(fname string) (*config.Root, project.Path, bool) {
+	dir := filepath.Dir(fname)
+	root, _, found, err := config.TryLoadConfig(dir)
+	if err != nil || !found {
+		s.log.Debug().
+			Err(err).
+			Str("file", fname).
+			Msg("unable to load project configuration for completion")
+		return nil, project.Path{}, false
+	}
+	return root, project.PrjAbsPath(root.HostDir(), dir), true
+}
+
+// baseDir returns the base directory used by functions dealing with paths.
+func (s *Server) baseDir(fname string) string {
+	dir := filepath.Dir(fname)
+	if st, err := os.Stat(dir); err == nil && st.IsDir() {
+		return dir
+	}
+	return s.workspace
+}
+
+func objectCompletion(ns map[string]cty.Value, path []string, partial string) []lsp.CompletionItem {
+	keys := ns
+	if len(path) > 0 {
+		val, ok := lookupValue(ns, path)
+		if !ok || !isObject(val) {
+			return nil
+		}
+		keys = val.AsValueMap()
+	}
+
+	var names []string
+	for name := range keys {
+		if strings.HasPrefix(name, partial) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var items []lsp.CompletionItem
+	for _, name := range names {
+		items = append(items, lsp.CompletionItem{
+			Label:  name,
+			Kind:   lsp.CompletionItemKindProperty,
+			Detail: keys[name].Type().FriendlyName(),
+		})
+	}
+	return items
+}
+
+// lookupValue returns the value at the given path of the namespace.
+func lookupValue(ns map[string]cty.Value, path []string) (cty.Value, bool) {
+	val, ok := ns[path[0]]
+	if !ok {
+		return cty.NilVal, false
+	}
+	for _, key := range path[1:] {
+		if !isObject(val) {
+			return cty.NilVal, false
+		}
+		val, ok = val.AsValueMap()[key]
+		if !ok {
+			return cty.NilVal, false
+		}
+	}
+	return val, true
+}
+
+func isObject(val cty.Value) bool {
+	return val.IsKnown() && !val.IsNull() &&
+		(val.Type().IsObjectType() || val.Type().IsMapType())
+}
+
+// cursorContext lexes the content before the cursor and returns the types of
+// the blocks enclosing it and if the cursor is inside an expression.
+func cursorContext(before string) (blocks []string, inExpr bool) {
+	type scope struct {
+		block   string
+		isBlock bool
+	}
+
+	tokens, _ := hclsyntax.LexConfig([]byte(before), "", hhcl.InitialPos)
+	var scopes []scope
+	lineStart := 0
+	for i, tok := range tokens {
+		switch tok.Type {
+		case hclsyntax.TokenNewline:
+			lineStart = i + 1
+		case hclsyntax.TokenOBrace:
+			if len(scopes) == 0 || scopes[len(scopes)-1].isBlock {
+				line := tokens[lineStart:i]
+				if len(line) > 0 && line[0].Type == hclsyntax.TokenIdent && !hasEqual(line) {
+					scopes = append(scopes, scope{
+						block:   string(line[0].Bytes),
+						isBlock: true,
+					})
+					lineStart = i + 1
+					continue
+				}
+			}
+			scopes = append(scopes, scope{})
+		case hclsyntax.TokenOBrack, hclsyntax.TokenOParen,
+			hclsyntax.TokenTemplateInterp, hclsyntax.TokenTemplateControl:
+			scopes = append(scopes, scope{})
+		case hclsyntax.TokenCBrace, hclsyntax.TokenCBrack, hclsyntax.TokenCParen,
+			hclsyntax.TokenTemplateSeqEnd:
+			if len(scopes) > 0 {
+				scopes = scopes[:len(scopes)-1]
+			}
+			if tok.Type == hclsyntax.TokenCBrace {
+				lineStart = i + 1
+			}
+		}
+	}
+
+	for _, s := range scopes {
+		if !s.isBlock {
+			return blocks, true
+		}
+		blocks = append(blocks, s.block)
+	}
+	if lineStart < len(tokens) && hasEqual(tokens[lineStart:]) {
+		return blocks, true
+	}
+	return blocks, false
+}
+
+func hasEqual(tokens hclsyntax.Tokens) bool {
+	for _, tok := range tokens {
+		if tok.Type == hclsyntax.TokenEqual {
+			return true
+		}
+	}
+	return false
+}
+
+// wordBefore returns the traversal (eg.: global.a.b) being typed at the end
+// of the given text.
+func wordBefore(text string) string {
+	start := len(text)
+	for start > 0 && (text[start-1] == '.' || isIdentChar(text[start-1])) {
+		start--
+	}
+	return text[start:]
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '-' ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// positionOffset converts the LSP position (line and UTF-16 character) into
+// a byte offset of content.
+func positionOffset(content string, pos lsp.Position) int {
+	offset := 0
+	for line := uint32(0); line < pos.Line; line++ {
+		i := strings.IndexByte(content[offset:], '\n')
+		if i == -1 {
+			return len(content)
+		}
+		offset += i + 1
+	}
+
+	var units uint32
+	for offset < len(content) && units < pos.Character {
+		r, size := utf8.DecodeRuneInString(content[offset:])
+		if r == '\n' {
+			break
+		}
+		units += uint32(len(utf16.Encode([]rune{r})))
+		offset += size
+	}
+	return offset
+}
+
+// offsetPosition converts the byte offset of content into a LSP position.
+func offsetPosition(content string, offset int) lsp.Position {
+	var pos lsp.Position
+	for _, r := range content[:offset] {
+		if r == '\n' {
+			pos.Line++
+			pos.Character = 0
+			continue
+		}
+		pos.Character += uint32(len(utf16.Encode([]rune{r})))
+	}
+	return pos
+}
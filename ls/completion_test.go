@@ -0,0 +1,112 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package tmls_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/madlambda/spells/assert"
+	lstest "github.com/terramate-io/terramate/test/ls"
+	lsp "go.lsp.dev/protocol"
+)
+
+func TestCompletion(t *testing.T) {
+	t.Parallel()
+
+	type position struct {
+		line, char uint32
+	}
+	type testcase struct {
+		name string
+		pos  position
+		want []string
+	}
+
+	f := lstest.Setup(t,
+		`s:stack:id=stack-id`,
+		`f:globals.tm:globals {
+  env = "prod"
+  obj = {
+    a = 1
+    b = 2
+  }
+}`,
+	)
+
+	const content = `generate_hcl "file.tf" {
+  con
+}
+
+globals {
+  a = global.o
+  b = global.obj.
+  c = terramate.stack.
+  d = tm_up
+}
+
+terramate {
+  config {
+    g
+  }
+}
+
+s`
+
+	f.Editor.CheckInitialize(f.Sandbox.RootDir())
+	f.Editor.Change("stack/edit.tm", content)
+
+	// diagnostics are sent for every file in the directory.
+	for i := 0; i < 2; i++ {
+		r := <-f.Editor.Requests
+		assert.EqualStrings(t, lsp.MethodTextDocumentPublishDiagnostics, r.Method())
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "generate_hcl attributes and blocks",
+			pos:  position{1, 5},
+			want: []string{"condition", "context", "content"},
+		},
+		{
+			name: "globals keys",
+			pos:  position{5, 14},
+			want: []string{"obj"},
+		},
+		{
+			name: "nested globals keys",
+			pos:  position{6, 17},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "stack metadata",
+			pos:  position{7, 22},
+			want: []string{"description", "id", "name", "path", "tags"},
+		},
+		{
+			name: "functions",
+			pos:  position{8, 11},
+			want: []string{"tm_upper"},
+		},
+		{
+			name: "terramate.config blocks",
+			pos:  position{13, 5},
+			want: []string{"git"},
+		},
+		{
+			name: "top level blocks",
+			pos:  position{17, 1},
+			want: []string{"script", "stack"},
+		},
+	} {
+		items := f.Editor.Completion("stack/edit.tm", tc.pos.line, tc.pos.char)
+		got := []string{}
+		for _, item := range items {
+			got = append(got, item.Label)
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("%s: completion mismatch (-want +got):\n%s", tc.name, diff)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package tmls
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/hcl/ast"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/terramate-io/terramate/stdlib"
+	"github.com/zclconf/go-cty/cty"
+	"go.lsp.dev/jsonrpc2"
+	lsp "go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+// globalRef is a reference to a global found in a document.
+type globalRef struct {
+	// path of the global, without the global namespace.
+	path []string
+
+	// rng is the range of the reference in the document.
+	rng lsp.Range
+
+	// value is the evaluated global, if it could be evaluated.
+	value    cty.Value
+	hasValue bool
+
+	// expr is the expression defining (or last overriding) the global.
+	expr    globals.Expr
+	hasExpr bool
+}
+
+func (s *Server) handleHover(
+	ctx context.Context,
+	reply jsonrpc2.Replier,
+	r jsonrpc2.Request,
+	log zerolog.Logger,
+) error {
+	var params lsp.HoverParams
+	if err := json.Unmarshal(r.Params(), &params); err != nil {
+		log.Error().Err(err).Msg("failed to unmarshal params")
+		return jsonrpc2.ErrParse
+	}
+
+	ref, ok := s.globalAt(params.TextDocument.URI.Filename(), params.Position)
+	if !ok || (!ref.hasValue && !ref.hasExpr) {
+		return reply(ctx, nil, nil)
+	}
+
+	var doc strings.Builder
+	if ref.hasValue {
+		fmt.Fprintf(&doc, "```hcl\nglobal.%s = %s\n```\n",
+			strings.Join(ref.path, "."), ast.TokensForValue(ref.value).Bytes())
+	}
+	if ref.hasExpr {
+		if ref.hasValue {
+			doc.WriteString("\n")
+		}
+		fmt.Fprintf(&doc, "Defined at `%s:%d`\n",
+			ref.expr.Origin.Path(), ref.expr.Origin.Start().Line())
+	}
+
+	return reply(ctx, lsp.Hover{
+		Contents: lsp.MarkupContent{
+			Kind:  lsp.Markdown,
+			Value: doc.String(),
+		},
+		Range: &ref.rng,
+	}, nil)
+}
+
+func (s *Server) handleDefinition(
+	ctx context.Context,
+	reply jsonrpc2.Replier,
+	r jsonrpc2.Request,
+	log zerolog.Logger,
+) error {
+	var params lsp.DefinitionParams
+	if err := json.Unmarshal(r.Params(), &params); err != nil {
+		log.Error().Err(err).Msg("failed to unmarshal params")
+		return jsonrpc2.ErrParse
+	}
+
+	ref, ok := s.globalAt(params.TextDocument.URI.Filename(), params.Position)
+	if !ok || !ref.hasExpr {
+		return reply(ctx, nil, nil)
+	}
+
+	return reply(ctx, lsp.Location{
+		URI:   uri.File(ref.expr.Origin.HostPath()),
+		Range: lspRange(ref.expr.Origin),
+	}, nil)
+}
+
+// globalAt looks for a global reference at the given position of the file.
+// The global is evaluated for the directory of the file and the path
+// considered is the one up to the attribute under the cursor.
+func (s *Server) globalAt(fname string, pos lsp.Position) (globalRef, bool) {
+	content, err := s.document(fname)
+	if err != nil {
+		s.log.Error().Err(err).Str("file", fname).Msg("reading document.")
+		return globalRef{}, false
+	}
+
+	offset := positionOffset(content, pos)
+	start := offset - len(wordBefore(content[:offset]))
+	end := offset
+	for end < len(content) && isIdentChar(content[end]) {
+		end++
+	}
+
+	parts := strings.Split(content[start:end], ".")
+	if len(parts) < 2 || parts[0] != "global" || parts[len(parts)-1] == "" {
+		return globalRef{}, false
+	}
+
+	root, dir, ok := s.loadRoot(fname)
+	if !ok {
+		return globalRef{}, false
+	}
+	tree, ok := root.Lookup(dir)
+	if !ok {
+		return globalRef{}, false
+	}
+
+	ref := globalRef{
+		path: parts[1:],
+		rng: lsp.Range{
+			Start: offsetPosition(content, start),
+			End:   offsetPosition(content, end),
+		},
+	}
+
+	exprs, err := globals.LoadExprs(tree)
+	if err != nil {
+		s.log.Debug().Err(err).Msg("loading globals expressions.")
+		return globalRef{}, false
+	}
+	ref.expr, ref.hasExpr = exprs.Lookup(ref.path)
+
+	evalctx := eval.NewContext(stdlib.Functions(s.baseDir(fname)))
+	evalctx.SetNamespace("terramate", metadataFor(root, dir))
+	report := globals.ForDir(root, dir, evalctx)
+	ref.value, ref.hasValue = lookupValue(report.Globals.AsValueMap(), ref.path)
+	return ref, true
+}
+
+func lspRange(r info.Range) lsp.Range {
+	return lsp.Range{
+		Start: lsp.Position{
+			Line:      uint32(r.Start().Line()) - 1,
+			Character: uint32(r.Start().Column()) - 1,
+		},
+		End: lsp.Position{
+			Line:      uint32(r.End().Line()) - 1,
+			Character: uint32(r.End().Column()) - 1,
+		},
+	}
+}
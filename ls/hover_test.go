@@ -0,0 +1,81 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package tmls_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/madlambda/spells/assert"
+	lstest "github.com/terramate-io/terramate/test/ls"
+	lsp "go.lsp.dev/protocol"
+)
+
+func TestHoverAndDefinitionOfGlobals(t *testing.T) {
+	t.Parallel()
+
+	f := lstest.Setup(t,
+		`s:stack`,
+		`f:globals.tm:globals {
+  env = "prod"
+  obj = {
+    a = 1
+  }
+}`,
+		`f:stack/globals.tm:globals {
+  env = "dev"
+}`,
+	)
+
+	const content = `generate_hcl "file.tf" {
+  content {
+    env = global.env
+    a   = global.obj.a
+  }
+}`
+
+	f.Editor.CheckInitialize(f.Sandbox.RootDir())
+	f.Editor.Change("stack/edit.tm", content)
+
+	// diagnostics are sent for every file in the directory.
+	for i := 0; i < 3; i++ {
+		r := <-f.Editor.Requests
+		assert.EqualStrings(t, lsp.MethodTextDocumentPublishDiagnostics, r.Method())
+	}
+
+	hover := f.Editor.Hover("stack/edit.tm", 2, 18)
+	if hover == nil {
+		t.Fatal("expected hover info for global.env")
+	}
+	assert.EqualStrings(t, "```hcl\nglobal.env = \"dev\"\n```\n\nDefined at `/stack/globals.tm:2`\n",
+		hover.Contents.Value)
+	want := &lsp.Range{
+		Start: lsp.Position{Line: 2, Character: 10},
+		End:   lsp.Position{Line: 2, Character: 20},
+	}
+	if diff := cmp.Diff(want, hover.Range); diff != "" {
+		t.Fatalf("hover range mismatch (-want +got):\n%s", diff)
+	}
+
+	hover = f.Editor.Hover("stack/edit.tm", 3, 21)
+	if hover == nil {
+		t.Fatal("expected hover info for global.obj.a")
+	}
+	assert.EqualStrings(t, "```hcl\nglobal.obj.a = 1\n```\n\nDefined at `/globals.tm:3`\n",
+		hover.Contents.Value)
+
+	loc := f.Editor.Definition("stack/edit.tm", 2, 18)
+	if loc == nil {
+		t.Fatal("expected definition of global.env")
+	}
+	assert.EqualStrings(t, filepath.Join(f.Sandbox.RootDir(), "stack", "globals.tm"),
+		loc.URI.Filename())
+	assert.EqualInts(t, 1, int(loc.Range.Start.Line))
+	assert.EqualInts(t, 2, int(loc.Range.Start.Character))
+
+	if hover := f.Editor.Hover("stack/edit.tm", 0, 2); hover != nil {
+		t.Fatalf("unexpected hover info outside globals: %v", hover.Contents.Value)
+	}
+}
@@ -29,6 +29,10 @@ type Server struct {
 	workspace string
 	handlers  handlers
 
+	// documents holds the last content of the opened documents, as sent by
+	// the editor. It may differ from the content of the file on disk.
+	documents map[string]string
+
 	log zerolog.Logger
 }
 
@@ -50,8 +54,9 @@ func NewServer(conn jsonrpc2.Conn) *Server {
 // ServerWithLogger creates a new language server with a custom logger.
 func ServerWithLogger(conn jsonrpc2.Conn, l zerolog.Logger) *Server {
 	s := &Server{
-		conn: conn,
-		log:  l,
+		conn:      conn,
+		log:       l,
+		documents: map[string]string{},
 	}
 	s.buildHandlers()
 	return s
@@ -64,7 +69,10 @@ func (s *Server) buildHandlers() {
 		lsp.MethodTextDocumentDidOpen:    s.handleDocumentOpen,
 		lsp.MethodTextDocumentDidChange:  s.handleDocumentChange,
 		lsp.MethodTextDocumentDidSave:    s.handleDocumentSaved,
+		lsp.MethodTextDocumentDidClose:   s.handleDocumentClose,
 		lsp.MethodTextDocumentCompletion: s.handleCompletion,
+		lsp.MethodTextDocumentHover:      s.handleHover,
+		lsp.MethodTextDocumentDefinition: s.handleDefinition,
 	}
 }
 
@@ -110,13 +118,16 @@ func (s *Server) handleInitialize(
 	s.workspace = string(uri.New(params.RootURI).Filename())
 	err := reply(ctx, lsp.InitializeResult{
 		Capabilities: lsp.ServerCapabilities{
-			CompletionProvider: &lsp.CompletionOptions{},
+			CompletionProvider: &lsp.CompletionOptions{
+				// complete globals and metadata attributes as they are typed.
+				TriggerCharacters: []string{"."},
+			},
 
 			// if we support `goto` definition.
-			DefinitionProvider: false,
+			DefinitionProvider: true,
 
 			// If we support `hover` info.
-			HoverProvider: false,
+			HoverProvider: true,
 
 			TextDocumentSync: lsp.TextDocumentSyncOptions{
 				// Send all file content on every change (can be optimized later).
@@ -174,6 +185,7 @@ func (s *Server) handleDocumentOpen(
 
 	fname := params.TextDocument.URI.Filename()
 	content := params.TextDocument.Text
+	s.documents[fname] = content
 
 	return s.checkAndReply(ctx, reply, fname, content)
 }
@@ -198,6 +210,7 @@ func (s *Server) handleDocumentChange(
 
 	content := params.ContentChanges[0].Text
 	fname := params.TextDocument.URI.Filename()
+	s.documents[fname] = content
 
 	return s.checkAndReply(ctx, reply, fname, content)
 }
@@ -220,10 +233,29 @@ func (s *Server) handleDocumentSaved(
 		log.Error().Err(err).Msg("reading saved file.")
 		return nil
 	}
+	s.documents[fname] = string(content)
 
 	return s.checkAndReply(ctx, reply, fname, string(content))
 }
 
+func (s *Server) handleDocumentClose(
+	ctx context.Context,
+	reply jsonrpc2.Replier,
+	r jsonrpc2.Request,
+	log zerolog.Logger,
+) error {
+	var params lsp.DidCloseTextDocumentParams
+	if err := json.Unmarshal(r.Params(), &params); err != nil {
+		log.Error().Err(err).Msg("failed to unmarshal params")
+		return jsonrpc2.ErrParse
+	}
+
+	// the editor discards the unsaved content of closed documents, so the
+	// file content is used from now on.
+	delete(s.documents, params.TextDocument.URI.Filename())
+	return reply(ctx, nil, nil)
+}
+
 // sendErrorDiagnostics sends diagnostics for each provided file, the ones with
 // no reported error gets an empty list of diagnostics, so the editor can clean
 // up its problems panel for it.
@@ -290,8 +322,34 @@ func (s *Server) handleCompletion(
 		log.Error().Err(err).Msg("failed to unmarshal params")
 		return jsonrpc2.ErrParse
 	}
-	log.Debug().Str("params", string(r.Params()))
-	return reply(ctx, nil, nil)
+
+	fname := params.TextDocument.URI.Filename()
+	content, err := s.document(fname)
+	if err != nil {
+		log.Error().Err(err).Msg("reading file for completion.")
+		return reply(ctx, nil, nil)
+	}
+
+	items := s.completionItems(fname, content, params.Position)
+	if items == nil {
+		items = []lsp.CompletionItem{}
+	}
+	return reply(ctx, lsp.CompletionList{
+		IsIncomplete: false,
+		Items:        items,
+	}, nil)
+}
+
+// document returns the content of the file, as seen by the editor.
+func (s *Server) document(fname string) (string, error) {
+	if content, ok := s.documents[fname]; ok {
+		return content, nil
+	}
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
 }
 
 func (s *Server) sendDiagnostics(ctx context.Context, uri lsp.URI, diags []lsp.Diagnostic) {
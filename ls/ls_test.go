@@ -43,6 +43,30 @@ func TestDocumentOpen(t *testing.T) {
 		params.URI.Filename())
 }
 
+func TestDocumentCloseDiscardsUnsavedContent(t *testing.T) {
+	t.Parallel()
+	f := lstest.Setup(t, "s:stack")
+
+	f.Sandbox.RootEntry().CreateFile("stack/edit.tm", "globals {\n  a = 1\n}\n")
+
+	f.Editor.CheckInitialize(f.Sandbox.RootDir())
+	f.Editor.Change("stack/edit.tm", "globals {\n  a = terramate.\n}\n")
+
+	// diagnostics are sent for every file in the directory.
+	for i := 0; i < 2; i++ {
+		r := <-f.Editor.Requests
+		assert.EqualStrings(t, lsp.MethodTextDocumentPublishDiagnostics, r.Method())
+	}
+
+	items := f.Editor.Completion("stack/edit.tm", 1, 16)
+	assert.IsTrue(t, len(items) > 0, "want completion of the unsaved content")
+
+	f.Editor.Close("stack/edit.tm")
+
+	items = f.Editor.Completion("stack/edit.tm", 1, 16)
+	assert.EqualInts(t, 0, len(items), "want completion of the file content: %v", items)
+}
+
 func TestDocumentChange(t *testing.T) {
 	t.Skip("not ready")
 
@@ -0,0 +1,67 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package mapexpr
+
+import (
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// DefaultIterator is the name of the iterator variable when no iterator
+// attribute is defined.
+const DefaultIterator = "element"
+
+// ParseIterator parses the iterator attribute, returning the name of the
+// iterator variable.
+func ParseIterator(attr *hhcl.Attribute) (string, error) {
+	iteratorTraversal, diags := hhcl.AbsTraversalForExpr(attr.Expr)
+	if diags.HasErrors() {
+		return "", errors.E(diags)
+	}
+	if len(iteratorTraversal) != 1 {
+		return "", errors.E(
+			attr.Range,
+			"dynamic iterator must be a single variable name",
+		)
+	}
+	return iteratorTraversal.RootName(), nil
+}
+
+// ForEach evaluates the forEach expression and calls fn for each one of its
+// elements, in the same order as the `map` block iterates them. The evaluation
+// context given to fn is a copy of evalctx with the iterator variable defined
+// as an object with the key and value of the element.
+//
+// The iteration stops on the first error returned by fn.
+func ForEach(
+	evalctx *eval.Context,
+	forEach hhcl.Expression,
+	iterator string,
+	fn func(evalctx *eval.Context) error,
+) error {
+	foreach, err := evalctx.Eval(forEach)
+	if err != nil {
+		return errors.E(err, "evaluating `for_each` expression")
+	}
+
+	if !foreach.IsKnown() || foreach.IsNull() || !foreach.CanIterateElements() {
+		return errors.E(forEach.Range(),
+			"`for_each` expression of type %s cannot be iterated",
+			foreach.Type().FriendlyName())
+	}
+
+	var iterErr error
+	foreach.ForEachElement(func(key, value cty.Value) (stop bool) {
+		elemctx := evalctx.Copy()
+		elemctx.SetNamespace(iterator, map[string]cty.Value{
+			"key":   key,
+			"value": value,
+		})
+		iterErr = fn(elemctx)
+		return iterErr != nil
+	})
+	return iterErr
+}
@@ -53,19 +53,13 @@ func NewMapExpr(block *ast.MergedBlock) (*MapExpr, error) {
 		}
 	}
 
-	iterator := "element"
+	iterator := DefaultIterator
 	if it, ok := block.Attributes["iterator"]; ok {
-		iteratorTraversal, diags := hhcl.AbsTraversalForExpr(it.Expr)
-		if diags.HasErrors() {
-			return nil, errors.E(diags)
-		}
-		if len(iteratorTraversal) != 1 {
-			return nil, errors.E(
-				it.Range,
-				"dynamic iterator must be a single variable name",
-			)
+		var err error
+		iterator, err = ParseIterator(it.Attribute)
+		if err != nil {
+			return nil, err
 		}
-		iterator = iteratorTraversal.RootName()
 	}
 
 	var valueExpr hhcl.Expression
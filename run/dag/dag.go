@@ -25,9 +25,18 @@ type (
 		values map[ID]interface{}
 		cycles map[ID]bool
 
+		// declaredBy is a map of edge -> []ID of the nodes declaring it.
+		declaredBy map[edge][]ID
+
 		validated bool
 	}
 
+	// edge is a descendant -> ancestor edge.
+	edge struct {
+		descendant ID
+		ancestor   ID
+	}
+
 	// Visited in a map of visited dag nodes by id.
 	// Note: it's not concurrent-safe.
 	Visited map[ID]struct{}
@@ -43,8 +52,9 @@ const (
 // New creates a new empty Directed-Acyclic-Graph.
 func New() *DAG {
 	return &DAG{
-		dag:    make(map[ID][]ID),
-		values: make(map[ID]interface{}),
+		dag:        make(map[ID][]ID),
+		values:     make(map[ID]interface{}),
+		declaredBy: make(map[edge][]ID),
 	}
 }
 
@@ -64,26 +74,22 @@ func (d *DAG) AddNode(id ID, value interface{}, descendants, ancestors []ID) err
 			d.dag[bid] = []ID{}
 		}
 
-		d.addAncestor(bid, id)
+		d.addAncestor(bid, id, id)
 	}
 
 	if _, ok := d.dag[id]; !ok {
 		d.dag[id] = []ID{}
 	}
 
-	d.addAncestors(id, ancestors)
+	for _, ancestor := range ancestors {
+		d.addAncestor(id, ancestor, id)
+	}
 	d.values[id] = value
 	d.validated = false
 	return nil
 }
 
-func (d *DAG) addAncestors(node ID, ancestorIDs []ID) {
-	for _, ancestor := range ancestorIDs {
-		d.addAncestor(node, ancestor)
-	}
-}
-
-func (d *DAG) addAncestor(node, ancestor ID) {
+func (d *DAG) addAncestor(node, ancestor, declarer ID) {
 	nodeAncestors, ok := d.dag[node]
 	if !ok {
 		panic("internal error: empty list of edges must exist at this point")
@@ -94,6 +100,11 @@ func (d *DAG) addAncestor(node, ancestor ID) {
 	}
 
 	d.dag[node] = nodeAncestors
+
+	e := edge{descendant: node, ancestor: ancestor}
+	if !idList(d.declaredBy[e]).contains(declarer) {
+		d.declaredBy[e] = append(d.declaredBy[e], declarer)
+	}
 }
 
 // Validate the DAG looking for cycles.
@@ -167,6 +178,29 @@ func (d *DAG) AncestorsOf(id ID) []ID {
 	return d.dag[id]
 }
 
+// DescendantsOf returns the sorted list of node ids having the given id as
+// ancestor.
+func (d *DAG) DescendantsOf(id ID) []ID {
+	descendants := idList{}
+	for nodeID, ancestors := range d.dag {
+		if idList(ancestors).contains(id) {
+			descendants = append(descendants, nodeID)
+		}
+	}
+	sort.Sort(descendants)
+	return descendants
+}
+
+// DeclaredBy returns the sorted list of node ids which declared the edge from
+// the descendant to the ancestor: the descendant, if it declared the ancestor
+// as one of its ancestors, and the ancestor, if it declared the descendant as
+// one of its descendants.
+func (d *DAG) DeclaredBy(descendant, ancestor ID) []ID {
+	declarers := append(idList{}, d.declaredBy[edge{descendant: descendant, ancestor: ancestor}]...)
+	sort.Sort(declarers)
+	return declarers
+}
+
 // HasCycle returns true if the DAG has a cycle.
 func (d *DAG) HasCycle(id ID) bool {
 	if !d.validated {
@@ -299,6 +299,33 @@ func TestValidatedDAG(t *testing.T) {
 	}
 }
 
+func TestDescendantsOf(t *testing.T) {
+	d := dag.New()
+	assert.NoError(t, d.AddNode("A", nil, []dag.ID{"C", "B"}, nil))
+	assert.NoError(t, d.AddNode("B", nil, nil, nil))
+	assert.NoError(t, d.AddNode("C", nil, nil, []dag.ID{"B"}))
+	assert.NoError(t, d.AddNode("D", nil, nil, []dag.ID{"C"}))
+
+	assertOrder(t, []dag.ID{"B", "C"}, d.DescendantsOf("A"))
+	assertOrder(t, []dag.ID{"C"}, d.DescendantsOf("B"))
+	assertOrder(t, []dag.ID{"D"}, d.DescendantsOf("C"))
+	assertOrder(t, []dag.ID{}, d.DescendantsOf("D"))
+	assertOrder(t, []dag.ID{}, d.DescendantsOf("unknown"))
+}
+
+func TestDeclaredBy(t *testing.T) {
+	d := dag.New()
+	assert.NoError(t, d.AddNode("A", nil, []dag.ID{"B", "C"}, nil))
+	assert.NoError(t, d.AddNode("B", nil, nil, []dag.ID{"A"}))
+	assert.NoError(t, d.AddNode("C", nil, nil, nil))
+	assert.NoError(t, d.AddNode("D", nil, nil, []dag.ID{"C"}))
+
+	assertOrder(t, []dag.ID{"A", "B"}, d.DeclaredBy("B", "A"))
+	assertOrder(t, []dag.ID{"A"}, d.DeclaredBy("C", "A"))
+	assertOrder(t, []dag.ID{"D"}, d.DeclaredBy("D", "C"))
+	assertOrder(t, []dag.ID{}, d.DeclaredBy("A", "D"))
+}
+
 func assertOrder(t *testing.T, want, got []dag.ID) {
 	t.Helper()
 	assert.EqualInts(t, len(want), len(got), "length mismatch")
@@ -55,9 +55,7 @@ func LoadEnv(root *config.Root, st *config.Stack) (EnvVars, error) {
 	}
 
 	evalctx := eval.NewContext(stdlib.Functions(st.HostDir(root)))
-	runtime := root.Runtime()
-	runtime.Merge(st.RuntimeValues(root))
-	evalctx.SetNamespace("terramate", runtime)
+	evalctx.SetNamespace("terramate", globals.StackRuntime(root, st))
 	evalctx.SetNamespace("global", globalsReport.Globals.AsValueMap())
 	evalctx.SetEnv(os.Environ())
 
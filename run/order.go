@@ -14,6 +14,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/terramate-io/terramate/config"
 	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/project"
 	"github.com/terramate-io/terramate/run/dag"
 )
 
@@ -21,10 +22,26 @@ import (
 // In the case of multiple possible orders, it returns the lexicographic sorted
 // path.
 func Sort(root *config.Root, stacks config.List[*config.SortableStack]) (config.List[*config.SortableStack], string, error) {
+	d, reason, err := BuildOrderDAG(root, stacks)
+	if err != nil {
+		return nil, reason, err
+	}
+	orderedStacks, err := SortDAG(d, stacks)
+	if err != nil {
+		return nil, "", err
+	}
+	return orderedStacks, "", nil
+}
+
+// BuildOrderDAG builds and validates the run order DAG of the given list of
+// stacks. Besides the stack's before and after fields, a parent stack is always
+// ordered before its child stacks.
+// If a cycle is detected, the returned reason describes it.
+func BuildOrderDAG(root *config.Root, stacks config.List[*config.SortableStack]) (*dag.DAG, string, error) {
 	d := dag.New()
 
 	logger := log.With().
-		Str("action", "run.Sort()").
+		Str("action", "run.BuildOrderDAG()").
 		Str("root", root.HostDir()).
 		Logger()
 
@@ -77,7 +94,12 @@ func Sort(root *config.Root, stacks config.List[*config.SortableStack]) (config.
 	if err != nil {
 		return nil, reason, err
 	}
+	return d, "", nil
+}
 
+// SortDAG returns the given stacks in the topological order of the DAG.
+// The DAG must be built by [BuildOrderDAG] from the same list of stacks.
+func SortDAG(d *dag.DAG, stacks config.List[*config.SortableStack]) (config.List[*config.SortableStack], error) {
 	order := d.Order()
 
 	orderedStacks := make(config.List[*config.SortableStack], 0, len(order))
@@ -99,7 +121,7 @@ func Sort(root *config.Root, stacks config.List[*config.SortableStack]) (config.
 	for _, id := range order {
 		val, err := d.Node(id)
 		if err != nil {
-			return nil, "", fmt.Errorf("calculating run-order: %w", err)
+			return nil, fmt.Errorf("calculating run-order: %w", err)
 		}
 		s := val.(*config.Stack)
 		if !isSelectedStack(s) {
@@ -108,7 +130,130 @@ func Sort(root *config.Root, stacks config.List[*config.SortableStack]) (config.
 		orderedStacks = append(orderedStacks, s.Sortable())
 	}
 
-	return orderedStacks, "", nil
+	return orderedStacks, nil
+}
+
+// Dependencies computes, for each of the given stacks, the list of stacks from
+// the same list that must finish executing before it can start.
+// Stacks present in the DAG but not in the list are traversed transparently,
+// so the ordering constraints they impose are kept between the listed stacks.
+// The returned lists are lexicographically sorted.
+func Dependencies(d *dag.DAG, stacks config.List[*config.SortableStack]) map[project.Path]project.Paths {
+	selected := map[dag.ID]struct{}{}
+	for _, st := range stacks {
+		selected[dag.ID(st.Dir().String())] = struct{}{}
+	}
+
+	deps := map[project.Path]project.Paths{}
+	for _, st := range stacks {
+		found := map[dag.ID]struct{}{}
+		visited := dag.Visited{}
+
+		var walk func(id dag.ID)
+		walk = func(id dag.ID) {
+			for _, ancestor := range d.AncestorsOf(id) {
+				if _, ok := visited[ancestor]; ok {
+					continue
+				}
+				visited[ancestor] = struct{}{}
+
+				if _, ok := selected[ancestor]; ok {
+					found[ancestor] = struct{}{}
+					continue
+				}
+				walk(ancestor)
+			}
+		}
+
+		walk(dag.ID(st.Dir().String()))
+
+		stackDeps := make(project.Paths, 0, len(found))
+		for id := range found {
+			stackDeps = append(stackDeps, project.NewPath(string(id)))
+		}
+		stackDeps.Sort()
+		deps[st.Dir()] = stackDeps
+	}
+	return deps
+}
+
+// Kinds of the edges of the run order DAG.
+const (
+	// EdgeParent orders a parent stack before its child stacks.
+	EdgeParent = "parent"
+
+	// EdgeAfter is declared by the stack.after field of the stack ordered
+	// after.
+	EdgeAfter = "after"
+
+	// EdgeBefore is declared by the stack.before field of the stack ordered
+	// before.
+	EdgeBefore = "before"
+)
+
+// OrderEdge is a direct edge of the run order DAG.
+type OrderEdge struct {
+	// Stack is the other stack of the edge.
+	Stack project.Path
+
+	// Kinds are the kinds of the edge: [EdgeParent], [EdgeAfter] and/or
+	// [EdgeBefore].
+	Kinds []string
+}
+
+// OrderEdges computes, for each of the given stacks, the direct edges of the
+// DAG ordering other stacks right before it, including stacks not present in
+// the list. If reverse is true, the edges ordering other stacks right after
+// each stack are returned instead, as needed by a reversed execution.
+// The DAG must be built by [BuildOrderDAG] and the returned edges are
+// lexicographically sorted by stack.
+func OrderEdges(d *dag.DAG, stacks config.List[*config.SortableStack], reverse bool) map[project.Path][]OrderEdge {
+	edges := map[project.Path][]OrderEdge{}
+	for _, st := range stacks {
+		id := dag.ID(st.Dir().String())
+		others := append([]dag.ID{}, d.AncestorsOf(id)...)
+		if reverse {
+			others = d.DescendantsOf(id)
+		}
+		sort.Slice(others, func(i, j int) bool { return others[i] < others[j] })
+
+		stackEdges := make([]OrderEdge, 0, len(others))
+		for _, other := range others {
+			descendant, ancestor := id, other
+			if reverse {
+				descendant, ancestor = other, id
+			}
+
+			isParent := project.NewPath(string(descendant)).HasPrefix(string(ancestor) + "/")
+			var isAfter, isBefore bool
+			for _, declarer := range d.DeclaredBy(descendant, ancestor) {
+				if declarer == descendant {
+					isAfter = true
+				} else {
+					isBefore = true
+				}
+			}
+
+			var kinds []string
+			if isParent {
+				kinds = append(kinds, EdgeParent)
+			}
+			if isAfter {
+				kinds = append(kinds, EdgeAfter)
+			}
+			// the parent edge is declared in the before of the parent stack.
+			if isBefore && !isParent {
+				kinds = append(kinds, EdgeBefore)
+			}
+
+			stackEdges = append(stackEdges, OrderEdge{
+				Stack: project.NewPath(string(other)),
+				Kinds: kinds,
+			})
+		}
+		edges[st.Dir()] = stackEdges
+	}
+	return edges
 }
 
 // BuildDAG builds a run order DAG for the given stack.
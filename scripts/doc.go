@@ -0,0 +1,6 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+// Package scripts provides functions for looking up and evaluating the script
+// blocks available to stacks.
+package scripts
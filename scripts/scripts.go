@@ -0,0 +1,258 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package scripts
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/stdlib"
+	"github.com/zclconf/go-cty/cty"
+	"golang.org/x/exp/slices"
+)
+
+// Errors returned when evaluating scripts.
+const (
+	// ErrEval indicates that an error happened while evaluating one of the
+	// script attributes.
+	ErrEval errors.Kind = "evaluating script"
+
+	// ErrInvalidCommand indicates that a script command evaluated to an
+	// invalid value.
+	ErrInvalidCommand errors.Kind = "invalid script command"
+)
+
+// Script is a script evaluated in the context of a stack.
+type Script struct {
+	// Labels of the script block.
+	Labels []string
+
+	// Description is the evaluated description of the script.
+	Description string
+
+	// Jobs are the evaluated jobs, in execution order.
+	Jobs []Job
+}
+
+// Job is an evaluated script job.
+type Job struct {
+	// Cmds is the list of commands of the job, in execution order.
+	// Each command is a list of arguments where the first is the program.
+	Cmds [][]string
+}
+
+// Definition is a script block together with the directory defining it.
+type Definition struct {
+	// Dir is the directory where the script is defined.
+	Dir project.Path
+
+	// Script is the parsed script block.
+	Script *hcl.Script
+}
+
+// Name returns the name of the script, which is its labels joined by spaces.
+func (s Script) Name() string {
+	return strings.Join(s.Labels, " ")
+}
+
+// Name returns the name of the script, which is its labels joined by spaces.
+func (d Definition) Name() string {
+	return strings.Join(d.Script.Labels, " ")
+}
+
+// Description evaluates the script description in the context of the
+// directory defining it, which only has access to the project metadata.
+func (d Definition) Description(root *config.Root) (string, error) {
+	evalctx := eval.NewContext(stdlib.Functions(d.Dir.HostPath(root.HostDir())))
+	evalctx.SetNamespace("terramate", root.Runtime())
+	return evalDescription(evalctx, d.Script)
+}
+
+// Definitions returns the scripts defined in the project, one for each unique
+// label path, sorted by name. When the same labels are defined in multiple
+// directories, the definition closest to the project root is returned.
+func Definitions(root *config.Root) []Definition {
+	var defs []Definition
+	for _, tree := range root.Tree().AsList() {
+		for _, script := range tree.Node.Scripts {
+			defs = append(defs, Definition{
+				Dir:    tree.Dir(),
+				Script: script,
+			})
+		}
+	}
+
+	sort.SliceStable(defs, func(i, j int) bool {
+		if defs[i].Name() != defs[j].Name() {
+			return defs[i].Name() < defs[j].Name()
+		}
+		return defs[i].Dir.String() < defs[j].Dir.String()
+	})
+
+	var unique []Definition
+	for _, def := range defs {
+		if len(unique) > 0 && slices.Equal(unique[len(unique)-1].Script.Labels, def.Script.Labels) {
+			continue
+		}
+		unique = append(unique, def)
+	}
+	return unique
+}
+
+// Lookup looks for the script with the given labels, starting at the
+// configuration of dir and going up the directory hierarchy until the project
+// root is reached. The closest definition takes precedence.
+// It returns the script and the directory defining it, if found.
+func Lookup(root *config.Root, dir project.Path, labels []string) (*hcl.Script, project.Path, bool) {
+	tree, ok := root.Lookup(dir)
+	if !ok {
+		return nil, project.Path{}, false
+	}
+
+	for ; tree != nil; tree = tree.Parent {
+		for _, script := range tree.Node.Scripts {
+			if slices.Equal(script.Labels, labels) {
+				return script, tree.Dir(), true
+			}
+		}
+	}
+	return nil, project.Path{}, false
+}
+
+// Eval evaluates the script description and jobs using the given evaluation
+// context.
+func Eval(evalctx *eval.Context, script *hcl.Script) (Script, error) {
+	evaluated := Script{
+		Labels: script.Labels,
+	}
+
+	desc, err := evalDescription(evalctx, script)
+	if err != nil {
+		return Script{}, err
+	}
+	evaluated.Description = desc
+
+	for _, job := range script.Jobs {
+		var cmds [][]string
+		if job.Command != nil {
+			cmd, err := evalCommand(evalctx, job.Command.Expr, job.Command.Range)
+			if err != nil {
+				return Script{}, err
+			}
+			cmds = append(cmds, cmd)
+		} else {
+			cmds, err = evalCommands(evalctx, job.Commands.Expr, job.Commands.Range)
+			if err != nil {
+				return Script{}, err
+			}
+		}
+		evaluated.Jobs = append(evaluated.Jobs, Job{Cmds: cmds})
+	}
+
+	return evaluated, nil
+}
+
+// EvalStack evaluates the script in the context of the given stack, which
+// gives access to the stack globals, metadata and environment variables.
+func EvalStack(root *config.Root, st *config.Stack, script *hcl.Script) (Script, error) {
+	globalsReport := globals.ForStack(root, st)
+	if err := globalsReport.AsError(); err != nil {
+		return Script{}, errors.E(ErrEval, err, "loading globals of stack %s", st.Dir)
+	}
+
+	evalctx := eval.NewContext(stdlib.Functions(st.HostDir(root)))
+	evalctx.SetNamespace("terramate", globals.StackRuntime(root, st))
+	evalctx.SetNamespace("global", globalsReport.Globals.AsValueMap())
+	evalctx.SetEnv(os.Environ())
+
+	return Eval(evalctx, script)
+}
+
+func evalDescription(evalctx *eval.Context, script *hcl.Script) (string, error) {
+	val, err := evalctx.Eval(script.Description.Expr)
+	if err != nil {
+		return "", errors.E(ErrEval, err, "evaluating script description")
+	}
+	if val.IsNull() || val.Type() != cty.String {
+		return "", errors.E(ErrEval, script.Description.Range,
+			"script description must be a string but has type %s",
+			val.Type().FriendlyName())
+	}
+	return val.AsString(), nil
+}
+
+func evalCommand(evalctx *eval.Context, expr hhcl.Expression, rng info.Range) ([]string, error) {
+	val, err := evalctx.Eval(expr)
+	if err != nil {
+		return nil, errors.E(ErrEval, err, "evaluating script command")
+	}
+	return commandFromValue(val, rng)
+}
+
+func evalCommands(evalctx *eval.Context, expr hhcl.Expression, rng info.Range) ([][]string, error) {
+	val, err := evalctx.Eval(expr)
+	if err != nil {
+		return nil, errors.E(ErrEval, err, "evaluating script commands")
+	}
+
+	if val.IsNull() || !isListValue(val) {
+		return nil, errors.E(ErrInvalidCommand, rng,
+			"commands must be a list of commands but has type %s",
+			val.Type().FriendlyName())
+	}
+
+	if val.LengthInt() == 0 {
+		return nil, errors.E(ErrInvalidCommand, rng, "commands must not be empty")
+	}
+
+	var cmds [][]string
+	it := val.ElementIterator()
+	for it.Next() {
+		_, elem := it.Element()
+		cmd, err := commandFromValue(elem, rng)
+		if err != nil {
+			return nil, err
+		}
+		cmds = append(cmds, cmd)
+	}
+	return cmds, nil
+}
+
+func commandFromValue(val cty.Value, rng info.Range) ([]string, error) {
+	if val.IsNull() || !isListValue(val) {
+		return nil, errors.E(ErrInvalidCommand, rng,
+			"command must be a list of strings but has type %s",
+			val.Type().FriendlyName())
+	}
+
+	if val.LengthInt() == 0 {
+		return nil, errors.E(ErrInvalidCommand, rng, "command must not be empty")
+	}
+
+	var args []string
+	it := val.ElementIterator()
+	for it.Next() {
+		_, arg := it.Element()
+		if arg.IsNull() || arg.Type() != cty.String {
+			return nil, errors.E(ErrInvalidCommand, rng,
+				"command arguments must be strings but found %s",
+				arg.Type().FriendlyName())
+		}
+		args = append(args, arg.AsString())
+	}
+	return args, nil
+}
+
+func isListValue(val cty.Value) bool {
+	return val.Type().IsListType() || val.Type().IsTupleType()
+}
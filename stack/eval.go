@@ -5,6 +5,7 @@ package stack
 
 import (
 	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/globals"
 	"github.com/terramate-io/terramate/hcl/eval"
 	"github.com/terramate-io/terramate/stdlib"
 )
@@ -35,7 +36,5 @@ func (e *EvalCtx) SetGlobals(g *eval.Object) {
 
 // SetMetadata sets the given metadata in the stack evaluation context.
 func (e *EvalCtx) SetMetadata(st *config.Stack) {
-	runtime := e.root.Runtime()
-	runtime.Merge(st.RuntimeValues(e.root))
-	e.SetNamespace("terramate", runtime)
+	e.SetNamespace("terramate", globals.StackRuntime(e.root, st))
 }
@@ -12,6 +12,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
 	"github.com/rs/zerolog/log"
 	"github.com/terramate-io/terramate/config"
 	"github.com/terramate-io/terramate/errors"
@@ -30,6 +31,17 @@ type (
 		gitBaseRef string       // gitBaseRef is the git ref where we compare changes.
 
 		outerGit *git.Git
+
+		// realRootDir is the root dir with symlinks resolved.
+		realRootDir string
+
+		// changedFiles is the set of changed files (real host paths) computed
+		// by ListChanged. It's used to avoid inspecting unchanged files.
+		changedFiles map[string]struct{}
+
+		// symlinks are the symlinks of the project tracked by git, computed
+		// by ListChanged if there are changed files.
+		symlinks project.Paths
 	}
 
 	// Report is the report of project's stacks and the result of its default checks.
@@ -50,7 +62,56 @@ type (
 	Entry struct {
 		Stack  *config.Stack
 		Reason string // Reason why this entry was returned.
+
+		// Change is the structured reason of why the stack has changed.
+		// It is only set for entries returned by [Manager.ListChanged].
+		Change Change
+	}
+
+	// Change describes why a stack was detected as changed.
+	Change struct {
+		// Kind of the change.
+		Kind ChangeKind
+
+		// Paths are the offending project paths. Depending on the kind they
+		// are the changed files, the chain of changed modules, the trigger
+		// file or the watched file.
+		Paths project.Paths
+
+		// TriggerReason is the reason given when the stack was triggered.
+		TriggerReason string
 	}
+
+	// ChangeKind is the kind of change detected in a stack.
+	ChangeKind string
+)
+
+// Kinds of change detected in stacks.
+const (
+	// ChangeFiles means that files inside the stack directory changed.
+	ChangeFiles ChangeKind = "files_changed"
+
+	// ChangeModule means that a local module used by the stack changed because
+	// one of the modules it depends on has unmerged changes.
+	ChangeModule ChangeKind = "module_changed"
+
+	// ChangeUnmerged means that a local module used directly by the stack has
+	// unmerged changes.
+	ChangeUnmerged ChangeKind = "unmerged"
+
+	// ChangeModuleRef means that the ref (or source) of a remote module used
+	// by the stack, directly or through local modules, changed.
+	ChangeModuleRef ChangeKind = "module_ref_changed"
+
+	// ChangeSymlink means that a symlink inside the stack points to a file
+	// or directory of the repository which changed.
+	ChangeSymlink ChangeKind = "symlink_target_changed"
+
+	// ChangeTriggered means that the stack was triggered with a trigger file.
+	ChangeTriggered ChangeKind = "triggered"
+
+	// ChangeWatchFile means that a file watched by the stack changed.
+	ChangeWatchFile ChangeKind = "watch_file"
 )
 
 const errList errors.Kind = "listing stacks error"
@@ -148,6 +209,29 @@ func (m *Manager) ListChanged() (*Report, error) {
 		return nil, errors.E(errListChanged, err)
 	}
 
+	m.realRootDir, err = filepath.EvalSymlinks(m.root.HostDir())
+	if err != nil {
+		return nil, errors.E(errListChanged, err, "evaluating symlinks of project root")
+	}
+
+	m.changedFiles = map[string]struct{}{}
+	for _, path := range changedFiles {
+		m.changedFiles[filepath.Join(m.realRootDir, filepath.FromSlash(path))] = struct{}{}
+	}
+
+	m.symlinks = nil
+	if len(changedFiles) > 0 {
+		logger.Debug().Msg("List symlinks.")
+
+		symlinks, err := g.ListSymlinks()
+		if err != nil {
+			return nil, errors.E(errListChanged, err)
+		}
+		for _, link := range symlinks {
+			m.symlinks = append(m.symlinks, project.NewPath("/"+link))
+		}
+	}
+
 	stackSet := map[project.Path]Entry{}
 
 	for _, path := range changedFiles {
@@ -189,16 +273,30 @@ func (m *Manager) ListChanged() (*Report, error) {
 				return nil, errors.E(errListChanged, err)
 			}
 
+			triggerInfo, err := trigger.ParseFile(abspath)
+			if err != nil {
+				logger.Debug().Err(err).Msg("unable to parse trigger file, ignoring its reason")
+			}
+
 			stackSet[s.Dir] = Entry{
 				Stack:  s,
 				Reason: "stack has been triggered by: " + projpath.String(),
+				Change: Change{
+					Kind:          ChangeTriggered,
+					Paths:         project.Paths{projpath},
+					TriggerReason: triggerInfo.Reason,
+				},
 			}
 			continue
 		}
 
 		dirname := filepath.Dir(abspath)
 
-		if _, ok := stackSet[project.PrjAbsPath(m.root.HostDir(), dirname)]; ok {
+		if entry, ok := stackSet[project.PrjAbsPath(m.root.HostDir(), dirname)]; ok {
+			if entry.Change.Kind == ChangeFiles {
+				entry.Change.Paths = append(entry.Change.Paths, projpath)
+				stackSet[entry.Stack.Dir] = entry
+			}
 			continue
 		}
 
@@ -231,9 +329,19 @@ func (m *Manager) ListChanged() (*Report, error) {
 			return nil, errors.E(errListChanged, err)
 		}
 
+		if entry, ok := stackSet[s.Dir]; ok && entry.Change.Kind == ChangeFiles {
+			entry.Change.Paths = append(entry.Change.Paths, projpath)
+			stackSet[s.Dir] = entry
+			continue
+		}
+
 		stackSet[s.Dir] = Entry{
 			Stack:  s,
 			Reason: "stack has unmerged changes",
+			Change: Change{
+				Kind:  ChangeFiles,
+				Paths: project.Paths{projpath},
+			},
 		}
 	}
 
@@ -268,6 +376,37 @@ rangeStacks:
 					"stack changed because watched file %q changed",
 					changed,
 				),
+				Change: Change{
+					Kind:  ChangeWatchFile,
+					Paths: project.Paths{changed},
+				},
+			}
+			continue rangeStacks
+		}
+
+		link, target, changed, err := m.changedSymlink(stack)
+		if err != nil {
+			return nil, errors.E(errListChanged, err, "checking symlinks of stack %s", stack.Dir)
+		}
+
+		if changed {
+			logger.Debug().
+				Stringer("stack", stack).
+				Stringer("symlink", link).
+				Stringer("target", target).
+				Msg("symlink target changed.")
+
+			stack.IsChanged = true
+			stackSet[stack.Dir] = Entry{
+				Stack: stack,
+				Reason: fmt.Sprintf(
+					"stack changed because symlink %q points to changed %q",
+					link, target,
+				),
+				Change: Change{
+					Kind:  ChangeSymlink,
+					Paths: project.Paths{link, target},
+				},
 			}
 			continue rangeStacks
 		}
@@ -276,7 +415,7 @@ rangeStacks:
 			Stringer("stack", stack).
 			Msg("Apply function to stack.")
 
-		err := m.filesApply(stack.HostDir(m.root), func(file fs.DirEntry) error {
+		err = m.filesApply(stack.HostDir(m.root), func(file fs.DirEntry) error {
 			if path.Ext(file.Name()) != ".tf" {
 				return nil
 			}
@@ -293,7 +432,7 @@ rangeStacks:
 			}
 
 			for _, mod := range modules {
-				changed, why, err := m.moduleChanged(mod, stack.HostDir(m.root), make(map[string]bool))
+				changed, why, change, err := m.moduleChanged(mod, tfpath, make(map[string]bool))
 				if err != nil {
 					return errors.E(errListChanged, err, "checking module %q", mod.Source)
 				}
@@ -311,6 +450,7 @@ rangeStacks:
 							"stack changed because %q changed because %s",
 							mod.Source, why,
 						),
+						Change: change,
 					}
 					return nil
 				}
@@ -415,6 +555,78 @@ func (m *Manager) AddWantedOf(scopeStacks config.List[*config.SortableStack]) (c
 	return selectedStacks, nil
 }
 
+// AddDependentsOf returns the given stacks together with all stacks ordered
+// after them, directly or not, by the run order (after, before and parent
+// stacks).
+func (m *Manager) AddDependentsOf(scopeStacks config.List[*config.SortableStack]) (config.List[*config.SortableStack], error) {
+	return m.addOrderedOf(scopeStacks, func(d *dag.DAG, id dag.ID) []dag.ID {
+		return d.DescendantsOf(id)
+	})
+}
+
+// AddDependenciesOf returns the given stacks together with all stacks ordered
+// before them, directly or not, by the run order (after, before and parent
+// stacks).
+func (m *Manager) AddDependenciesOf(scopeStacks config.List[*config.SortableStack]) (config.List[*config.SortableStack], error) {
+	return m.addOrderedOf(scopeStacks, func(d *dag.DAG, id dag.ID) []dag.ID {
+		return d.AncestorsOf(id)
+	})
+}
+
+// addOrderedOf returns the given stacks together with all stacks reachable
+// from them in the run order DAG of the whole project by following the next
+// function.
+func (m *Manager) addOrderedOf(
+	scopeStacks config.List[*config.SortableStack],
+	next func(d *dag.DAG, id dag.ID) []dag.ID,
+) (config.List[*config.SortableStack], error) {
+	allstacks, err := config.LoadAllStacks(m.root.Tree())
+	if err != nil {
+		return nil, errors.E(err, "loading all stacks")
+	}
+
+	orderDag, reason, err := run.BuildOrderDAG(m.root, allstacks)
+	if err != nil {
+		if errors.IsKind(err, dag.ErrCycleDetected) {
+			return nil, errors.E(err, "building order DAG: cycle detected: %s", reason)
+		}
+		return nil, errors.E(err, "building order DAG")
+	}
+
+	var selectedStacks config.List[*config.SortableStack]
+	visited := dag.Visited{}
+	var pending []dag.ID
+	for _, s := range scopeStacks {
+		id := dag.ID(s.Dir().String())
+		if _, ok := visited[id]; ok {
+			continue
+		}
+		visited[id] = struct{}{}
+		selectedStacks = append(selectedStacks, s)
+		pending = append(pending, id)
+	}
+
+	for len(pending) > 0 {
+		id := pending[0]
+		pending = pending[1:]
+
+		for _, nextID := range next(orderDag, id) {
+			if _, ok := visited[nextID]; ok {
+				continue
+			}
+			visited[nextID] = struct{}{}
+
+			node, err := orderDag.Node(nextID)
+			if err != nil {
+				return nil, errors.E(err, "getting stack %s from order DAG", nextID)
+			}
+			selectedStacks = append(selectedStacks, node.(*config.Stack).Sortable())
+			pending = append(pending, nextID)
+		}
+	}
+	return selectedStacks, nil
+}
+
 func (m *Manager) filesApply(dir string, apply func(file fs.DirEntry) error) error {
 	logger := log.With().
 		Str("action", "filesApply()").
@@ -445,34 +657,39 @@ func (m *Manager) filesApply(dir string, apply func(file fs.DirEntry) error) err
 }
 
 // moduleChanged recursively check if the module mod or any of the modules it
-// uses has changed. All .tf files of the module are parsed and this function is
-// called recursively. The visited keep track of the modules already parsed to
-// avoid infinite loops.
+// uses has changed. If the module has no unmerged changes then all its .tf
+// files are parsed and this function is called recursively. The visited keep
+// track of the modules already parsed to avoid infinite loops. The tfpath is
+// the file declaring the module. Remote modules are changed if their source
+// is not declared in the file at the base ref.
 func (m *Manager) moduleChanged(
-	mod tf.Module, basedir string, visited map[string]bool,
-) (changed bool, why string, err error) {
+	mod tf.Module, tfpath string, visited map[string]bool,
+) (changed bool, why string, change Change, err error) {
 	logger := log.With().
 		Str("action", "moduleChanged()").
 		Logger()
 
-	if _, ok := visited[mod.Source]; ok {
-		return false, "", nil
-	}
-
 	if !mod.IsLocal() {
-		// if the source is a remote path (URL, VCS path, S3 bucket, etc) then
-		// we assume it's not changed.
-		return false, "", nil
+		return m.remoteModuleChanged(mod, tfpath)
 	}
 
-	modPath := filepath.Join(basedir, mod.Source)
+	// the module dir (or any of its parents) can be a symlink, then the
+	// real path is the one checked for changes and used to detect cycles.
+	modPath, err := filepath.EvalSymlinks(filepath.Join(filepath.Dir(tfpath), mod.Source))
+	if err != nil {
+		return false, "", Change{}, errors.E(err, "\"source\" path %q is not a directory",
+			filepath.Join(filepath.Dir(tfpath), mod.Source))
+	}
 
-	st, err := os.Stat(modPath)
+	if _, ok := visited[modPath]; ok {
+		return false, "", Change{}, nil
+	}
 
-	// TODO(i4k): resolve symlinks
+	modPrjPath := project.PrjAbsPath(m.realRootDir, modPath)
 
+	st, err := os.Stat(modPath)
 	if err != nil || !st.IsDir() {
-		return false, "", errors.E("\"source\" path %q is not a directory", modPath)
+		return false, "", Change{}, errors.E("\"source\" path %q is not a directory", modPath)
 	}
 
 	logger.Debug().
@@ -480,20 +697,17 @@ func (m *Manager) moduleChanged(
 		Msg("Get list of changed files.")
 	changedFiles, err := m.listChangedFiles(modPath, m.gitBaseRef)
 	if err != nil {
-		return false, "", errors.E(err,
+		return false, "", Change{}, errors.E(err,
 			"listing changes in the module %q",
 			mod.Source)
 	}
 
+	visited[modPath] = true
+
 	if len(changedFiles) > 0 {
-		return true, fmt.Sprintf("module %q has unmerged changes", mod.Source), nil
+		return m.unmergedModuleChanged(mod, modPath, changedFiles)
 	}
 
-	visited[mod.Source] = true
-
-	logger.Debug().
-		Str("path", modPath).
-		Msg("Apply function to files in path.")
 	err = m.filesApply(modPath, func(file fs.DirEntry) error {
 		if changed {
 			return nil
@@ -502,21 +716,32 @@ func (m *Manager) moduleChanged(
 			return nil
 		}
 
-		modules, err := tf.ParseModules(filepath.Join(modPath, file.Name()))
+		modfile := filepath.Join(modPath, file.Name())
+		modules, err := tf.ParseModules(modfile)
 		if err != nil {
 			return errors.E(err, "parsing module %q", mod.Source)
 		}
 
 		for _, mod2 := range modules {
-			var reason string
+			var (
+				reason    string
+				subchange Change
+			)
 
-			changed, reason, err = m.moduleChanged(mod2, modPath, visited)
+			changed, reason, subchange, err = m.moduleChanged(mod2, modfile, visited)
 			if err != nil {
 				return err
 			}
 
 			if changed {
 				why = fmt.Sprintf("%s%s changed because %s ", why, mod.Source, reason)
+				change = Change{
+					Kind:  subchange.Kind,
+					Paths: append(project.Paths{modPrjPath}, subchange.Paths...),
+				}
+				if change.Kind == ChangeUnmerged {
+					change.Kind = ChangeModule
+				}
 				return nil
 			}
 		}
@@ -525,10 +750,198 @@ func (m *Manager) moduleChanged(
 	})
 
 	if err != nil {
-		return false, "", err
+		return false, "", Change{}, err
+	}
+
+	return changed, fmt.Sprintf("module %q changed because %s", mod.Source, why), change, nil
+}
+
+// changedSymlink looks for symlinks tracked by git inside the stack directory
+// (child stacks and hidden directories are not inspected) pointing to a
+// changed file or to a directory with changed files of the repository. Broken
+// symlinks and symlink cycles are ignored.
+func (m *Manager) changedSymlink(stack *config.Stack) (link, target project.Path, changed bool, err error) {
+	logger := log.With().
+		Str("action", "changedSymlink()").
+		Stringer("stack", stack.Dir).
+		Logger()
+
+	for _, link := range m.symlinks {
+		if !m.ownsPath(stack, link) {
+			continue
+		}
+
+		realpath, err := filepath.EvalSymlinks(link.HostPath(m.root.HostDir()))
+		if err != nil {
+			logger.Debug().
+				Err(err).
+				Stringer("symlink", link).
+				Msg("ignoring broken symlink")
+			continue
+		}
+
+		if isInsideDir(m.realRootDir, realpath) && m.hasChanges(realpath) {
+			return link, project.PrjAbsPath(m.realRootDir, realpath), true, nil
+		}
+	}
+	return project.Path{}, project.Path{}, false, nil
+}
+
+// ownsPath tells if the file is inside the stack directory and not inside
+// one of its child stacks or hidden directories.
+func (m *Manager) ownsPath(stack *config.Stack, file project.Path) bool {
+	if stack.Dir.String() != "/" && !file.HasPrefix(stack.Dir.String()+"/") {
+		return false
+	}
+	for dir := file.Dir(); dir != stack.Dir; dir = dir.Dir() {
+		if strings.HasPrefix(path.Base(dir.String()), ".") {
+			return false
+		}
+		tree, found := m.root.Lookup(dir)
+		if found && tree.IsStack() {
+			return false
+		}
+	}
+	return true
+}
+
+// hasChanges tells if the real path is a changed file or a directory
+// containing changed files.
+func (m *Manager) hasChanges(realpath string) bool {
+	if _, ok := m.changedFiles[realpath]; ok {
+		return true
+	}
+	for file := range m.changedFiles {
+		if isInsideDir(realpath, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// unmergedModuleChanged returns the change of a local module with unmerged
+// changes. The module is reported as changed by the ref of a remote module if
+// one of its changed files bumps the ref, otherwise by its unmerged changes.
+// Its nested local modules are not inspected because the module is changed
+// anyway.
+func (m *Manager) unmergedModuleChanged(
+	mod tf.Module, modPath string, changedFiles []string,
+) (changed bool, why string, change Change, err error) {
+	modPrjPath := project.PrjAbsPath(m.realRootDir, modPath)
+
+	for _, changedFile := range changedFiles {
+		if path.Ext(changedFile) != ".tf" || path.Dir(changedFile) != "." {
+			continue
+		}
+
+		modfile := filepath.Join(modPath, changedFile)
+		if _, err := os.Stat(modfile); err != nil {
+			// removed files have no modules.
+			continue
+		}
+
+		modules, err := tf.ParseModules(modfile)
+		if err != nil {
+			return false, "", Change{}, errors.E(err, "parsing module %q", mod.Source)
+		}
+
+		for _, mod2 := range modules {
+			if mod2.IsLocal() {
+				continue
+			}
+
+			changed, reason, subchange, err := m.remoteModuleChanged(mod2, modfile)
+			if err != nil {
+				return false, "", Change{}, err
+			}
+			if changed {
+				return true, fmt.Sprintf("module %q changed because %s", mod.Source, reason), Change{
+					Kind:  subchange.Kind,
+					Paths: append(project.Paths{modPrjPath}, subchange.Paths...),
+				}, nil
+			}
+		}
+	}
+
+	return true, fmt.Sprintf("module %q has unmerged changes", mod.Source), Change{
+		Kind:  ChangeUnmerged,
+		Paths: project.Paths{modPrjPath},
+	}, nil
+}
+
+// remoteModuleChanged checks if the remote module declared in the tfpath file
+// is declared with a different source or ref than the modules of the file at
+// the base ref. Only Git sources are supported, other remote sources are never
+// changed.
+func (m *Manager) remoteModuleChanged(
+	mod tf.Module, tfpath string,
+) (changed bool, why string, change Change, err error) {
+	logger := log.With().
+		Str("action", "remoteModuleChanged()").
+		Str("module", mod.Name).
+		Str("source", mod.Source).
+		Logger()
+
+	src, err := tf.ParseSource(mod.Source)
+	if err != nil {
+		logger.Debug().Err(err).Msg("ignoring unsupported module source")
+		return false, "", Change{}, nil
+	}
+
+	// the file can be a symlink to a shared file, so the real file needs to
+	// be checked.
+	realpath, err := filepath.EvalSymlinks(tfpath)
+	if err != nil {
+		return false, "", Change{}, errors.E(err, "evaluating symlinks of %q", tfpath)
 	}
 
-	return changed, fmt.Sprintf("module %q changed because %s", mod.Source, why), nil
+	if _, ok := m.changedFiles[realpath]; !ok {
+		return false, "", Change{}, nil
+	}
+
+	g, err := m.gitAt(filepath.Dir(realpath))
+	if err != nil {
+		return false, "", Change{}, err
+	}
+
+	baseContent, err := g.ShowFile(m.gitBaseRef, filepath.Base(realpath))
+	if err != nil {
+		// the file is new, then any module on it is new as well and
+		// the change is detected by the changed files.
+		logger.Debug().Err(err).Msg("file not found at base ref")
+		return false, "", Change{}, nil
+	}
+
+	baseModules, err := tf.ParseModulesContent(tfpath, []byte(baseContent))
+	if err != nil {
+		logger.Debug().Err(err).Msg("ignoring unparseable file at base ref")
+		return false, "", Change{}, nil
+	}
+
+	// the module is matched by its full source, including the ref, so
+	// renaming the module block or reordering the blocks is not a change.
+	for _, baseMod := range baseModules {
+		if baseMod.Source == mod.Source {
+			return false, "", Change{}, nil
+		}
+	}
+
+	why = fmt.Sprintf("module %q is not declared at the base ref", mod.Source)
+	for _, baseMod := range baseModules {
+		baseSrc, err := tf.ParseSource(baseMod.Source)
+		if err == nil && baseSrc.URL == src.URL && baseSrc.Subdir == src.Subdir {
+			why = fmt.Sprintf("module %q ref changed from %q to %q", src.URL, baseSrc.Ref, src.Ref)
+			break
+		}
+		if baseMod.Name == mod.Name {
+			why = fmt.Sprintf("module %q source changed from %q", mod.Source, baseMod.Source)
+		}
+	}
+
+	return true, why, Change{
+		Kind:  ChangeModuleRef,
+		Paths: project.Paths{project.PrjAbsPath(m.realRootDir, realpath)},
+	}, nil
 }
 
 // listChangedFiles lists all changed files in the dir directory.
@@ -542,17 +955,7 @@ func (m *Manager) listChangedFiles(dir string, gitBaseRef string) ([]string, err
 		return nil, errors.E("is not a directory")
 	}
 
-	gOuter, err := m.globalGit()
-	if err != nil {
-		return nil, errors.E(errListChanged, err)
-	}
-
-	globalArgs := setupInheritedGitConfigArgs(gOuter)
-
-	g, err := git.WithConfig(git.Config{
-		WorkingDir: dir,
-		GlobalArgs: globalArgs,
-	})
+	g, err := m.gitAt(dir)
 	if err != nil {
 		return nil, err
 	}
@@ -574,6 +977,20 @@ func (m *Manager) listChangedFiles(dir string, gitBaseRef string) ([]string, err
 	return g.DiffNames(baseRef, headRef)
 }
 
+// gitAt returns a git wrapper with the given working dir, which inherits
+// the relevant configuration of the global git.
+func (m *Manager) gitAt(dir string) (*git.Git, error) {
+	gOuter, err := m.globalGit()
+	if err != nil {
+		return nil, errors.E(errListChanged, err)
+	}
+
+	return git.WithConfig(git.Config{
+		WorkingDir: dir,
+		GlobalArgs: setupInheritedGitConfigArgs(gOuter),
+	})
+}
+
 func (m *Manager) globalGit() (*git.Git, error) {
 	var err error
 	if m.outerGit == nil {
@@ -585,17 +1002,32 @@ func (m *Manager) globalGit() (*git.Git, error) {
 	return m.outerGit, err
 }
 
+// hasChangedWatchedFiles returns the first changed file matching any of the
+// stack.watch entries and none of the negated ones. Watch entries can be
+// files, directories (matching every file inside it) or glob patterns.
 func hasChangedWatchedFiles(stack *config.Stack, changedFiles []string) (project.Path, bool) {
-	for _, watchFile := range stack.Watch {
-		for _, file := range changedFiles {
-			if file == watchFile.String()[1:] { // project paths
-				return watchFile, true
-			}
+	if len(stack.Watch) == 0 {
+		return project.Path{}, false
+	}
+	watch := watchMatcher(stack.Watch)
+	exclude := watchMatcher(stack.WatchExclude)
+	for _, file := range changedFiles {
+		elems := strings.Split(file, "/")
+		if watch.Match(elems, false) && !exclude.Match(elems, false) {
+			return project.NewPath("/" + file), true
 		}
 	}
 	return project.Path{}, false
 }
 
+func watchMatcher(paths project.Paths) gitignore.Matcher {
+	patterns := make([]gitignore.Pattern, len(paths))
+	for i, p := range paths {
+		patterns[i] = gitignore.ParsePattern(p.String(), nil)
+	}
+	return gitignore.NewMatcher(patterns)
+}
+
 func checkRepoIsClean(g *git.Git) (RepoChecks, error) {
 	logger := log.With().
 		Str("action", "checkRepoIsClean()").
@@ -639,6 +1071,10 @@ func setupInheritedGitConfigArgs(git *git.Git) []string {
 	return r
 }
 
+func isInsideDir(dir, path string) bool {
+	return strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
 // EntrySlice implements the Sort interface.
 type EntrySlice []Entry
 
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/madlambda/spells/assert"
 	"github.com/terramate-io/terramate/config"
 	"github.com/terramate-io/terramate/project"
@@ -176,6 +177,10 @@ func TestListChangedStackReason(t *testing.T) {
 	assert.EqualInts(t, 1, len(changed), "unexpected number of entries")
 	assert.EqualStrings(t, "/", changed[0].Stack.Dir.String(), "stack dir mismatch")
 	assert.EqualStrings(t, "stack has unmerged changes", changed[0].Reason)
+	assert.EqualStrings(t, string(stack.ChangeFiles), string(changed[0].Change.Kind))
+	if len(changed[0].Change.Paths) == 0 {
+		t.Fatal("expected the changed files in the change reason")
+	}
 
 	repo = singleStackDependentModuleChangedRepo(t)
 
@@ -191,6 +196,103 @@ func TestListChangedStackReason(t *testing.T) {
 		!strings.Contains(changed[0].Reason, "../module2") {
 		t.Fatalf("unexpected reason %q (modules: %+v)", changed[0].Reason, repo.modules)
 	}
+	assert.EqualStrings(t, string(stack.ChangeModule), string(changed[0].Change.Kind))
+	assert.EqualInts(t, 2, len(changed[0].Change.Paths), "unexpected module chain: %v",
+		changed[0].Change.Paths)
+}
+
+func TestListChangedRemoteModuleRef(t *testing.T) {
+	t.Parallel()
+
+	repodir := remoteModuleBumpRepo(t, `
+module "remote" {
+	source = "github.com/terramate-io/example?ref=v1.1.0"
+}
+`)
+
+	m := newManager(t, repodir)
+	report, err := m.ListChanged()
+	assert.NoError(t, err, "unexpected error")
+
+	changed := report.Stacks
+	assert.EqualInts(t, 1, len(changed), "unexpected number of entries")
+	assert.EqualStrings(t, "/stack", changed[0].Stack.Dir.String(), "stack dir mismatch")
+	assert.EqualStrings(t, string(stack.ChangeModuleRef), string(changed[0].Change.Kind))
+
+	if !strings.Contains(changed[0].Reason, `ref changed from "v1.0.0" to "v1.1.0"`) {
+		t.Fatalf("unexpected reason %q", changed[0].Reason)
+	}
+
+	want := []string{"/modules/module1", "/modules/module1/main.tf"}
+	if diff := cmp.Diff(want, changed[0].Change.Paths.Strings()); diff != "" {
+		t.Fatalf("unexpected change paths (-want +got):\n%s", diff)
+	}
+}
+
+func TestListChangedRemoteModuleRenamedIsNotRefChange(t *testing.T) {
+	t.Parallel()
+
+	repodir := remoteModuleBumpRepo(t, `
+module "renamed" {
+	source = "github.com/terramate-io/example?ref=v1.0.0"
+}
+`)
+
+	m := newManager(t, repodir)
+	report, err := m.ListChanged()
+	assert.NoError(t, err, "unexpected error")
+
+	changed := report.Stacks
+	assert.EqualInts(t, 1, len(changed), "unexpected number of entries")
+	assert.EqualStrings(t, "/stack", changed[0].Stack.Dir.String(), "stack dir mismatch")
+	assert.EqualStrings(t, string(stack.ChangeUnmerged), string(changed[0].Change.Kind))
+}
+
+func TestListChangedUnmergedModuleParseError(t *testing.T) {
+	t.Parallel()
+
+	repodir := remoteModuleBumpRepo(t, `
+module "remote" {
+`)
+
+	m := newManager(t, repodir)
+	_, err := m.ListChanged()
+	assert.Error(t, err, "want error parsing the changed module")
+}
+
+// remoteModuleBumpRepo creates a repository with a stack using a local module
+// which uses a remote module. The main.tf of the local module is replaced by
+// modContent in a branch not merged yet.
+func remoteModuleBumpRepo(t *testing.T, modContent string) string {
+	repo := singleMergeCommitRepoNoStack(t)
+	modules := test.Mkdir(t, repo.Dir, "modules")
+	module1 := test.Mkdir(t, modules, "module1")
+	stackdir := test.Mkdir(t, repo.Dir, "stack")
+	root, err := config.LoadRoot(repo.Dir)
+	assert.NoError(t, err)
+	createStack(t, root, stackdir)
+
+	test.WriteFile(t, stackdir, "main.tf", `
+module "something" {
+	source = "../modules/module1"
+}
+`)
+	test.WriteFile(t, module1, "main.tf", `
+module "remote" {
+	source = "github.com/terramate-io/example?ref=v1.0.0"
+}
+`)
+
+	g := test.NewGitWrapper(t, repo.Dir, []string{})
+	assert.NoError(t, g.Add(repo.Dir), "add files")
+	assert.NoError(t, g.Commit("files"), "commit files")
+	assert.NoError(t, g.Push("origin", "main"))
+	assert.NoError(t, g.Checkout("bump-module", true), "failed to create branch")
+
+	mainFile := test.WriteFile(t, module1, "main.tf", modContent)
+	assert.NoError(t, g.Add(mainFile), "add main.tf")
+	assert.NoError(t, g.Commit("change module"), "commit main.tf")
+	return repo.Dir
 }
 
 func assertStacks(
@@ -0,0 +1,130 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package stdlib
+
+import (
+	"sync"
+
+	"github.com/terramate-io/terramate/errors"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// Call is a call of a function whose result doesn't depend only on its
+// arguments but also on the filesystem or on the clock, eg.: tm_file.
+type Call struct {
+	// Name is the name of the called function.
+	Name string
+	// Args are the arguments of the call.
+	Args []cty.Value
+	// Result is the value returned by the call.
+	Result cty.Value
+	// Impure tells if the function may return a different result for the same
+	// arguments and filesystem, like tm_timestamp and tm_uuid.
+	Impure bool
+}
+
+type callObserver struct {
+	fn func(Call)
+}
+
+var (
+	// callObservers are the registered call observers by basedir.
+	callObservers   = map[string][]*callObserver{}
+	callObserversMu sync.RWMutex
+)
+
+// observedFuncNames are the functions whose calls are observable.
+var observedFuncNames = map[string]bool{
+	"tm_file":             false,
+	"tm_fileexists":       false,
+	"tm_fileset":          false,
+	"tm_filebase64":       false,
+	"tm_filebase64sha256": false,
+	"tm_filebase64sha512": false,
+	"tm_filemd5":          false,
+	"tm_filesha1":         false,
+	"tm_filesha256":       false,
+	"tm_filesha512":       false,
+	"tm_templatefile":     false,
+	"tm_timestamp":        true,
+	"tm_uuid":             true,
+	"tm_bcrypt":           true,
+}
+
+// ObserveCalls registers fn to be called on every successful call of the
+// filesystem and impure functions returned by [Functions] for the given
+// basedir. The returned function unregisters the observer.
+//
+// Since each stack is evaluated with its own basedir, this allows to know
+// which files were read while evaluating the configuration of a stack.
+func ObserveCalls(basedir string, fn func(Call)) (unregister func()) {
+	observer := &callObserver{fn: fn}
+
+	callObserversMu.Lock()
+	callObservers[basedir] = append(callObservers[basedir], observer)
+	callObserversMu.Unlock()
+
+	return func() {
+		callObserversMu.Lock()
+		defer callObserversMu.Unlock()
+
+		observers := callObservers[basedir]
+		for i, o := range observers {
+			if o == observer {
+				observers = append(observers[:i:i], observers[i+1:]...)
+				break
+			}
+		}
+		if len(observers) == 0 {
+			delete(callObservers, basedir)
+			return
+		}
+		callObservers[basedir] = observers
+	}
+}
+
+// Recall calls again the function of the given call with the same arguments
+// and the given basedir, returning its current result.
+func Recall(basedir string, call Call) (cty.Value, error) {
+	fn, ok := Functions(basedir)[call.Name]
+	if !ok {
+		return cty.NilVal, errors.E("function %s not found", call.Name)
+	}
+	return fn.Call(call.Args)
+}
+
+func notifyCall(basedir string, call Call) {
+	callObserversMu.RLock()
+	observers := callObservers[basedir]
+	callObserversMu.RUnlock()
+
+	for _, o := range observers {
+		o.fn(call)
+	}
+}
+
+// observedFunc wraps fn so its calls are notified to the observers of basedir.
+func observedFunc(basedir, name string, fn function.Function, impure bool) function.Function {
+	return function.New(&function.Spec{
+		Params:   fn.Params(),
+		VarParam: fn.VarParam(),
+		Type: func(args []cty.Value) (cty.Type, error) {
+			return fn.ReturnTypeForValues(args)
+		},
+		Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+			result, err := fn.Call(args)
+			if err != nil {
+				return result, err
+			}
+			notifyCall(basedir, Call{
+				Name:   name,
+				Args:   append([]cty.Value{}, args...),
+				Result: result,
+				Impure: impure,
+			})
+			return result, nil
+		},
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sync"
 
 	resyntax "regexp/syntax"
 
@@ -25,12 +26,30 @@ import (
 	"github.com/zclconf/go-cty/cty/function"
 )
 
-var regexCache map[string]*regexp.Regexp
+var (
+	// regexCache is shared by all evaluations, which may happen concurrently
+	// when generating code.
+	regexCache   map[string]*regexp.Regexp
+	regexCacheMu sync.RWMutex
+)
 
 func init() {
 	regexCache = map[string]*regexp.Regexp{}
 }
 
+func cachedRegex(pattern string) (*regexp.Regexp, bool) {
+	regexCacheMu.RLock()
+	defer regexCacheMu.RUnlock()
+	re, ok := regexCache[pattern]
+	return re, ok
+}
+
+func cacheRegex(pattern string, re *regexp.Regexp) {
+	regexCacheMu.Lock()
+	defer regexCacheMu.Unlock()
+	regexCache[pattern] = re
+}
+
 // Functions returns all the Terramate default functions.
 // The `basedir` must be an absolute path for an existent directory or it panics.
 func Functions(basedir string) map[string]function.Function {
@@ -68,6 +87,12 @@ func Functions(basedir string) map[string]function.Function {
 	tmfuncs["tm_ternary"] = TernaryFunc()
 
 	tmfuncs["tm_version_match"] = VersionMatch()
+
+	for name, impure := range observedFuncNames {
+		if fn, ok := tmfuncs[name]; ok {
+			tmfuncs[name] = observedFunc(basedir, name, fn, impure)
+		}
+	}
 	return tmfuncs
 }
 
@@ -126,7 +151,7 @@ func Regex() function.Function {
 				return cty.DynamicVal, nil
 			}
 
-			re, ok := regexCache[args[0].AsString()]
+			re, ok := cachedRegex(args[0].AsString())
 			if !ok {
 				panic("should be in the cache")
 			}
@@ -150,7 +175,7 @@ func Regex() function.Function {
 // Returns an error if parsing fails or if the pattern uses a mixture of
 // named and unnamed capture groups, which is not permitted.
 func regexPatternResultType(pattern string) (cty.Type, error) {
-	re, ok := regexCache[pattern]
+	re, ok := cachedRegex(pattern)
 	if !ok {
 		var rawErr error
 		re, rawErr = regexp.Compile(pattern)
@@ -163,7 +188,7 @@ func regexPatternResultType(pattern string) (cty.Type, error) {
 			return cty.NilType, fmt.Errorf("error parsing pattern: %s", err)
 		}
 
-		regexCache[pattern] = re
+		cacheRegex(pattern, re)
 	}
 
 	allNames := re.SubexpNames()[1:]
@@ -68,6 +68,7 @@ func AssertTerramateConfig(t *testing.T, got, want hcl.Config) {
 	AssertDiff(t, got.Vendor, want.Vendor, "terramate vendor")
 	assertGenHCLBlocks(t, got.Generate.HCLs, want.Generate.HCLs)
 	assertGenFileBlocks(t, got.Generate.Files, want.Generate.Files)
+	assertGenDataBlocks(t, got.Generate.Data, want.Generate.Data)
 	assertScriptBlocks(t, got.Scripts, want.Scripts)
 }
 
@@ -246,6 +247,7 @@ func assertTerramateConfigBlock(t *testing.T, got, want *hcl.RootConfig) {
 
 	assertTerramateRunBlock(t, got.Run, want.Run)
 	assertTerramateCloudBlock(t, got.Cloud, want.Cloud)
+	assertTerramateStacksBlock(t, got.Stacks, want.Stacks)
 }
 
 func assertGenHCLBlocks(t *testing.T, got, want []hcl.GenHCLBlock) {
@@ -276,6 +278,20 @@ func assertGenFileBlocks(t *testing.T, got, want []hcl.GenFileBlock) {
 	}
 }
 
+func assertGenDataBlocks(t *testing.T, got, want []hcl.GenDataBlock) {
+	t.Helper()
+
+	assert.EqualInts(t, len(want), len(got), "gendata blocks differ in len")
+
+	for i, gotBlock := range got {
+		wantBlock := want[i]
+		AssertEqualRanges(t, gotBlock.Range, wantBlock.Range, "gendata range differs")
+		assert.EqualStrings(t, wantBlock.Label, gotBlock.Label, "gendata label differs")
+		assert.EqualStrings(t, wantBlock.Format, gotBlock.Format, "gendata format differs")
+		assertAssertsBlock(t, gotBlock.Asserts, wantBlock.Asserts, "gendata asserts")
+	}
+}
+
 func assertScriptBlocks(t *testing.T, got, want []*hcl.Script) {
 	t.Helper()
 
@@ -337,6 +353,8 @@ func assertTerramateRunBlock(t *testing.T, got, want *hcl.RunConfig) {
 		"want.Run.CheckGenCode %v != got.Run.CheckGenCode %v",
 		want.CheckGenCode, got.CheckGenCode)
 
+	AssertDiff(t, got.RunPolicy, want.RunPolicy, "run policy mismatch")
+
 	if (want.Env == nil) != (got.Env == nil) {
 		t.Fatalf(
 			"want.Run.Env[%+v] != got.Run.Env[%+v]",
@@ -376,6 +394,22 @@ func assertTerramateCloudBlock(t *testing.T, got, want *hcl.CloudConfig) {
 	}
 }
 
+func assertTerramateStacksBlock(t *testing.T, got, want *hcl.StacksConfig) {
+	t.Helper()
+
+	if (want == nil) != (got == nil) {
+		t.Fatalf("want.Stacks[%+v] != got.Stacks[%+v]", want, got)
+	}
+
+	if want == nil {
+		return
+	}
+
+	if !slices.Equal(want.ExportedGlobals, got.ExportedGlobals) {
+		t.Fatalf("want.Stacks[%+v] != got.Stacks[%+v]", want, got)
+	}
+}
+
 // hclFromAttributes ensures that we always build the same HCL document
 // given an hcl.Attributes.
 func hclFromAttributes(t *testing.T, attrs ast.Attributes) string {
@@ -404,6 +438,8 @@ func assertStackBlock(t *testing.T, got, want *hcl.Stack) {
 	for i, w := range want.After {
 		assert.EqualStrings(t, w, got.After[i], "stack after mismatch")
 	}
+
+	AssertDiff(t, got.Run, want.Run, "stack run policy mismatch")
 }
 
 // WriteRootConfig writes a basic terramate root config.
@@ -421,7 +457,7 @@ func removeTerramateHCLHeader(code string) string {
 	lines := []string{}
 
 	for _, line := range strings.Split(code, "\n") {
-		if strings.HasPrefix(line, "// TERRAMATE") {
+		if strings.HasPrefix(line, "// TERRAMATE") || strings.HasPrefix(line, "# TERRAMATE") {
 			continue
 		}
 		lines = append(lines, line)
@@ -49,6 +49,12 @@ func FixRangesOnConfig(dir string, cfg hcl.Config) {
 
 		fixRangeOnAsserts(dir, cfg.Generate.HCLs[i].Asserts)
 	}
+	for i := range cfg.Generate.Data {
+		cfg.Generate.Data[i].Range = FixRange(dir,
+			cfg.Generate.Data[i].Range)
+
+		fixRangeOnAsserts(dir, cfg.Generate.Data[i].Asserts)
+	}
 }
 
 // FixRange fix the given range.
@@ -71,6 +71,16 @@ func GenerateFile(builders ...hclwrite.BlockBuilder) *hclwrite.Block {
 	return Block("generate_file", builders...)
 }
 
+// GenerateJSON is a helper for a "generate_json" block.
+func GenerateJSON(builders ...hclwrite.BlockBuilder) *hclwrite.Block {
+	return Block("generate_json", builders...)
+}
+
+// GenerateYAML is a helper for a "generate_yaml" block.
+func GenerateYAML(builders ...hclwrite.BlockBuilder) *hclwrite.Block {
+	return Block("generate_yaml", builders...)
+}
+
 // Content is a helper for a "content" block.
 func Content(builders ...hclwrite.BlockBuilder) *hclwrite.Block {
 	return Block("content", builders...)
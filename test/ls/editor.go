@@ -127,14 +127,80 @@ func (e *Editor) Change(path, content string) {
 	assert.NoError(t, err, "call %q", lsp.MethodTextDocumentDidChange)
 }
 
+// Close sends a didClose request to the language server.
+func (e *Editor) Close(path string) {
+	t := e.t
+	t.Helper()
+	abspath := filepath.Join(e.sandbox.RootDir(), path)
+	var closeResult interface{}
+	_, err := e.call(lsp.MethodTextDocumentDidClose, lsp.DidCloseTextDocumentParams{
+		TextDocument: lsp.TextDocumentIdentifier{
+			URI: uri.File(abspath),
+		},
+	}, &closeResult)
+	assert.NoError(t, err, "call %q", lsp.MethodTextDocumentDidClose)
+}
+
+// Completion sends a completion request to the language server and returns
+// the completion items.
+func (e *Editor) Completion(path string, line, character uint32) []lsp.CompletionItem {
+	t := e.t
+	t.Helper()
+	var result lsp.CompletionList
+	_, err := e.call(lsp.MethodTextDocumentCompletion, lsp.CompletionParams{
+		TextDocumentPositionParams: e.positionParams(path, line, character),
+	}, &result)
+	assert.NoError(t, err, "calling %s", lsp.MethodTextDocumentCompletion)
+	return result.Items
+}
+
+// Hover sends a hover request to the language server and returns its result.
+func (e *Editor) Hover(path string, line, character uint32) *lsp.Hover {
+	t := e.t
+	t.Helper()
+	var result *lsp.Hover
+	_, err := e.call(lsp.MethodTextDocumentHover, lsp.HoverParams{
+		TextDocumentPositionParams: e.positionParams(path, line, character),
+	}, &result)
+	assert.NoError(t, err, "calling %s", lsp.MethodTextDocumentHover)
+	return result
+}
+
+// Definition sends a definition request to the language server and returns
+// the location found.
+func (e *Editor) Definition(path string, line, character uint32) *lsp.Location {
+	t := e.t
+	t.Helper()
+	var result *lsp.Location
+	_, err := e.call(lsp.MethodTextDocumentDefinition, lsp.DefinitionParams{
+		TextDocumentPositionParams: e.positionParams(path, line, character),
+	}, &result)
+	assert.NoError(t, err, "calling %s", lsp.MethodTextDocumentDefinition)
+	return result
+}
+
+func (e *Editor) positionParams(path string, line, character uint32) lsp.TextDocumentPositionParams {
+	return lsp.TextDocumentPositionParams{
+		TextDocument: lsp.TextDocumentIdentifier{
+			URI: uri.File(filepath.Join(e.sandbox.RootDir(), path)),
+		},
+		Position: lsp.Position{
+			Line:      line,
+			Character: character,
+		},
+	}
+}
+
 // DefaultInitializeResult is the default server response for the initialization
 // request.
 func DefaultInitializeResult() lsp.InitializeResult {
 	return lsp.InitializeResult{
 		Capabilities: lsp.ServerCapabilities{
-			CompletionProvider: &lsp.CompletionOptions{},
-			DefinitionProvider: false,
-			HoverProvider:      false,
+			CompletionProvider: &lsp.CompletionOptions{
+				TriggerCharacters: []string{"."},
+			},
+			DefinitionProvider: true,
+			HoverProvider:      true,
 			TextDocumentSync: map[string]interface{}{
 				"change":    float64(1),
 				"openClose": true,
@@ -17,6 +17,7 @@ import (
 // Module represents a terraform module.
 // Note that only the fields relevant for terramate are declared here.
 type Module struct {
+	Name   string // Name is the label of the module block.
 	Source string // Source is the module source path (eg.: directory, git path, etc).
 }
 
@@ -42,11 +43,26 @@ func ParseModules(path string) ([]Module, error) {
 		return nil, errors.E(err, "stat failed on %q", path)
 	}
 
-	p := hclparse.NewParser()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.E(err, "reading %q", path)
+	}
 
 	logger.Debug().Msg("Parse HCL file")
 
-	f, diags := p.ParseHCLFile(path)
+	return ParseModulesContent(path, content)
+}
+
+// ParseModulesContent parses blocks of type "module" containing a single label
+// from the given content. The path is only used for error reporting.
+func ParseModulesContent(path string, content []byte) ([]Module, error) {
+	logger := log.With().
+		Str("action", "ParseModulesContent()").
+		Str("path", path).
+		Logger()
+
+	p := hclparse.NewParser()
+	f, diags := p.ParseHCL(content, path)
 	if diags.HasErrors() {
 		return nil, errors.E(ErrHCLSyntax, diags)
 	}
@@ -85,7 +101,10 @@ func ParseModules(path string) ([]Module, error) {
 
 			continue
 		}
-		modules = append(modules, Module{Source: source})
+		modules = append(modules, Module{
+			Name:   moduleName,
+			Source: source,
+		})
 	}
 
 	return modules, nil
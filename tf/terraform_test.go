@@ -69,6 +69,7 @@ func TestHCLParserModules(t *testing.T) {
 			want: want{
 				modules: []tf.Module{
 					{
+						Name:   "test",
 						Source: "",
 					},
 				},
@@ -83,6 +84,7 @@ func TestHCLParserModules(t *testing.T) {
 			want: want{
 				modules: []tf.Module{
 					{
+						Name:   "test",
 						Source: "test",
 					},
 				},
@@ -103,6 +105,7 @@ func TestHCLParserModules(t *testing.T) {
 			want: want{
 				modules: []tf.Module{
 					{
+						Name:   "test",
 						Source: "test",
 					},
 				},
@@ -126,9 +129,11 @@ module "bleh" {
 			want: want{
 				modules: []tf.Module{
 					{
+						Name:   "test",
 						Source: "test",
 					},
 					{
+						Name:   "bleh",
 						Source: "bleh",
 					},
 				},
@@ -211,6 +216,8 @@ module "test" {
 				"got: %v, want: %v", modules, tc.want.modules)
 
 			for i := 0; i < len(tc.want.modules); i++ {
+				assert.EqualStrings(t, tc.want.modules[i].Name, modules[i].Name,
+					"module name mismatch")
 				assert.EqualStrings(t, tc.want.modules[i].Source, modules[i].Source,
 					"module source mismatch")
 			}